@@ -0,0 +1,701 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Node is a node of the syntax tree of a statement. Pos is the position of
+// its first character and End the position just after its last
+type Node interface {
+	Pos() Position
+	End() Position
+}
+
+// Expr is an expression node: *Ident, *BasicLit, *Param, *Call, *ArrayLit,
+// *TupleLit, *ParenExpr, *UnaryExpr, *BinaryExpr or *RawExpr
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Source is the source of the rows of an INSERT: *ValuesSource,
+// *SelectSource, *InfileSource or *DataSource
+type Source interface {
+	Node
+	sourceNode()
+}
+
+// InsertStmt is the syntax tree of an INSERT statement
+type InsertStmt struct {
+	Insert   Position   // INSERT keyword
+	Table    *TableName // nil for INSERT INTO FUNCTION
+	Function *Call      // table function of INSERT INTO FUNCTION, nil otherwise
+	Columns  *ColumnList
+	// Source is nil for INSERT ... VALUES or FORMAT name without inline
+	// data, whose rows are sent separately
+	Source   Source
+	Settings []*Setting
+	Format   *Ident // name following FORMAT, nil without one
+	// Trailer holds the clause of another dialect following the rows, e.g.
+	// ON DUPLICATE KEY UPDATE, as written
+	Trailer *RawExpr
+}
+
+// TableName is a possibly database qualified table name
+type TableName struct {
+	Database *Ident // nil for unqualified tables
+	Table    *Ident
+}
+
+// ColumnList is the parenthesized column list of an INSERT. Its columns are
+// *Ident, nested names included, or *Call for COLUMNS('regexp') matchers
+type ColumnList struct {
+	Lparen  Position
+	Columns []Expr
+	Rparen  Position
+}
+
+// Setting is a name = value pair of SETTINGS
+type Setting struct {
+	Name  *Ident
+	Value Expr
+}
+
+// ValuesSource is VALUES followed by the rows inserted, if inline
+type ValuesSource struct {
+	Values Position // VALUES keyword
+	Rows   []*Row
+}
+
+// Row is a parenthesized row of values following VALUES
+type Row struct {
+	Lparen Position
+	Values []Expr
+	Rparen Position
+}
+
+// SelectSource is the query of INSERT ... SELECT, preceding WITH clause
+// included, kept as written
+type SelectSource struct {
+	Select Position // SELECT or WITH keyword
+	Query  string
+}
+
+// InfileSource is FROM INFILE 'file' [COMPRESSION 'method']
+type InfileSource struct {
+	From        Position // FROM keyword
+	File        *BasicLit
+	Compression *BasicLit // nil without COMPRESSION
+}
+
+// DataSource is the inline data following FORMAT name, left untokenized
+type DataSource struct {
+	DataPos Position
+	Data    string
+}
+
+// Ident is an identifier as written, quotes included. Nested column names
+// such as n.x and qualified function names are a single Ident
+type Ident struct {
+	NamePos Position
+	Name    string
+}
+
+// BasicLit is a string or number literal, or NULL, as written
+type BasicLit struct {
+	ValuePos Position
+	Kind     TokenKind // TokenString, TokenNumber or TokenKeyword for NULL
+	Value    string
+}
+
+// Param is a ? placeholder, an @name argument or a {name:Type} parameter
+type Param struct {
+	ValuePos Position
+	Kind     TokenKind // TokenPlaceholder, TokenNamedPlaceholder or TokenParameter
+	Value    string
+}
+
+// Call is a function call such as toDate('2024-01-01')
+type Call struct {
+	Name   *Ident
+	Lparen Position
+	Args   []Expr
+	Rparen Position
+}
+
+// ArrayLit is an array literal such as [1, 2]
+type ArrayLit struct {
+	Lbrack Position
+	Elems  []Expr
+	Rbrack Position
+}
+
+// TupleLit is a tuple literal such as (1, 'a'), or () when empty
+type TupleLit struct {
+	Lparen Position
+	Elems  []Expr
+	Rparen Position
+}
+
+// ParenExpr is a parenthesized expression
+type ParenExpr struct {
+	Lparen Position
+	X      Expr
+	Rparen Position
+}
+
+// UnaryExpr is -x, +x or NOT x
+type UnaryExpr struct {
+	OpPos Position
+	Op    string // upper cased for NOT
+	X     Expr
+}
+
+// BinaryExpr is an operation between two expressions, e.g. a + 1, a AND b,
+// a NOT IN (1, 2) or x::UInt8, whose Y is the type
+type BinaryExpr struct {
+	X     Expr
+	OpPos Position
+	Op    string // as written, keywords upper cased and separated by a space
+	Y     Expr
+}
+
+// RawExpr is an expression the parser doesn't model, e.g. CASE or a
+// subquery, kept as written
+type RawExpr struct {
+	From Position
+	Text string
+}
+
+func (s *InsertStmt) Pos() Position { return s.Insert }
+func (s *InsertStmt) End() Position {
+	end := advance(s.Insert, "INSERT")
+	children := []Node{s.Columns, s.Source, s.Format, s.Trailer}
+	if s.Table != nil {
+		children = append(children, s.Table)
+	}
+	if s.Function != nil {
+		children = append(children, s.Function)
+	}
+	for _, setting := range s.Settings {
+		children = append(children, setting)
+	}
+	for _, child := range children {
+		if !isNilNode(child) && child.End().Offset > end.Offset {
+			end = child.End()
+		}
+	}
+	return end
+}
+
+func (t *TableName) Pos() Position {
+	if t.Database != nil {
+		return t.Database.Pos()
+	}
+	return t.Table.Pos()
+}
+func (t *TableName) End() Position  { return t.Table.End() }
+func (l *ColumnList) Pos() Position { return l.Lparen }
+func (l *ColumnList) End() Position { return advance(l.Rparen, ")") }
+func (s *Setting) Pos() Position    { return s.Name.Pos() }
+func (s *Setting) End() Position    { return s.Value.End() }
+
+func (s *ValuesSource) Pos() Position { return s.Values }
+func (s *ValuesSource) End() Position {
+	if len(s.Rows) == 0 {
+		return advance(s.Values, "VALUES")
+	}
+	return s.Rows[len(s.Rows)-1].End()
+}
+func (r *Row) Pos() Position          { return r.Lparen }
+func (r *Row) End() Position          { return advance(r.Rparen, ")") }
+func (s *SelectSource) Pos() Position { return s.Select }
+func (s *SelectSource) End() Position { return advance(s.Select, s.Query) }
+func (s *InfileSource) Pos() Position { return s.From }
+func (s *InfileSource) End() Position {
+	if s.Compression != nil {
+		return s.Compression.End()
+	}
+	return s.File.End()
+}
+func (s *DataSource) Pos() Position { return s.DataPos }
+func (s *DataSource) End() Position { return advance(s.DataPos, s.Data) }
+
+func (x *Ident) Pos() Position      { return x.NamePos }
+func (x *Ident) End() Position      { return advance(x.NamePos, x.Name) }
+func (x *BasicLit) Pos() Position   { return x.ValuePos }
+func (x *BasicLit) End() Position   { return advance(x.ValuePos, x.Value) }
+func (x *Param) Pos() Position      { return x.ValuePos }
+func (x *Param) End() Position      { return advance(x.ValuePos, x.Value) }
+func (x *Call) Pos() Position       { return x.Name.Pos() }
+func (x *Call) End() Position       { return advance(x.Rparen, ")") }
+func (x *ArrayLit) Pos() Position   { return x.Lbrack }
+func (x *ArrayLit) End() Position   { return advance(x.Rbrack, "]") }
+func (x *TupleLit) Pos() Position   { return x.Lparen }
+func (x *TupleLit) End() Position   { return advance(x.Rparen, ")") }
+func (x *ParenExpr) Pos() Position  { return x.Lparen }
+func (x *ParenExpr) End() Position  { return advance(x.Rparen, ")") }
+func (x *UnaryExpr) Pos() Position  { return x.OpPos }
+func (x *UnaryExpr) End() Position  { return x.X.End() }
+func (x *BinaryExpr) Pos() Position { return x.X.Pos() }
+func (x *BinaryExpr) End() Position { return x.Y.End() }
+func (x *RawExpr) Pos() Position    { return x.From }
+func (x *RawExpr) End() Position    { return advance(x.From, x.Text) }
+
+func (*Ident) exprNode()      {}
+func (*BasicLit) exprNode()   {}
+func (*Param) exprNode()      {}
+func (*Call) exprNode()       {}
+func (*ArrayLit) exprNode()   {}
+func (*TupleLit) exprNode()   {}
+func (*ParenExpr) exprNode()  {}
+func (*UnaryExpr) exprNode()  {}
+func (*BinaryExpr) exprNode() {}
+func (*RawExpr) exprNode()    {}
+
+func (*ValuesSource) sourceNode() {}
+func (*SelectSource) sourceNode() {}
+func (*InfileSource) sourceNode() {}
+func (*DataSource) sourceNode()   {}
+
+// advance returns the position following text written at pos
+func advance(pos Position, text string) Position {
+	pos.Offset += len(text)
+	for _, r := range text {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
+
+// isNilNode reports whether node is nil or a typed nil pointer, as held by
+// the optional fields of a node
+func isNilNode(node Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *ColumnList:
+		return n == nil
+	case *Ident:
+		return n == nil
+	case *BasicLit:
+		return n == nil
+	case *Call:
+		return n == nil
+	case *TableName:
+		return n == nil
+	case *InsertStmt:
+		return n == nil
+	case *RawExpr:
+		return n == nil
+	}
+	return false
+}
+
+// ParseInsertAST parses the first statement of query, which has to be an
+// INSERT, into its syntax tree. Values the parser doesn't model are kept as
+// *RawExpr rather than rejected
+func ParseInsertAST(query string) (*InsertStmt, error) {
+	return Parser{}.ParseInsertAST(query)
+}
+
+// ParseInsertAST is ParseInsertAST with the options and dialect of p
+func (p Parser) ParseInsertAST(query string) (*InsertStmt, error) {
+	e := p.extractor(query)
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	parser := &astParser{e: e, end: len(e.tokens)}
+	if parser.end > 0 && e.tokens[parser.end-1] == statementTerminator {
+		parser.end--
+	}
+	return parser.insert()
+}
+
+// astParser is a recursive-descent parser over the tokens of a statement,
+// up to the index end
+type astParser struct {
+	e   *columnExtractor
+	end int
+}
+
+// insertClauseKeywords holds the words ending the SETTINGS of an INSERT
+var insertClauseKeywords = []string{"VALUES", "FORMAT", "SELECT", "WITH", "FROM", "SETTINGS"}
+
+func (p *astParser) insert() (*InsertStmt, error) {
+	e := p.e
+	if !p.is(0, "INSERT") {
+		return nil, errors.New("not an INSERT statement")
+	}
+	stmt := &InsertStmt{Insert: e.tokenPosition(0)}
+	if !p.is(1, "INTO") {
+		return nil, p.errorAt(1, "expected INTO")
+	}
+	i := skipTableKeyword(e.tokens, 2)
+	if p.is(i, "FUNCTION") {
+		x, next, err := p.expr(i+1, p.end, 0)
+		call, ok := x.(*Call)
+		if err != nil || !ok {
+			return nil, p.errorAt(i+1, "expected table function call")
+		}
+		stmt.Function, i = call, next
+	} else {
+		table, next, err := p.tableName(i)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Table, i = table, next
+	}
+	if p.isValue(i, "(") {
+		columns, next, err := p.columnList(i)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Columns, i = columns, next
+	}
+
+	for i < p.end {
+		var err error
+		switch {
+		case p.is(i, "SETTINGS"):
+			var settings []*Setting
+			settings, i, err = p.settings(i + 1)
+			stmt.Settings = append(stmt.Settings, settings...)
+		case p.is(i, "VALUES") && stmt.Source == nil:
+			stmt.Source, i, err = p.values(i)
+		case p.is(i, "FROM") && p.is(i+1, "INFILE") && stmt.Source == nil:
+			stmt.Source, i, err = p.infile(i)
+		case (p.is(i, "SELECT") || p.is(i, "WITH")) && stmt.Source == nil:
+			stmt.Source = &SelectSource{Select: e.tokenPosition(i), Query: e.sourceText(i, p.end-1)}
+			i = p.end
+		case p.e.isRowsTrailer(i) && stmt.Trailer == nil:
+			stmt.Trailer = &RawExpr{From: e.tokenPosition(i), Text: e.sourceText(i, p.end-1)}
+			i = p.end
+		case p.is(i, "FORMAT") && stmt.Format == nil:
+			if i+1 == p.end || (!e.tokens[i+1].isIdentifier() && e.tokens[i+1].Kind != TokenKeyword) {
+				return nil, p.errorAt(i+1, "missing format name")
+			}
+			stmt.Format = p.ident(i + 1)
+			i += 2
+			if e.formatData >= 0 && e.formatData < len(e.query) && stmt.Source == nil {
+				stmt.Source = &DataSource{DataPos: e.position(e.formatData), Data: e.query[e.formatData:]}
+			}
+		default:
+			return nil, p.errorAt(i, "unexpected %s", e.tokens[i].Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+// tableName parses the possibly qualified table name at index i
+func (p *astParser) tableName(i int) (*TableName, int, error) {
+	if !p.isName(i) {
+		return nil, i, p.errorAt(i, "missing table name")
+	}
+	if p.isValue(i+1, ".") && p.isName(i+2) {
+		return &TableName{Database: p.ident(i), Table: p.ident(i + 2)}, i + 3, nil
+	}
+	return &TableName{Table: p.ident(i)}, i + 1, nil
+}
+
+// columnList parses the column list opened at index open
+func (p *astParser) columnList(open int) (*ColumnList, int, error) {
+	close := p.e.matchingParenthesis(open)
+	if close < 0 || close >= p.end {
+		return nil, open, p.errorAt(open, "unclosed column list")
+	}
+	list := &ColumnList{
+		Lparen:  p.e.tokenPosition(open),
+		Columns: make([]Expr, 0),
+		Rparen:  p.e.tokenPosition(close),
+	}
+	for _, item := range p.e.splitList(open+1, close) {
+		if len(item) == 1 && p.e.tokens[item[0]].Kind == TokenString {
+			// Single quoted column names are accepted as well
+			list.Columns = append(list.Columns, p.ident(item[0]))
+			continue
+		}
+		list.Columns = append(list.Columns, p.item(item))
+	}
+	return list, close + 1, nil
+}
+
+// settings parses the name = value pairs following SETTINGS, from index i
+func (p *astParser) settings(i int) ([]*Setting, int, error) {
+	end, depth := i, 0
+	for ; end < p.end; end++ {
+		token := p.e.tokens[end]
+		if token.Value == "(" || token.Value == "[" {
+			depth++
+		} else if token.Value == ")" || token.Value == "]" {
+			depth--
+		} else if depth == 0 && slices.ContainsFunc(insertClauseKeywords, func(word string) bool { return token.IsKeyword(word) }) {
+			break
+		}
+	}
+	items := p.e.splitList(i, end)
+	if len(items) == 0 {
+		return nil, i, p.errorAt(i, "missing setting name after SETTINGS")
+	}
+	settings := make([]*Setting, 0, len(items))
+	for _, item := range items {
+		if len(item) < 3 || !p.isName(item[0]) || p.e.tokens[item[1]].Value != "=" {
+			return nil, i, p.errorAt(item[0], "expected name = value")
+		}
+		settings = append(settings, &Setting{Name: p.ident(item[0]), Value: p.item(item[2:])})
+	}
+	return settings, end, nil
+}
+
+// values parses VALUES at index i and the rows following it
+func (p *astParser) values(i int) (*ValuesSource, int, error) {
+	source := &ValuesSource{
+		Values: p.e.tokenPosition(i),
+		Rows:   make([]*Row, 0),
+	}
+	i++
+	for p.isValue(i, "(") {
+		close := p.e.matchingParenthesis(i)
+		if close < 0 || close >= p.end {
+			return nil, i, p.errorAt(i, "unclosed row")
+		}
+		if comma := p.e.emptyItem(i, close); comma >= 0 {
+			return nil, i, p.errorAt(comma, "missing value")
+		}
+		row := &Row{
+			Lparen: p.e.tokenPosition(i),
+			Values: make([]Expr, 0),
+			Rparen: p.e.tokenPosition(close),
+		}
+		for _, item := range p.e.splitList(i+1, close) {
+			row.Values = append(row.Values, p.item(item))
+		}
+		source.Rows = append(source.Rows, row)
+		i = close + 1
+		if p.isValue(i, ",") {
+			i++
+		}
+	}
+	return source, i, nil
+}
+
+// infile parses FROM INFILE 'file' [COMPRESSION 'method'] at index i
+func (p *astParser) infile(i int) (*InfileSource, int, error) {
+	source := &InfileSource{From: p.e.tokenPosition(i)}
+	if i+2 >= p.end || p.e.tokens[i+2].Kind != TokenString {
+		return nil, i, p.errorAt(i+2, "missing file name after INFILE")
+	}
+	source.File = p.literal(i + 2)
+	i += 3
+	if p.is(i, "COMPRESSION") {
+		if i+1 == p.end || p.e.tokens[i+1].Kind != TokenString {
+			return nil, i, p.errorAt(i+1, "missing compression after COMPRESSION")
+		}
+		source.Compression = p.literal(i + 1)
+		i += 2
+	}
+	return source, i, nil
+}
+
+// item parses the tokens at the given indexes as one expression, falling
+// back to a RawExpr when they aren't entirely a modelled expression
+func (p *astParser) item(item []int) Expr {
+	first, last := item[0], item[len(item)-1]
+	if x, next, err := p.expr(first, last+1, 0); err == nil && next == last+1 {
+		return x
+	}
+	return &RawExpr{From: p.e.tokenPosition(first), Text: p.e.sourceText(first, last)}
+}
+
+// binaryOperators holds the precedence of binary operators, higher binding
+// tighter. Keywords are upper cased, NOT IN and NOT LIKE included
+var binaryOperators = map[string]int{
+	"OR": 1, "AND": 2,
+	"=": 4, "==": 4, "!=": 4, "<>": 4, "<": 4, "<=": 4, ">": 4, ">=": 4,
+	"LIKE": 4, "ILIKE": 4, "IN": 4, "NOT LIKE": 4, "NOT ILIKE": 4, "NOT IN": 4,
+	"||": 5, "+": 6, "-": 6, "*": 7, "/": 7, "%": 7,
+}
+
+// notPrecedence is the precedence of the unary NOT operator
+const notPrecedence = 3
+
+// expr parses a binary expression of operators binding tighter than prec
+// from index i up to end, by precedence climbing
+func (p *astParser) expr(i, end, prec int) (Expr, int, error) {
+	x, i, err := p.unary(i, end)
+	if err != nil {
+		return nil, i, err
+	}
+	for i < end {
+		op, width := p.binaryOperator(i, end)
+		precedence := binaryOperators[op]
+		if precedence == 0 || precedence <= prec {
+			break
+		}
+		opPos := p.e.tokenPosition(i)
+		y, next, err := p.expr(i+width, end, precedence)
+		if err != nil {
+			return nil, next, err
+		}
+		x = &BinaryExpr{X: x, OpPos: opPos, Op: op, Y: y}
+		i = next
+	}
+	return x, i, nil
+}
+
+// binaryOperator returns the binary operator at index i and the number of
+// tokens it takes, or an empty string
+func (p *astParser) binaryOperator(i, end int) (string, int) {
+	token := p.e.tokens[i]
+	switch {
+	case token.Kind == TokenOperator && token.Value != "::" && token.Value != "->":
+		return token.Value, 1
+	case token.IsKeyword("NOT") && i+1 < end:
+		for _, word := range []string{"IN", "LIKE", "ILIKE"} {
+			if isWord(p.e.tokens[i+1], word) {
+				return "NOT " + word, 2
+			}
+		}
+	case isWord(token, "AND") || isWord(token, "OR") || isWord(token, "IN") || isWord(token, "LIKE") || isWord(token, "ILIKE"):
+		return strings.ToUpper(token.Value), 1
+	}
+	return "", 0
+}
+
+// unary parses an operand, possibly preceded by -, + or NOT and followed by
+// :: casts
+func (p *astParser) unary(i, end int) (Expr, int, error) {
+	if i >= end {
+		return nil, i, p.errorAt(i, "missing expression")
+	}
+	token := p.e.tokens[i]
+	switch {
+	case token.Value == "-" || token.Value == "+":
+		x, next, err := p.unary(i+1, end)
+		if err != nil {
+			return nil, next, err
+		}
+		return &UnaryExpr{OpPos: p.e.tokenPosition(i), Op: token.Value, X: x}, next, nil
+	case token.IsKeyword("NOT"):
+		x, next, err := p.expr(i+1, end, notPrecedence)
+		if err != nil {
+			return nil, next, err
+		}
+		return &UnaryExpr{OpPos: p.e.tokenPosition(i), Op: "NOT", X: x}, next, nil
+	}
+	x, i, err := p.primary(i, end)
+	for err == nil && i+1 < end && p.e.tokens[i].Value == "::" {
+		opPos := p.e.tokenPosition(i)
+		var y Expr
+		if y, i, err = p.primary(i+1, end); err == nil {
+			x = &BinaryExpr{X: x, OpPos: opPos, Op: "::", Y: y}
+		}
+	}
+	return x, i, err
+}
+
+// primary parses a literal, parameter, name, function call, array, tuple
+// or parenthesized expression at index i
+func (p *astParser) primary(i, end int) (Expr, int, error) {
+	e := p.e
+	token := e.tokens[i]
+	switch {
+	case token.Kind == TokenString || token.Kind == TokenNumber || token.IsKeyword("NULL"):
+		return p.literal(i), i + 1, nil
+	case token.Kind == TokenPlaceholder || token.Kind == TokenNamedPlaceholder || token.Kind == TokenParameter:
+		return &Param{ValuePos: e.tokenPosition(i), Kind: token.Kind, Value: token.Value}, i + 1, nil
+	case token.Value == "[":
+		close := e.matchingParenthesis(i)
+		if close < 0 || close >= end {
+			return nil, i, p.errorAt(i, "unclosed bracket")
+		}
+		return &ArrayLit{Lbrack: e.tokenPosition(i), Elems: p.items(i+1, close), Rbrack: e.tokenPosition(close)}, close + 1, nil
+	case token.Value == "(":
+		close := e.matchingParenthesis(i)
+		if close < 0 || close >= end {
+			return nil, i, p.errorAt(i, "unclosed parenthesis")
+		}
+		elems := p.items(i+1, close)
+		if len(elems) == 1 && e.tokens[close-1].Value != "," {
+			return &ParenExpr{Lparen: e.tokenPosition(i), X: elems[0], Rparen: e.tokenPosition(close)}, close + 1, nil
+		}
+		return &TupleLit{Lparen: e.tokenPosition(i), Elems: elems, Rparen: e.tokenPosition(close)}, close + 1, nil
+	case token.isIdentifier() || (token.Kind == TokenKeyword && i+1 < end && e.tokens[i+1].Value == "("):
+		last := i
+		for last+2 < end && e.tokens[last+1].Value == "." && e.tokens[last+2].isIdentifier() {
+			last += 2
+		}
+		name := &Ident{NamePos: e.tokenPosition(i), Name: e.sourceText(i, last)}
+		if last+1 == end || e.tokens[last+1].Value != "(" {
+			return name, last + 1, nil
+		}
+		close := e.matchingParenthesis(last + 1)
+		if close < 0 || close >= end {
+			return nil, i, p.errorAt(last+1, "unclosed parenthesis")
+		}
+		call := &Call{
+			Name:   name,
+			Lparen: e.tokenPosition(last + 1),
+			Args:   p.items(last+2, close),
+			Rparen: e.tokenPosition(close),
+		}
+		return call, close + 1, nil
+	}
+	return nil, i, p.errorAt(i, "unexpected %s", token.Value)
+}
+
+// items parses the comma separated expressions from start up to end
+func (p *astParser) items(start, end int) []Expr {
+	items := make([]Expr, 0)
+	for _, item := range p.e.splitList(start, end) {
+		items = append(items, p.item(item))
+	}
+	return items
+}
+
+// ident returns the token at index i as an Ident
+func (p *astParser) ident(i int) *Ident {
+	return &Ident{NamePos: p.e.tokenPosition(i), Name: p.e.tokens[i].Value}
+}
+
+// literal returns the token at index i as a BasicLit
+func (p *astParser) literal(i int) *BasicLit {
+	token := p.e.tokens[i]
+	return &BasicLit{ValuePos: p.e.tokenPosition(i), Kind: token.Kind, Value: token.Value}
+}
+
+// is reports whether the token at index i is the given word
+func (p *astParser) is(i int, word string) bool {
+	return i < p.end && isWord(p.e.tokens[i], word)
+}
+
+// isValue reports whether the token at index i is the given punctuation or
+// operator
+func (p *astParser) isValue(i int, value string) bool {
+	return i < p.end && (p.e.tokens[i].Kind == TokenPunctuation || p.e.tokens[i].Kind == TokenOperator) && p.e.tokens[i].Value == value
+}
+
+// isName reports whether the token at index i can be a table or column
+// name, keywords not being reserved
+func (p *astParser) isName(i int) bool {
+	return i < p.end && (p.e.tokens[i].isIdentifier() || p.e.tokens[i].Kind == TokenKeyword)
+}
+
+// errorAt is columnExtractor.errorAt, positioned at the end of the query
+// past the last token
+func (p *astParser) errorAt(i int, format string, args ...any) error {
+	if i >= len(p.e.tokens) {
+		return &SyntaxError{Pos: p.e.position(len(p.e.query)), Err: fmt.Errorf(format, args...)}
+	}
+	return p.e.errorAt(i, format, args...)
+}
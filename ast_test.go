@@ -0,0 +1,146 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInsertAST(t *testing.T) {
+	query := "INSERT INTO db.t (id, n.x, COLUMNS('^m'))\n" +
+		"SETTINGS async_insert = 1\n" +
+		"VALUES (1, -2.5, 'a'), (?, [1, 2], (3, 'b')) (NULL, toDate({d:String}) + 1, CASE WHEN 1 THEN 2 END)"
+	stmt, err := ParseInsertAST(query)
+	assert.NoError(t, err)
+
+	assert.Equal(t, &TableName{
+		Database: &Ident{NamePos: Position{Offset: 12, Line: 1, Column: 13}, Name: `db`},
+		Table:    &Ident{NamePos: Position{Offset: 15, Line: 1, Column: 16}, Name: `t`},
+	}, stmt.Table)
+	assert.Equal(t, &ColumnList{
+		Lparen: Position{Offset: 17, Line: 1, Column: 18},
+		Columns: []Expr{
+			&Ident{NamePos: Position{Offset: 18, Line: 1, Column: 19}, Name: `id`},
+			&Ident{NamePos: Position{Offset: 22, Line: 1, Column: 23}, Name: `n.x`},
+			&Call{
+				Name:   &Ident{NamePos: Position{Offset: 27, Line: 1, Column: 28}, Name: `COLUMNS`},
+				Lparen: Position{Offset: 34, Line: 1, Column: 35},
+				Args:   []Expr{&BasicLit{ValuePos: Position{Offset: 35, Line: 1, Column: 36}, Kind: TokenString, Value: `'^m'`}},
+				Rparen: Position{Offset: 39, Line: 1, Column: 40},
+			},
+		},
+		Rparen: Position{Offset: 40, Line: 1, Column: 41},
+	}, stmt.Columns)
+	assert.Equal(t, []*Setting{{
+		Name:  &Ident{NamePos: Position{Offset: 51, Line: 2, Column: 10}, Name: `async_insert`},
+		Value: &BasicLit{ValuePos: Position{Offset: 66, Line: 2, Column: 25}, Kind: TokenNumber, Value: `1`},
+	}}, stmt.Settings)
+	assert.Nil(t, stmt.Format)
+
+	values, ok := stmt.Source.(*ValuesSource)
+	assert.True(t, ok)
+	assert.Len(t, values.Rows, 3)
+	assert.Equal(t, Position{Offset: 68, Line: 3, Column: 1}, values.Pos())
+	assert.Equal(t, &UnaryExpr{
+		OpPos: Position{Offset: 79, Line: 3, Column: 12},
+		Op:    `-`,
+		X:     &BasicLit{ValuePos: Position{Offset: 80, Line: 3, Column: 13}, Kind: TokenNumber, Value: `2.5`},
+	}, values.Rows[0].Values[1])
+	assert.IsType(t, &Param{}, values.Rows[1].Values[0])
+	assert.IsType(t, &ArrayLit{}, values.Rows[1].Values[1])
+	assert.IsType(t, &TupleLit{}, values.Rows[1].Values[2])
+
+	last := values.Rows[2]
+	assert.Equal(t, &BasicLit{ValuePos: Position{Offset: 114, Line: 3, Column: 47}, Kind: TokenKeyword, Value: `NULL`}, last.Values[0])
+	sum, ok := last.Values[1].(*BinaryExpr)
+	assert.True(t, ok)
+	assert.Equal(t, `+`, sum.Op)
+	assert.IsType(t, &Call{}, sum.X)
+	assert.Equal(t, &RawExpr{From: Position{Offset: 144, Line: 3, Column: 77}, Text: `CASE WHEN 1 THEN 2 END`}, last.Values[2])
+	assert.Equal(t, len(query), stmt.End().Offset)
+	assert.Equal(t, len(query)-1, last.Values[2].End().Offset)
+}
+
+func TestParseInsertASTExpressions(t *testing.T) {
+	stmt, err := ParseInsertAST(`INSERT INTO t VALUES (a + b * c, NOT x = 1 AND y NOT IN (1, 2) OR z, '1'::UInt8, (1), ())`)
+	assert.NoError(t, err)
+	row := stmt.Source.(*ValuesSource).Rows[0]
+
+	// a + (b * c)
+	sum := row.Values[0].(*BinaryExpr)
+	assert.Equal(t, `+`, sum.Op)
+	assert.Equal(t, `*`, sum.Y.(*BinaryExpr).Op)
+
+	// ((NOT (x = 1)) AND (y NOT IN (1, 2))) OR z
+	or := row.Values[1].(*BinaryExpr)
+	assert.Equal(t, `OR`, or.Op)
+	and := or.X.(*BinaryExpr)
+	assert.Equal(t, `AND`, and.Op)
+	assert.Equal(t, `NOT`, and.X.(*UnaryExpr).Op)
+	assert.Equal(t, `NOT IN`, and.Y.(*BinaryExpr).Op)
+	assert.IsType(t, &TupleLit{}, and.Y.(*BinaryExpr).Y)
+
+	cast := row.Values[2].(*BinaryExpr)
+	assert.Equal(t, `::`, cast.Op)
+	assert.Equal(t, &Ident{NamePos: Position{Offset: 74, Line: 1, Column: 75}, Name: `UInt8`}, cast.Y)
+	assert.IsType(t, &ParenExpr{}, row.Values[3])
+	assert.Equal(t, []Expr{}, row.Values[4].(*TupleLit).Elems)
+}
+
+func TestParseInsertASTSources(t *testing.T) {
+	t.Run(`select`, func(t *testing.T) {
+		stmt, err := ParseInsertAST(`INSERT INTO t (a) WITH 1 AS x SELECT x FROM s;`)
+		assert.NoError(t, err)
+		assert.Equal(t, &SelectSource{Select: Position{Offset: 18, Line: 1, Column: 19}, Query: `WITH 1 AS x SELECT x FROM s`}, stmt.Source)
+	})
+
+	t.Run(`infile`, func(t *testing.T) {
+		stmt, err := ParseInsertAST(`INSERT INTO t FROM INFILE 'data.csv.gz' COMPRESSION 'gzip' FORMAT CSV`)
+		assert.NoError(t, err)
+		infile := stmt.Source.(*InfileSource)
+		assert.Equal(t, `'data.csv.gz'`, infile.File.Value)
+		assert.Equal(t, `'gzip'`, infile.Compression.Value)
+		assert.Equal(t, `CSV`, stmt.Format.Name)
+	})
+
+	t.Run(`format data`, func(t *testing.T) {
+		stmt, err := ParseInsertAST("INSERT INTO t FORMAT JSONEachRow {\"a\": 1}")
+		assert.NoError(t, err)
+		assert.Equal(t, &DataSource{DataPos: Position{Offset: 33, Line: 1, Column: 34}, Data: `{"a": 1}`}, stmt.Source)
+	})
+
+	t.Run(`batch`, func(t *testing.T) {
+		stmt, err := ParseInsertAST(`INSERT INTO FUNCTION s3('url', 'CSV') VALUES`)
+		assert.NoError(t, err)
+		assert.Nil(t, stmt.Table)
+		assert.Equal(t, `s3`, stmt.Function.Name.Name)
+		assert.Len(t, stmt.Function.Args, 2)
+		assert.Empty(t, stmt.Source.(*ValuesSource).Rows)
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		for query, message := range map[string]string{
+			`SELECT 1`:                          `not an INSERT statement`,
+			`INSERT t VALUES`:                   `missing INTO after INSERT`,
+			`INSERT INTO t (a VALUES`:           `1:15: unclosed column list`,
+			`INSERT INTO t SETTINGS VALUES (1)`: `1:24: missing setting name after SETTINGS`,
+			`INSERT INTO t VALUES (1) garbage`:  `1:26: unexpected garbage`,
+			`INSERT INTO t FORMAT`:              `1:21: missing format name`,
+			`INSERT INTO t VALUES (1,,2)`:       `1:25: missing value`,
+		} {
+			_, err := ParseInsertAST(query)
+			assert.EqualError(t, err, message, query)
+		}
+	})
+
+	t.Run(`trailer`, func(t *testing.T) {
+		stmt, err := Parser{Dialect: MySQL}.ParseInsertAST(`INSERT INTO t (a) VALUES (1) ON DUPLICATE KEY UPDATE a = a + 1`)
+		assert.NoError(t, err)
+		assert.Len(t, stmt.Source.(*ValuesSource).Rows, 1)
+		assert.Equal(t, &RawExpr{From: Position{Offset: 29, Line: 1, Column: 30}, Text: `ON DUPLICATE KEY UPDATE a = a + 1`}, stmt.Trailer)
+		assert.Equal(t, `INSERT INTO t (a) VALUES (1) ON DUPLICATE KEY UPDATE a = a + 1`, Format(stmt, FormatStyle{}))
+
+		_, err = ParseInsertAST(`INSERT INTO t (a) VALUES (1) ON DUPLICATE KEY UPDATE a = a + 1`)
+		assert.EqualError(t, err, `1:30: unexpected ON`)
+	})
+}
@@ -0,0 +1,56 @@
+package clickhouse_go_insert_statement_parsing
+
+// AuditEntry describes how a single statement accesses data
+type AuditEntry struct {
+	Operation string   `json:"operation"` // leading keyword, e.g. SELECT or INSERT
+	Tables    []string `json:"tables"`    // tables and table functions referenced
+	Columns   []string `json:"columns"`   // target columns of an INSERT
+	Literals  bool     `json:"literals"`  // whether string or numeric literals are present
+}
+
+// AuditReport splits a script on semicolons and reports the operation,
+// referenced tables, INSERT target columns and presence of literals of each
+// statement, for security reviews of query corpora. This is best effort,
+// tokenisation errors are ignored
+func AuditReport(script string) []AuditEntry {
+	e := &columnExtractor{
+		query: script,
+	}
+	statements, _ := e.statements()
+
+	report := make([]AuditEntry, 0, 1)
+	for _, tokens := range statements {
+		entry := AuditEntry{
+			Operation: statementOperation(tokens),
+			Tables:    make([]string, 0, 2),
+			Columns:   make([]string, 0),
+		}
+		for _, ref := range tableRefs(tokens) {
+			entry.Tables = append(entry.Tables, ref.String())
+		}
+
+		// Single quoted tokens are column names inside the INSERT column list
+		// and string literals everywhere else
+		open, end := -1, -1
+		if entry.Operation == "INSERT" {
+			statement := &columnExtractor{
+				tokens: tokens,
+			}
+			entry.Columns = statement.columns()
+			if first, last, ok := statement.columnList(); ok {
+				open, end = first, last
+			}
+		}
+		for i, token := range tokens {
+			if i > open && i < end {
+				continue
+			}
+			if token.Kind == TokenNumber || token.Kind == TokenString {
+				entry.Literals = true
+				break
+			}
+		}
+		report = append(report, entry)
+	}
+	return report
+}
@@ -0,0 +1,38 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditReport(t *testing.T) {
+	t.Run(`statements`, func(t *testing.T) {
+		report := AuditReport("INSERT INTO db.t (a, 'b c') SELECT x, y FROM src;\n" +
+			"SELECT a FROM db.t JOIN u USING (id) WHERE a = 'secret';\n" +
+			"ALTER TABLE db.t DELETE WHERE a = 1")
+		assert.Equal(t, []AuditEntry{
+			{Operation: `INSERT`, Tables: []string{`db.t`, `src`}, Columns: []string{`a`, `'b c'`}},
+			{Operation: `SELECT`, Tables: []string{`db.t`, `u`}, Columns: []string{}, Literals: true},
+			{Operation: `ALTER`, Tables: []string{`db.t`}, Columns: []string{}, Literals: true},
+		}, report)
+	})
+
+	t.Run(`literal spelled as a column`, func(t *testing.T) {
+		report := AuditReport(`INSERT INTO t ('a') VALUES ('a')`)
+		assert.Equal(t, []AuditEntry{
+			{Operation: `INSERT`, Tables: []string{`t`}, Columns: []string{`'a'`}, Literals: true},
+		}, report)
+	})
+
+	t.Run(`with`, func(t *testing.T) {
+		report := AuditReport(`WITH recent AS (SELECT a FROM t) SELECT a FROM recent`)
+		assert.Equal(t, `SELECT`, report[0].Operation)
+	})
+
+	t.Run(`empty statements`, func(t *testing.T) {
+		report := AuditReport(`;SELECT 1;;`)
+		assert.Len(t, report, 1)
+		assert.True(t, report[0].Literals)
+	})
+}
@@ -0,0 +1,39 @@
+package clickhouse_go_insert_statement_parsing
+
+import "errors"
+
+// NormalizeForBatch returns the part of an INSERT that clickhouse-go's
+// PrepareBatch expects, ending right after VALUES or the name following
+// FORMAT, with any inline data stripped. VALUES is appended to INSERTs
+// lacking both. The columns are those of the column list
+func NormalizeForBatch(query string) (string, []string, error) {
+	return Parser{}.NormalizeForBatch(query)
+}
+
+// NormalizeForBatch is NormalizeForBatch with the options and dialect of p
+func (p Parser) NormalizeForBatch(query string) (string, []string, error) {
+	insert, err := p.ParseInsert(query)
+	if err != nil {
+		return "", nil, err
+	}
+	if insert.IsSelect() || insert.Infile != nil {
+		return "", nil, errors.New("INSERT reads its rows from a query or file, not a batch")
+	}
+	e := insert.e
+	end := insert.values
+	if end < 0 {
+		if format := e.findClause(e.headerEnd(), "FORMAT"); format >= 0 {
+			end = e.skipComments(format + 1)
+		}
+	}
+	if end >= 0 && end < len(e.tokens) {
+		return e.query[:e.tokenEnd(end)], insert.Columns, nil
+	}
+
+	// ParseInsert made sure the tokens start with INSERT
+	last := len(e.tokens) - 1
+	for e.tokens[last] == statementTerminator || e.tokens[last].Kind == TokenComment {
+		last--
+	}
+	return e.query[:e.tokenEnd(last)] + " VALUES", insert.Columns, nil
+}
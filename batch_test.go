@@ -0,0 +1,77 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeForBatch(t *testing.T) {
+	tests := []struct {
+		query   string
+		prefix  string
+		columns []string
+		err     string
+	}{
+		{
+			query:   `INSERT INTO t (a, b) VALUES (1, 2), (3, 4)`,
+			prefix:  `INSERT INTO t (a, b) VALUES`,
+			columns: []string{`a`, `b`},
+		},
+		{
+			query:   "INSERT INTO t (a) FORMAT JSONEachRow\n{\"a\": 1}",
+			prefix:  `INSERT INTO t (a) FORMAT JSONEachRow`,
+			columns: []string{`a`},
+		},
+		{
+			query:   `INSERT INTO db.t (a, b) SETTINGS async_insert = 1;`,
+			prefix:  `INSERT INTO db.t (a, b) SETTINGS async_insert = 1 VALUES`,
+			columns: []string{`a`, `b`},
+		},
+		{
+			query:   `insert into t values`,
+			prefix:  `insert into t values`,
+			columns: []string{},
+		},
+		{
+			query:   `INSERT INTO t -- comment`,
+			prefix:  `INSERT INTO t VALUES`,
+			columns: []string{},
+		},
+		{
+			// invalid UTF-8, replaced in the tokens, is kept as written
+			query:   "INSERT INTO `t\xff` (`a\xfe`) SETTINGS comment = 'x\xff'",
+			prefix:  "INSERT INTO `t\xff` (`a\xfe`) SETTINGS comment = 'x\xff' VALUES",
+			columns: []string{"`a\ufffd`"},
+		},
+		{
+			query:   "INSERT INTO t FORMAT `\xff`\n1",
+			prefix:  "INSERT INTO t FORMAT `\xff`",
+			columns: []string{},
+		},
+		{
+			query: `INSERT INTO t (a) SELECT 1`,
+			err:   `INSERT reads its rows from a query or file, not a batch`,
+		},
+		{
+			query: `INSERT INTO t FROM INFILE 'data.csv' FORMAT CSV`,
+			err:   `INSERT reads its rows from a query or file, not a batch`,
+		},
+		{
+			query: `SELECT 1`,
+			err:   `not an INSERT statement`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			prefix, columns, err := NormalizeForBatch(tt.query)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.prefix, prefix)
+			assert.Equal(t, tt.columns, columns)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strconv"
+	"strings"
+)
+
+// QuoteIdentifier returns name as written in a query: unchanged when it is a
+// plain identifier, otherwise in backticks with backticks and backslashes
+// escaped
+func QuoteIdentifier(name string) string {
+	if isPlainIdentifier(name) {
+		return name
+	}
+	return quote(name, '`')
+}
+
+// QuoteString returns s as a single quoted string literal
+func QuoteString(s string) string {
+	return quote(s, '\'')
+}
+
+// Literal returns value as a SQL literal: numbers are kept as they are,
+// anything else becomes a string literal
+func Literal(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil && isNumberLiteral(value) {
+		return value
+	}
+	return QuoteString(value)
+}
+
+// QuoteTable returns the possibly database qualified name of ref, quoting
+// each part as needed
+func QuoteTable(ref TableRef) string {
+	if ref.Database != "" {
+		return QuoteIdentifier(ref.Database) + "." + QuoteIdentifier(ref.Table)
+	}
+	return QuoteIdentifier(ref.Table)
+}
+
+func quote(s string, q byte) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte(q)
+	for i := 0; i < len(s); i++ {
+		if s[i] == q || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte(q)
+	return b.String()
+}
+
+// isPlainIdentifier reports whether name tokenizes as a single identifier
+// that isn't a keyword, so it needs no quotes
+func isPlainIdentifier(name string) bool {
+	tokens := plainTokens(name)
+	return len(tokens) == 1 && tokens[0].Kind == TokenIdentifier && tokens[0].Value == name
+}
+
+// isNumberLiteral reports whether value tokenizes as a single number
+func isNumberLiteral(value string) bool {
+	tokens := plainTokens(value)
+	return len(tokens) == 1 && tokens[0].Kind == TokenNumber && tokens[0].Value == value
+}
+
+func plainTokens(s string) []Token {
+	e := &columnExtractor{
+		query: s,
+	}
+	if err := e.parse(); err != nil {
+		return nil
+	}
+	return e.tokens
+}
+
+// InsertBuilder writes INSERT statements for a table and column list,
+// quoting names as needed
+type InsertBuilder struct {
+	Table   TableRef
+	Columns []string
+}
+
+// Header returns INSERT INTO table (columns), or INSERT INTO table when
+// there are no columns
+func (b InsertBuilder) Header() string {
+	var s strings.Builder
+	s.WriteString("INSERT INTO ")
+	s.WriteString(QuoteTable(b.Table))
+	if len(b.Columns) > 0 {
+		s.WriteString(" (")
+		for i, column := range b.Columns {
+			if i > 0 {
+				s.WriteString(", ")
+			}
+			s.WriteString(QuoteIdentifier(column))
+		}
+		s.WriteString(")")
+	}
+	return s.String()
+}
+
+// Values returns an INSERT of rows, each value rendered with Literal
+func (b InsertBuilder) Values(rows [][]string) string {
+	var s strings.Builder
+	s.WriteString(b.Header())
+	s.WriteString(" VALUES ")
+	for i, row := range rows {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString("(")
+		for j, value := range row {
+			if j > 0 {
+				s.WriteString(", ")
+			}
+			s.WriteString(Literal(value))
+		}
+		s.WriteString(")")
+	}
+	return s.String()
+}
+
+// Format returns the header of an INSERT whose data follows in format
+func (b InsertBuilder) Format(format string) string {
+	return b.Header() + " FORMAT " + format
+}
@@ -0,0 +1,48 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoting(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+	}{
+		{`plain identifier`, QuoteIdentifier(`user_id`), `user_id`},
+		{`keyword`, QuoteIdentifier(`values`), "`values`"},
+		{`space`, QuoteIdentifier(`QTY (MT)`), "`QTY (MT)`"},
+		{`backtick and backslash`, QuoteIdentifier("a`b\\c"), "`a\\`b\\\\c`"},
+		{`leading digit`, QuoteIdentifier(`1st`), `1st`},
+		{`number`, QuoteIdentifier(`1`), "`1`"},
+		{`empty`, QuoteIdentifier(``), "``"},
+		{`string`, QuoteString(`it's`), `'it\'s'`},
+		{`integer`, Literal(`42`), `42`},
+		{`signed`, Literal(`-0.5e3`), `'-0.5e3'`},
+		{`exponent`, Literal(`1.5e3`), `1.5e3`},
+		{`not a number`, Literal(`inf`), `'inf'`},
+		{`qualified table`, QuoteTable(TableRef{Database: `db`, Table: `my table`}), "db.`my table`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.actual)
+		})
+	}
+}
+
+func TestInsertBuilder(t *testing.T) {
+	b := InsertBuilder{Table: TableRef{Table: `t`}, Columns: []string{`a`, `b c`}}
+	assert.Equal(t, "INSERT INTO t (a, `b c`)", b.Header())
+	assert.Equal(t, "INSERT INTO t (a, `b c`) VALUES (1, 'x'), (2, 'y')", b.Values([][]string{{`1`, `x`}, {`2`, `y`}}))
+	assert.Equal(t, "INSERT INTO t (a, `b c`) FORMAT CSV", b.Format(`CSV`))
+	assert.Equal(t, `INSERT INTO t`, InsertBuilder{Table: TableRef{Table: `t`}}.Header())
+
+	t.Run(`round trip`, func(t *testing.T) {
+		e := &columnExtractor{query: b.Values([][]string{{`1`, `x`}})}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, "`b c`"}, e.columns())
+	})
+}
@@ -0,0 +1,101 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// readOperations are statements that never modify data, schema or settings
+var readOperations = map[string]bool{
+	"SELECT":   true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"SHOW":     true,
+	"EXPLAIN":  true,
+	"EXISTS":   true,
+	"CHECK":    true,
+	"USE":      true,
+}
+
+// writeOperations are statements that modify data, schema, access rights or
+// settings. SET is included as ClickHouse forbids it in readonly=1 mode
+var writeOperations = map[string]bool{
+	"INSERT":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"TRUNCATE": true,
+	"CREATE":   true,
+	"ATTACH":   true,
+	"DETACH":   true,
+	"UNDROP":   true,
+	"RENAME":   true,
+	"EXCHANGE": true,
+	"DELETE":   true,
+	"UPDATE":   true,
+	"OPTIMIZE": true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"KILL":     true,
+	"SYSTEM":   true,
+	"SET":      true,
+	"BACKUP":   true,
+	"RESTORE":  true,
+	"MOVE":     true,
+}
+
+// IsWriteStatement reports whether query, or any statement of a semicolon
+// separated script, may write. Comments and quoted text are never mistaken
+// for keywords. Queries that fail to tokenize, empty queries and
+// unrecognised statements are reported as errors, along with true, so that
+// read-only endpoints fail closed rather than guess
+func IsWriteStatement(query string) (bool, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	statements, err := e.statements()
+	if err != nil {
+		return true, err
+	}
+
+	for _, tokens := range statements {
+		operation := statementOperation(tokens)
+		switch {
+		case writeOperations[operation]:
+			return true, nil
+		case !readOperations[operation]:
+			return true, fmt.Errorf("unrecognised statement: %s", operation)
+		}
+	}
+	if len(statements) == 0 {
+		return true, errors.New("empty query")
+	}
+	return false, nil
+}
+
+// statementOperation returns the upper cased leading keyword of a statement,
+// looking past opening parentheses and WITH common table expressions to the
+// statement they prefix
+func statementOperation(tokens []Token) string {
+	for len(tokens) > 1 && tokens[0].Value == "(" {
+		tokens = tokens[1:]
+	}
+	operation := strings.ToUpper(tokens[0].Value)
+	if operation != "WITH" {
+		return operation
+	}
+	depth := 0
+	for _, token := range tokens[1:] {
+		switch token.Value {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		default:
+			if depth == 0 && (token.IsKeyword("SELECT") || token.IsKeyword("INSERT")) {
+				return strings.ToUpper(token.Value)
+			}
+		}
+	}
+	return operation
+}
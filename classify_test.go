@@ -0,0 +1,58 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWriteStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		write bool
+	}{
+		{`SELECT a FROM t`, false},
+		{`select a from t`, false},
+		{`(SELECT 1) UNION ALL (SELECT 2)`, false},
+		{`WITH x AS (SELECT 1) SELECT * FROM x`, false},
+		{`DESCRIBE TABLE t`, false},
+		{`SHOW CREATE TABLE t`, false},
+		{`EXPLAIN INSERT INTO t VALUES (1)`, false},
+		{`SELECT 'DROP TABLE t' AS "insert"`, false},
+		{"SELECT `delete` FROM t -- ; DROP TABLE t", false},
+		{`SELECT 1 /* ; DROP TABLE t */`, false},
+		{`SELECT 1;`, false},
+		{`INSERT INTO t (a) VALUES (1)`, true},
+		{`WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x`, true},
+		{`alter table t delete where 1`, true},
+		{`DROP TABLE t`, true},
+		{`TRUNCATE TABLE t`, true},
+		{`SET max_threads = 1`, true},
+		{`SELECT 1; DROP TABLE t`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			write, err := IsWriteStatement(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.write, write)
+		})
+	}
+
+	t.Run(`empty`, func(t *testing.T) {
+		write, err := IsWriteStatement(" ; -- nothing")
+		assert.EqualError(t, err, `empty query`)
+		assert.True(t, write)
+	})
+
+	t.Run(`unrecognised`, func(t *testing.T) {
+		write, err := IsWriteStatement(`SELECT 1; FROBNICATE t`)
+		assert.EqualError(t, err, `unrecognised statement: FROBNICATE`)
+		assert.True(t, write)
+	})
+
+	t.Run(`tokenization error`, func(t *testing.T) {
+		write, err := IsWriteStatement("SELECT 1 ` ; DROP TABLE t")
+		assert.Error(t, err)
+		assert.True(t, write)
+	})
+}
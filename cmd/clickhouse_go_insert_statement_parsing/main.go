@@ -0,0 +1,75 @@
+// Command clickhouse_go_insert_statement_parsing prints the access-pattern
+// report of a script or turns CSV into INSERT statements. Without flags it
+// compares the parser with the regexp clickhouse-go extracts columns with
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	parsing "clickhouse_go_insert_statement_parsing"
+)
+
+func main() {
+	audit := flag.Bool("audit", false, "read a script from stdin and print its access-pattern report as JSON")
+	csvTable := flag.String("csv", "", "read CSV with a header row from stdin and print INSERT statements into this table")
+	batchSize := flag.Int("batch", 1000, "rows per INSERT statement with -csv")
+	formatCSV := flag.Bool("format-csv", false, "with -csv, print a single INSERT ... FORMAT CSV followed by the rows")
+	flag.Parse()
+
+	if *csvTable != "" {
+		table, err := parsing.Parser{}.ExtractTable("INSERT INTO " + *csvTable)
+		if err != nil || table.Table == "" {
+			fmt.Fprintf(os.Stderr, "invalid table name: %s\n", *csvTable)
+			os.Exit(2)
+		}
+		out := bufio.NewWriter(os.Stdout)
+		err = parsing.ConvertCSV(os.Stdin, out, table, parsing.CSVOptions{BatchSize: *batchSize, FormatCSV: *formatCSV})
+		if err == nil {
+			err = out.Flush()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *audit {
+		script, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			panic(err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(parsing.AuditReport(string(script))); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	queries := []string{
+		"INSERT INTO `DATA (BASE`.`A (TABLE)` ( `column \\`one`, columnTwo, 'col)umn\\' (three ')",
+		"INSERT INTO db.table (`ITEM`, `QTY (MT)`)",
+	}
+
+	for _, query := range queries {
+		columns, err := parsing.Parser{}.ExtractColumns(query)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println("parser based", query, columns)
+
+		matches := extractInsertColumnsMatch.FindStringSubmatch(query)
+
+		fmt.Println("regexp based", query, matches[1])
+	}
+}
+
+// copied from clickhouse-go source code
+var extractInsertColumnsMatch = regexp.MustCompile(`(?si)INSERT INTO .+\s\((?P<Columns>.+)\)$`)
@@ -0,0 +1,162 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"strings"
+)
+
+// StatementColumns describes an INSERT of a script
+type StatementColumns struct {
+	Statement int // number of the statement in the script, starting from 1
+	Pos       Position
+	Table     TableRef
+	Columns   []string
+}
+
+// ExtractAll returns the table and columns of each INSERT of a semicolon
+// separated script along with its position, skipping other statements. The
+// errors of statements that fail to tokenize are joined, the others are still
+// returned
+func ExtractAll(script string) ([]StatementColumns, error) {
+	e := &columnExtractor{
+		query: script,
+	}
+	all := make([]StatementColumns, 0)
+	errs := make([]error, 0)
+	for n := 1; e.byteIndex < len(e.query); n++ {
+		if err := e.parse(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(e.tokens) == 0 || e.tokens[0] == statementTerminator {
+			n--
+			continue
+		}
+		if !e.tokens[0].IsKeyword("INSERT") {
+			continue
+		}
+		statement := StatementColumns{
+			Statement: n,
+			Pos:       e.tokenPosition(0),
+			Columns:   e.columns(),
+		}
+		if refs := tableRefs(e.tokens); len(refs) > 0 {
+			statement.Table = refs[0]
+		}
+		all = append(all, statement)
+	}
+	return all, errors.Join(errs...)
+}
+
+// Column is a column of the column list of an INSERT along with the comments
+// next to it
+type Column struct {
+	Name string
+	// Comments holds the text of the comments attached to the column, without
+	// their delimiters
+	Comments []string
+	// Annotations holds the items of the comments right after the name, as
+	// in a /* UInt64, required, default=0 */
+	Annotations []Annotation
+}
+
+// Annotation is an item of a comma separated comment following a column
+// name, either key=value or a bare key, e.g. a type or a flag
+type Annotation struct {
+	Key   string
+	Value string // empty for bare keys
+}
+
+// ExtractColumnComments returns the columns of the first statement of query
+// with the comments of the column list attached to them. A comment belongs
+// to the column ending on the same line before it, as in a, -- key, and
+// otherwise to the column following it
+func ExtractColumnComments(query string) ([]Column, error) {
+	e := &columnExtractor{
+		query:        query,
+		keepComments: true,
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	return e.columnComments(), nil
+}
+
+func (e *columnExtractor) columnComments() []Column {
+	columns := make([]Column, 0)
+	open, end, ok := e.columnList()
+	if !ok {
+		return columns
+	}
+
+	// The columns are split as by columns(), the comments between them
+	// attached as they come
+	listed := e.listedColumns()
+	var pending []string
+	previousEnd := -1 // offset right after the last column
+	for i := open + 1; i < end; i++ {
+		if len(columns) < len(listed) && i == listed[len(columns)].first {
+			column := listed[len(columns)]
+			columns = append(columns, Column{Name: column.name, Comments: pending})
+			pending = nil
+			i = column.last
+			previousEnd = e.tokenEnd(i)
+			continue
+		}
+		if e.tokens[i].Kind != TokenComment {
+			continue
+		}
+		text := commentText(e.tokens[i].Value)
+		if previousEnd < 0 || strings.Contains(e.query[previousEnd:e.offsets[i]], "\n") {
+			pending = append(pending, text)
+			continue
+		}
+		column := &columns[len(columns)-1]
+		column.Comments = append(column.Comments, text)
+		if e.tokens[i-1].Kind != TokenComment && e.tokens[i-1].Value != "," {
+			column.Annotations = append(column.Annotations, parseAnnotations(text)...)
+		}
+	}
+	if len(pending) > 0 && len(columns) > 0 {
+		columns[len(columns)-1].Comments = append(columns[len(columns)-1].Comments, pending...)
+	}
+	return columns
+}
+
+// parseAnnotations splits a comment at the commas outside parentheses, so
+// that types such as Map(String, UInt64) stay whole
+func parseAnnotations(text string) []Annotation {
+	var annotations []Annotation
+	depth, start := 0, 0
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) {
+			switch text[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if text[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		item := strings.TrimSpace(text[start:i])
+		start = i + 1
+		if item == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(item, "=")
+		annotations = append(annotations, Annotation{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return annotations
+}
+
+// commentText strips the delimiters and surrounding spaces off a comment
+func commentText(comment string) string {
+	if strings.HasPrefix(comment, "/*") {
+		comment = strings.TrimSuffix(comment[2:], "*/")
+	} else {
+		comment = strings.TrimPrefix(comment, "--")
+	}
+	return strings.TrimSpace(comment)
+}
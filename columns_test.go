@@ -0,0 +1,137 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractColumnComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []Column
+	}{
+		{
+			name:  `trailing comments`,
+			query: "INSERT INTO t (a, -- primary key\n b /* metric */)",
+			columns: []Column{
+				{Name: `a`, Comments: []string{`primary key`}},
+				{Name: `b`, Comments: []string{`metric`}, Annotations: []Annotation{{Key: `metric`}}},
+			},
+		},
+		{
+			name:  `leading comments`,
+			query: "INSERT INTO t (\n-- the key\na,\n/* one */ /* two */ b)",
+			columns: []Column{
+				{Name: `a`, Comments: []string{`the key`}},
+				{Name: `b`, Comments: []string{`one`, `two`}},
+			},
+		},
+		{
+			name:  `comment before the closing parenthesis`,
+			query: "INSERT INTO t (a,\nb\n-- last\n) VALUES",
+			columns: []Column{
+				{Name: `a`},
+				{Name: `b`, Comments: []string{`last`}},
+			},
+		},
+		{
+			name:  `annotations`,
+			query: "INSERT INTO t (a /* UInt64, required */, b /* Map(String, UInt8), default = {} */ /* second */,\n/* leading */ c)",
+			columns: []Column{
+				{
+					Name:        `a`,
+					Comments:    []string{`UInt64, required`},
+					Annotations: []Annotation{{Key: `UInt64`}, {Key: `required`}},
+				},
+				{
+					Name:        `b`,
+					Comments:    []string{`Map(String, UInt8), default = {}`, `second`},
+					Annotations: []Annotation{{Key: `Map(String, UInt8)`}, {Key: `default`, Value: `{}`}},
+				},
+				{Name: `c`, Comments: []string{`leading`}},
+			},
+		},
+		{
+			name:  `nested columns`,
+			query: "INSERT INTO t (n.a /* UInt8 */, n.b -- String\n)",
+			columns: []Column{
+				{Name: `n.a`, Comments: []string{`UInt8`}, Annotations: []Annotation{{Key: `UInt8`}}},
+				{Name: `n.b`, Comments: []string{`String`}, Annotations: []Annotation{{Key: `String`}}},
+			},
+		},
+		{
+			name:  `comment between INSERT and INTO`,
+			query: "INSERT -- x\nINTO t (a /* UInt8 */, b)",
+			columns: []Column{
+				{Name: `a`, Comments: []string{`UInt8`}, Annotations: []Annotation{{Key: `UInt8`}}},
+				{Name: `b`},
+			},
+		},
+		{
+			name:  `columns matcher`,
+			query: "INSERT INTO t (a, COLUMNS('b.*') -- x\n, /* y */ (c)) VALUES",
+			columns: []Column{
+				{Name: `a`},
+				{Name: `COLUMNS('b.*')`, Comments: []string{`x`}, Annotations: []Annotation{{Key: `x`}}},
+				{Name: `c`, Comments: []string{`y`}},
+			},
+		},
+		{
+			name:    `no column list`,
+			query:   `INSERT INTO t /* x */ VALUES`,
+			columns: []Column{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := ExtractColumnComments(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.columns, columns)
+
+			// The columns are those ExtractColumns returns
+			names := make([]string, 0, len(columns))
+			for _, column := range columns {
+				names = append(names, column.Name)
+			}
+			extracted, err := Parser{}.ExtractColumns(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, extracted, names)
+		})
+	}
+
+	_, err := ExtractColumnComments(`INSERT INTO t (a /* x`)
+	assert.EqualError(t, err, `1:18: unclosed block comment`)
+}
+
+func TestExtractAll(t *testing.T) {
+	script := "-- migration\nINSERT INTO db.a (x, y) VALUES (1, 2);\n\nSELECT 1;;\nINSERT INTO b VALUES (3);\n  insert into c (`z`) format CSV\n1\n"
+	all, err := ExtractAll(script)
+	assert.NoError(t, err)
+	assert.Equal(t, []StatementColumns{
+		{
+			Statement: 1,
+			Pos:       Position{Offset: 13, Line: 2, Column: 1},
+			Table:     TableRef{Database: `db`, Table: `a`},
+			Columns:   []string{`x`, `y`},
+		},
+		{
+			Statement: 3,
+			Pos:       Position{Offset: 64, Line: 5, Column: 1},
+			Table:     TableRef{Table: `b`},
+			Columns:   []string{},
+		},
+		{
+			Statement: 4,
+			Pos:       Position{Offset: 92, Line: 6, Column: 3},
+			Table:     TableRef{Table: `c`},
+			Columns:   []string{"`z`"},
+		},
+	}, all)
+
+	all, err = ExtractAll("INSERT INTO a (x €);\nINSERT INTO b (y)")
+	assert.EqualError(t, err, `1:18: unexpected rune: €`)
+	assert.Equal(t, []string{`y`}, all[0].Columns)
+	assert.Equal(t, 2, all[0].Statement)
+}
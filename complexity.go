@@ -0,0 +1,78 @@
+package clickhouse_go_insert_statement_parsing
+
+// Weights applied to each component when folding a Score into its Total.
+// Nesting is weighted heavily since deeply nested expressions are what make
+// a query expensive to analyse, while payload size is scaled down so a long
+// but flat VALUES list doesn't dominate the score
+const (
+	tokenWeight      = 1
+	depthWeight      = 10
+	expressionWeight = 2
+	bytesPerPoint    = 64
+)
+
+// Score describes the shape of a query for admission control purposes
+type Score struct {
+	Tokens      int // number of tokens in the query
+	Depth       int // deepest parenthesis nesting
+	Expressions int // comma separated expressions inside parentheses
+	Bytes       int // size of the query in bytes
+	Total       int // weighted combination of the fields above
+}
+
+// ComplexityScore tokenises the query and combines its token count, nesting
+// depth, expression count and payload size into a single score.
+// Tokenisation errors are ignored so that malformed input still gets scored
+func ComplexityScore(query string) Score {
+	e := &columnExtractor{
+		query: query,
+	}
+	tokens, _ := e.allTokens()
+
+	score := Score{
+		Tokens: len(tokens),
+		Bytes:  len(query),
+	}
+
+	// nonEmpty tracks, per open parenthesis or bracket, whether anything has been seen
+	// since it was opened so that "()" doesn't count as an expression
+	nonEmpty := make([]bool, 0, 8)
+	for _, token := range tokens {
+		switch token.Value {
+		case "(", "[":
+			if len(nonEmpty) > 0 {
+				nonEmpty[len(nonEmpty)-1] = true
+			}
+			nonEmpty = append(nonEmpty, false)
+			score.Depth = max(score.Depth, len(nonEmpty))
+		case ")", "]":
+			if len(nonEmpty) == 0 {
+				continue
+			}
+			if nonEmpty[len(nonEmpty)-1] {
+				score.Expressions++
+			}
+			nonEmpty = nonEmpty[:len(nonEmpty)-1]
+		case ",":
+			if len(nonEmpty) > 0 {
+				score.Expressions++
+			}
+		default:
+			if len(nonEmpty) > 0 {
+				nonEmpty[len(nonEmpty)-1] = true
+			}
+		}
+	}
+	// Unclosed parentheses still hold expressions
+	for _, seen := range nonEmpty {
+		if seen {
+			score.Expressions++
+		}
+	}
+
+	score.Total = score.Tokens*tokenWeight +
+		score.Depth*depthWeight +
+		score.Expressions*expressionWeight +
+		score.Bytes/bytesPerPoint
+	return score
+}
@@ -0,0 +1,60 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplexityScore(t *testing.T) {
+	t.Run(`simple`, func(t *testing.T) {
+		query := `INSERT INTO table (column1, column2)`
+		score := ComplexityScore(query)
+		assert.Equal(t, 8, score.Tokens)
+		assert.Equal(t, 1, score.Depth)
+		assert.Equal(t, 2, score.Expressions)
+		assert.Equal(t, len(query), score.Bytes)
+		assert.Equal(t, 8+10+4, score.Total)
+	})
+
+	t.Run(`nesting`, func(t *testing.T) {
+		score := ComplexityScore(`INSERT INTO table (a, b) SELECT f(g(x), y)`)
+		assert.Equal(t, 2, score.Depth)
+		// a, b / g(x), y / x
+		assert.Equal(t, 5, score.Expressions)
+	})
+
+	t.Run(`array literals nest`, func(t *testing.T) {
+		score := ComplexityScore(`INSERT INTO t (a) VALUES ([[1, 2], []])`)
+		assert.Equal(t, 3, score.Depth)
+		// a / [[1, 2], []] / [1, 2], [] / 1, 2
+		assert.Equal(t, 6, score.Expressions)
+	})
+
+	t.Run(`empty parentheses hold no expressions`, func(t *testing.T) {
+		score := ComplexityScore(`INSERT INTO table ()`)
+		assert.Equal(t, 1, score.Depth)
+		assert.Equal(t, 0, score.Expressions)
+	})
+
+	t.Run(`unbalanced parentheses`, func(t *testing.T) {
+		score := ComplexityScore(`INSERT INTO table (a, b`)
+		assert.Equal(t, 2, score.Expressions)
+		score = ComplexityScore(`INSERT INTO table a)`)
+		assert.Equal(t, 0, score.Depth)
+	})
+
+	t.Run(`every statement counts`, func(t *testing.T) {
+		score := ComplexityScore(`INSERT INTO t (a); INSERT INTO u (b, c)`)
+		assert.Equal(t, 15, score.Tokens)
+		assert.Equal(t, 3, score.Expressions)
+	})
+
+	t.Run(`payload size`, func(t *testing.T) {
+		small := ComplexityScore(`INSERT INTO table (a)`)
+		large := ComplexityScore(`INSERT INTO table (a` + strings.Repeat(` `, 640) + `)`)
+		assert.Equal(t, small.Tokens, large.Tokens)
+		assert.Equal(t, small.Total+10, large.Total)
+	})
+}
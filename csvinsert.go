@@ -0,0 +1,108 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// CSVOptions controls ConvertCSV
+type CSVOptions struct {
+	// BatchSize is the number of rows per INSERT ... VALUES statement
+	BatchSize int
+	// FormatCSV writes a single INSERT ... FORMAT CSV followed by the rows
+	// instead of VALUES statements
+	FormatCSV bool
+}
+
+// ConvertCSV reads CSV with a header row from r and writes INSERT statements
+// into table to w, one per batch of rows and terminated by semicolons. Rows
+// are streamed, only a batch is held in memory. Every statement written is
+// parsed back and its columns checked against the header
+func ConvertCSV(r io.Reader, w io.Writer, table TableRef, options CSVOptions) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	builder := InsertBuilder{
+		Table:   table,
+		Columns: header,
+	}
+
+	if options.FormatCSV {
+		statement := builder.Format("CSV")
+		if err := checkInsert(statement, header); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, statement); err != nil {
+			return err
+		}
+		writer := csv.NewWriter(w)
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	batchSize := max(options.BatchSize, 1)
+	batch := make([][]string, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		statement := builder.Values(batch)
+		batch = batch[:0]
+		if err := checkInsert(statement, header); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%s;\n", statement)
+		return err
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch = append(batch, record)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// checkInsert parses statement and verifies its columns are columns
+func checkInsert(statement string, columns []string) error {
+	e := &columnExtractor{
+		query: statement,
+	}
+	if err := e.parse(); err != nil {
+		return fmt.Errorf("self-check of generated INSERT failed: %w", err)
+	}
+	parsed := e.columns()
+	for i, column := range parsed {
+		parsed[i] = unquoteIdentifier(column)
+	}
+	if !slices.Equal(parsed, columns) {
+		return fmt.Errorf("self-check of generated INSERT failed: columns %q, expected %q", parsed, columns)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertCSV(t *testing.T) {
+	input := "id,name,`odd` col\n1,alice,x\n2,\"o'brien\",3.5\n3,bob,\n"
+
+	t.Run(`batched values`, func(t *testing.T) {
+		var out strings.Builder
+		err := ConvertCSV(strings.NewReader(input), &out, TableRef{Database: `db`, Table: `t`}, CSVOptions{BatchSize: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO db.t (id, name, `\\`odd\\` col`) VALUES (1, 'alice', 'x'), (2, 'o\\'brien', 3.5);\n"+
+			"INSERT INTO db.t (id, name, `\\`odd\\` col`) VALUES (3, 'bob', '');\n", out.String())
+	})
+
+	t.Run(`format csv`, func(t *testing.T) {
+		var out strings.Builder
+		err := ConvertCSV(strings.NewReader(input), &out, TableRef{Table: `t`}, CSVOptions{FormatCSV: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO t (id, name, `\\`odd\\` col`) FORMAT CSV\n1,alice,x\n2,o'brien,3.5\n3,bob,\n", out.String())
+	})
+
+	t.Run(`header only`, func(t *testing.T) {
+		var out strings.Builder
+		assert.NoError(t, ConvertCSV(strings.NewReader("a\n"), &out, TableRef{Table: `t`}, CSVOptions{}))
+		assert.Empty(t, out.String())
+	})
+
+	t.Run(`empty input`, func(t *testing.T) {
+		var out strings.Builder
+		assert.EqualError(t, ConvertCSV(strings.NewReader(""), &out, TableRef{Table: `t`}, CSVOptions{}), `reading header: EOF`)
+	})
+
+	t.Run(`self-check`, func(t *testing.T) {
+		assert.NoError(t, checkInsert("INSERT INTO t (a, `b c`) VALUES (1, 2)", []string{`a`, `b c`}))
+		assert.EqualError(t, checkInsert(`INSERT INTO t (a) VALUES (1)`, []string{`b`}),
+			`self-check of generated INSERT failed: columns ["a"], expected ["b"]`)
+	})
+}
@@ -0,0 +1,396 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// CreateTable describes a CREATE TABLE statement
+type CreateTable struct {
+	Table   TableRef
+	Columns []ColumnDefinition
+	// Elements holds the INDEX, PROJECTION and CONSTRAINT declarations of the
+	// column definition list as written
+	Elements []string
+
+	// Table-level clauses, with an empty Expr when left out
+	Engine      Clause
+	OrderBy     Clause
+	PartitionBy Clause
+	PrimaryKey  Clause
+	SampleBy    Clause
+	TTL         Clause
+	Settings    Clause
+	Comment     string // decoded text of COMMENT '...'
+}
+
+// Clause is the expression of a clause as written along with its position
+type Clause struct {
+	Expr string
+	Pos  Position
+}
+
+// tableClauses holds the words, possibly two, starting the clauses following
+// the column definitions of CREATE TABLE
+var tableClauses = [][]string{
+	{"ENGINE"}, {"ORDER", "BY"}, {"PARTITION", "BY"}, {"PRIMARY", "KEY"}, {"SAMPLE", "BY"}, {"TTL"},
+	{"SETTINGS"}, {"COMMENT"}, {"AS"}, {"EMPTY"},
+}
+
+// ColumnDefinition is a column declared by CREATE TABLE
+type ColumnDefinition struct {
+	Name string // as written, quotes included
+	Type string // type expression as written, e.g. Array(String), empty if left out
+	// Nullable is set for Nullable(...) types and types followed by NULL,
+	// unlike NOT NULL
+	Nullable bool
+	// DefaultKind is DEFAULT, MATERIALIZED, ALIAS or EPHEMERAL, in upper case,
+	// and Default the expression following it
+	DefaultKind string
+	Default     string
+	Comment     string // decoded text of COMMENT '...'
+	Codec       string // arguments of CODEC(...), e.g. Delta, ZSTD(1)
+	TTL         string // expression of the column TTL
+	Pos         Position
+}
+
+// columnModifiers holds the words ending the type of a column definition
+var columnModifiers = []string{
+	"NULL", "NOT", "DEFAULT", "MATERIALIZED", "ALIAS", "EPHEMERAL", "CODEC", "COMMENT", "TTL", "PRIMARY", "SETTINGS",
+}
+
+// defaultKinds holds the words introducing the default expression of a column
+var defaultKinds = []string{"DEFAULT", "MATERIALIZED", "ALIAS", "EPHEMERAL"}
+
+// tableElements holds the words starting the elements of the column
+// definition list that aren't columns
+var tableElements = []string{"INDEX", "PROJECTION", "CONSTRAINT", "PRIMARY"}
+
+// ParseCreateTable parses the column definitions of the first statement of
+// query, which has to be a CREATE TABLE
+func ParseCreateTable(query string) (*CreateTable, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	i := e.skipComments(0)
+	if i == len(e.tokens) || !e.tokens[i].IsKeyword("CREATE") {
+		return nil, errors.New("not a CREATE TABLE statement")
+	}
+	i = skipKeywords(e.tokens, i+1, "OR", "REPLACE", "TEMPORARY")
+	if i == len(e.tokens) || !e.tokens[i].IsKeyword("TABLE") {
+		return nil, errors.New("not a CREATE TABLE statement")
+	}
+	i = skipKeywords(e.tokens, i+1, "IF", "NOT", "EXISTS")
+	table, i := readTableName(e.tokens, i)
+	if table.Table == "" {
+		return nil, errors.New("missing table name")
+	}
+	create := &CreateTable{
+		Table:   table,
+		Columns: make([]ColumnDefinition, 0),
+	}
+	i = e.skipOnCluster(i)
+	// CREATE TABLE t AS other or AS SELECT has no column definitions
+	if i < len(e.tokens) && e.tokens[i].Value == "(" {
+		end := e.matchingParenthesis(i)
+		if end < 0 {
+			return nil, e.errorAt(i, "unclosed column definitions")
+		}
+		for _, element := range e.splitList(i+1, end) {
+			switch {
+			case e.clauseAt(element[0]) == "PRIMARY KEY" && len(element) > 2:
+				create.PrimaryKey = e.clause(element[2], element[len(element)-1])
+			case slices.ContainsFunc(tableElements, func(word string) bool { return isWord(e.tokens[element[0]], word) }):
+				create.Elements = append(create.Elements, e.sourceText(element[0], element[len(element)-1]))
+			default:
+				create.Columns = append(create.Columns, e.columnDefinition(element))
+			}
+		}
+		i = end + 1
+	}
+	e.tableClauses(create, i)
+	return create, nil
+}
+
+// skipOnCluster advances past ON CLUSTER name at index i, if present
+func (e *columnExtractor) skipOnCluster(i int) int {
+	_, i = e.onCluster(i)
+	return i
+}
+
+// onCluster returns the name of the cluster of ON CLUSTER name at index i,
+// if present, and the index following it
+func (e *columnExtractor) onCluster(i int) (string, int) {
+	if i+1 < len(e.tokens) && e.tokens[i].IsKeyword("ON") && isWord(e.tokens[i+1], "CLUSTER") {
+		cluster, next := readTableName(e.tokens, i+2)
+		return cluster.Table, next
+	}
+	return "", i
+}
+
+// AlterTable describes the column operations of an ALTER TABLE statement
+type AlterTable struct {
+	Table      TableRef
+	Operations []ColumnOperation
+}
+
+// ColumnOperation is an ADD, DROP, MODIFY or RENAME COLUMN command of ALTER
+// TABLE
+type ColumnOperation struct {
+	Action string // ADD, DROP, MODIFY or RENAME, in upper case
+	// Column holds the name of the column and, for ADD and MODIFY, its new
+	// type, nullability and default
+	Column  ColumnDefinition
+	NewName string // RENAME COLUMN ... TO name
+	After   string // ADD or MODIFY COLUMN ... AFTER name
+	First   bool   // ADD or MODIFY COLUMN ... FIRST
+	Pos     Position
+}
+
+// ParseAlterTable parses the column operations of the first statement of
+// query, which has to be an ALTER TABLE. Other commands, e.g. ADD INDEX or
+// UPDATE, are left out
+func ParseAlterTable(query string) (*AlterTable, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	i := e.skipComments(0)
+	if i+1 >= len(e.tokens) || !e.tokens[i].IsKeyword("ALTER") || !e.tokens[i+1].IsKeyword("TABLE") {
+		return nil, errors.New("not an ALTER TABLE statement")
+	}
+	table, i := readTableName(e.tokens, i+2)
+	if table.Table == "" {
+		return nil, errors.New("missing table name")
+	}
+	alter := &AlterTable{
+		Table:      table,
+		Operations: make([]ColumnOperation, 0),
+	}
+	i = e.skipOnCluster(i)
+	end := len(e.tokens)
+	if end > 0 && e.tokens[end-1] == statementTerminator {
+		end--
+	}
+	for _, command := range e.splitList(i, end) {
+		if operation, ok := e.columnOperation(command); ok {
+			alter.Operations = append(alter.Operations, operation)
+		}
+	}
+	return alter, nil
+}
+
+// columnOperation parses the tokens at the given indexes as a column command
+// of ALTER TABLE, reporting false for other commands
+func (e *columnExtractor) columnOperation(command []int) (ColumnOperation, bool) {
+	action := strings.ToUpper(e.tokens[command[0]].Value)
+	if !slices.Contains([]string{"ADD", "DROP", "MODIFY", "RENAME"}, action) ||
+		len(command) < 3 || !isWord(e.tokens[command[1]], "COLUMN") {
+		return ColumnOperation{}, false
+	}
+	operation := ColumnOperation{
+		Action: action,
+		Pos:    e.tokenPosition(command[0]),
+	}
+	rest := command[2:]
+	for _, words := range [][]string{{"IF", "NOT", "EXISTS"}, {"IF", "EXISTS"}} {
+		if len(rest) > len(words) && slices.EqualFunc(words, rest[:len(words)], func(word string, i int) bool {
+			return isWord(e.tokens[i], word)
+		}) {
+			rest = rest[len(words):]
+			break
+		}
+	}
+
+	switch action {
+	case "DROP":
+		operation.Column = ColumnDefinition{Name: e.tokens[rest[0]].Value, Pos: e.tokenPosition(rest[0])}
+	case "RENAME":
+		operation.Column = ColumnDefinition{Name: e.tokens[rest[0]].Value, Pos: e.tokenPosition(rest[0])}
+		if len(rest) == 3 && e.tokens[rest[1]].IsKeyword("TO") {
+			operation.NewName = e.tokens[rest[2]].Value
+		}
+	default:
+		n := len(rest)
+		switch {
+		case n > 2 && isWord(e.tokens[rest[n-2]], "AFTER"):
+			operation.After = e.tokens[rest[n-1]].Value
+			rest = rest[:n-2]
+		case n > 1 && isWord(e.tokens[rest[n-1]], "FIRST"):
+			operation.First = true
+			rest = rest[:n-1]
+		}
+		operation.Column = e.columnDefinition(rest)
+	}
+	return operation, true
+}
+
+// tableClauses fills in the clauses of create following the column
+// definitions, from the token at index i on
+func (e *columnExtractor) tableClauses(create *CreateTable, i int) {
+	clauses := map[string]*Clause{
+		"ENGINE":       &create.Engine,
+		"ORDER BY":     &create.OrderBy,
+		"PARTITION BY": &create.PartitionBy,
+		"PRIMARY KEY":  &create.PrimaryKey,
+		"SAMPLE BY":    &create.SampleBy,
+		"TTL":          &create.TTL,
+		"SETTINGS":     &create.Settings,
+	}
+	for i < len(e.tokens) {
+		name := e.clauseAt(i)
+		if name == "" {
+			i++
+			continue
+		}
+		start := e.skipComments(i + len(strings.Fields(name)))
+		if name == "ENGINE" && start < len(e.tokens) && e.tokens[start].Value == "=" {
+			start = e.skipComments(start + 1)
+		}
+		end, depth := start, 0
+		for ; end < len(e.tokens) && e.tokens[end] != statementTerminator; end++ {
+			switch e.tokens[end].Value {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+			}
+			if depth == 0 && end > start && e.clauseAt(end) != "" {
+				break
+			}
+		}
+		if clause, ok := clauses[name]; ok && end > start {
+			last := end - 1
+			for e.tokens[last].Kind == TokenComment {
+				last--
+			}
+			*clause = e.clause(start, last)
+		}
+		if name == "COMMENT" && start < len(e.tokens) && e.tokens[start].Kind == TokenString {
+			create.Comment, _ = e.tokens[start].DecodedValue()
+		}
+		if name == "AS" {
+			// The rest is the query of CREATE TABLE ... AS SELECT
+			return
+		}
+		i = end
+	}
+}
+
+// clauseAt returns the words starting a table-level clause at index i,
+// separated by a space and upper cased, or an empty string
+func (e *columnExtractor) clauseAt(i int) string {
+	for _, words := range tableClauses {
+		if i+len(words) > len(e.tokens) {
+			continue
+		}
+		matches := true
+		for n, word := range words {
+			matches = matches && isWord(e.tokens[i+n], word)
+		}
+		if matches {
+			return strings.Join(words, " ")
+		}
+	}
+	return ""
+}
+
+// clause returns the clause made of the tokens from first to last
+func (e *columnExtractor) clause(first, last int) Clause {
+	return Clause{Expr: e.sourceText(first, last), Pos: e.tokenPosition(first)}
+}
+
+// columnDefinition parses the tokens at the given indexes as a column
+// definition: a name, a type and modifiers
+func (e *columnExtractor) columnDefinition(element []int) ColumnDefinition {
+	column := ColumnDefinition{
+		Name: e.tokens[element[0]].Value,
+		Pos:  e.tokenPosition(element[0]),
+	}
+	// The type runs up to the first modifier outside parentheses
+	typeEnd := len(element)
+	depth := 0
+	for n := 1; n < len(element) && typeEnd == len(element); n++ {
+		token := e.tokens[element[n]]
+		switch {
+		case token.Value == "(":
+			depth++
+		case token.Value == ")":
+			depth--
+		case depth == 0 && slices.ContainsFunc(columnModifiers, func(word string) bool { return isWord(token, word) }):
+			typeEnd = n
+		}
+	}
+	if typeEnd > 1 {
+		column.Type = e.sourceText(element[1], element[typeEnd-1])
+		column.Nullable = strings.HasPrefix(strings.ToLower(column.Type), "nullable(")
+	}
+
+	for n := typeEnd; n < len(element); n++ {
+		token := e.tokens[element[n]]
+		switch {
+		case token.IsKeyword("NOT") && n+1 < len(element) && e.tokens[element[n+1]].IsKeyword("NULL"):
+			column.Nullable = false
+			n++
+		case token.IsKeyword("NULL"):
+			column.Nullable = true
+		case slices.ContainsFunc(defaultKinds, func(word string) bool { return isWord(token, word) }):
+			column.DefaultKind = strings.ToUpper(token.Value)
+			end := e.modifierEnd(element, n+1)
+			if end > n+1 {
+				column.Default = e.sourceText(element[n+1], element[end-1])
+			}
+			n = end - 1
+		case isWord(token, "COMMENT") && n+1 < len(element) && e.tokens[element[n+1]].Kind == TokenString:
+			column.Comment, _ = e.tokens[element[n+1]].DecodedValue()
+			n++
+		case isWord(token, "CODEC") && n+1 < len(element) && e.tokens[element[n+1]].Value == "(":
+			close := e.matchingParenthesis(element[n+1])
+			if close > element[n+1]+1 {
+				column.Codec = e.sourceText(element[n+1]+1, close-1)
+			}
+			for n+1 < len(element) && element[n+1] <= close {
+				n++
+			}
+		case isWord(token, "TTL"):
+			end := e.modifierEnd(element, n+1)
+			if end > n+1 {
+				column.TTL = e.sourceText(element[n+1], element[end-1])
+			}
+			n = end - 1
+		}
+	}
+	return column
+}
+
+// modifierEnd returns the position in element of the next column modifier
+// from n on, outside parentheses, or len(element)
+func (e *columnExtractor) modifierEnd(element []int, n int) int {
+	depth := 0
+	for ; n < len(element); n++ {
+		token := e.tokens[element[n]]
+		switch {
+		case token.Value == "(" || token.Value == "[":
+			depth++
+		case token.Value == ")" || token.Value == "]":
+			depth--
+		case depth == 0 && slices.ContainsFunc(columnModifiers, func(word string) bool {
+			return word != "NULL" && word != "NOT" && isWord(token, word)
+		}):
+			return n
+		}
+	}
+	return n
+}
+
+// isWord reports whether the token is the given word, either as a keyword
+// or, for words that aren't keywords, as a bare identifier
+func isWord(token Token, word string) bool {
+	return (token.Kind == TokenKeyword || token.Kind == TokenIdentifier) && strings.EqualFold(token.Value, word)
+}
@@ -0,0 +1,126 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCreateTable(t *testing.T) {
+	query := "CREATE TABLE IF NOT EXISTS db.events ON CLUSTER main (\n" +
+		"    id UInt64,\n" +
+		"    `user name` Nullable(String),\n" +
+		"    tags Array(LowCardinality(String)) DEFAULT [],\n" +
+		"    amount Decimal(18, 2) NOT NULL DEFAULT 0 COMMENT 'total',\n" +
+		"    note String NULL,\n" +
+		"    day Date MATERIALIZED toDate(ts) CODEC(Delta, ZSTD),\n" +
+		"    host ALIAS splitByChar('.', fqdn)[1],\n" +
+		"    INDEX idx id TYPE minmax GRANULARITY 1,\n" +
+		"    PRIMARY KEY (id)\n" +
+		") ENGINE = MergeTree ORDER BY id"
+	create, err := ParseCreateTable(query)
+	assert.NoError(t, err)
+	assert.Equal(t, TableRef{Database: `db`, Table: `events`}, create.Table)
+	assert.Equal(t, []ColumnDefinition{
+		{Name: `id`, Type: `UInt64`, Pos: Position{Offset: 59, Line: 2, Column: 5}},
+		{Name: "`user name`", Type: `Nullable(String)`, Nullable: true, Pos: Position{Offset: 74, Line: 3, Column: 5}},
+		{Name: `tags`, Type: `Array(LowCardinality(String))`, DefaultKind: `DEFAULT`, Default: `[]`, Pos: Position{Offset: 108, Line: 4, Column: 5}},
+		{Name: `amount`, Type: `Decimal(18, 2)`, DefaultKind: `DEFAULT`, Default: `0`, Comment: `total`, Pos: Position{Offset: 159, Line: 5, Column: 5}},
+		{Name: `note`, Type: `String`, Nullable: true, Pos: Position{Offset: 221, Line: 6, Column: 5}},
+		{Name: `day`, Type: `Date`, DefaultKind: `MATERIALIZED`, Default: `toDate(ts)`, Codec: `Delta, ZSTD`, Pos: Position{Offset: 243, Line: 7, Column: 5}},
+		{Name: `host`, DefaultKind: `ALIAS`, Default: `splitByChar('.', fqdn)[1]`, Pos: Position{Offset: 300, Line: 8, Column: 5}},
+	}, create.Columns)
+
+	t.Run(`table clauses`, func(t *testing.T) {
+		assert.Equal(t, Clause{Expr: `(id)`, Pos: Position{Offset: 398, Line: 10, Column: 17}}, create.PrimaryKey)
+		assert.Equal(t, Clause{Expr: `MergeTree`, Pos: Position{Offset: 414, Line: 11, Column: 12}}, create.Engine)
+		assert.Equal(t, Clause{Expr: `id`, Pos: Position{Offset: 433, Line: 11, Column: 31}}, create.OrderBy)
+		assert.Empty(t, create.TTL)
+
+		create, err := ParseCreateTable("CREATE TABLE t (d Date, id UInt64)\n" +
+			"ENGINE = ReplicatedMergeTree('/tables/{shard}/t', '{replica}')\n" +
+			"PARTITION BY toYYYYMM(d) ORDER BY (id, intHash32(id)) SAMPLE BY intHash32(id)\n" +
+			"PRIMARY KEY id TTL d + INTERVAL 1 MONTH DELETE, d + INTERVAL 1 WEEK TO DISK 'cold'\n" +
+			"SETTINGS index_granularity = 8192 COMMENT 'events';")
+		assert.NoError(t, err)
+		exprs := []string{create.Engine.Expr, create.PartitionBy.Expr, create.OrderBy.Expr, create.SampleBy.Expr, create.PrimaryKey.Expr, create.TTL.Expr}
+		assert.Equal(t, []string{
+			`ReplicatedMergeTree('/tables/{shard}/t', '{replica}')`,
+			`toYYYYMM(d)`,
+			`(id, intHash32(id))`,
+			`intHash32(id)`,
+			`id`,
+			`d + INTERVAL 1 MONTH DELETE, d + INTERVAL 1 WEEK TO DISK 'cold'`,
+		}, exprs)
+		assert.Equal(t, Position{Offset: 188, Line: 4, Column: 13}, create.PrimaryKey.Pos)
+	})
+
+	t.Run(`without column definitions`, func(t *testing.T) {
+		create, err := ParseCreateTable(`CREATE TABLE t AS other`)
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Table: `t`}, create.Table)
+		assert.Empty(t, create.Columns)
+
+		create, err = ParseCreateTable(`CREATE TABLE t ENGINE = Memory AS SELECT 1 AS x ORDER BY x`)
+		assert.NoError(t, err)
+		assert.Equal(t, `Memory`, create.Engine.Expr)
+		assert.Empty(t, create.OrderBy)
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		_, err := ParseCreateTable(`CREATE VIEW v AS SELECT 1`)
+		assert.EqualError(t, err, `not a CREATE TABLE statement`)
+		_, err = ParseCreateTable(`INSERT INTO t (a)`)
+		assert.EqualError(t, err, `not a CREATE TABLE statement`)
+		_, err = ParseCreateTable(`CREATE TABLE (a UInt8)`)
+		assert.EqualError(t, err, `missing table name`)
+		_, err = ParseCreateTable(`CREATE TABLE t (a UInt8`)
+		assert.EqualError(t, err, `1:16: unclosed column definitions`)
+	})
+}
+
+func TestParseAlterTable(t *testing.T) {
+	alter, err := ParseAlterTable("ALTER TABLE db.t ON CLUSTER main\n" +
+		"ADD COLUMN IF NOT EXISTS x UInt64 DEFAULT 0 AFTER y,\n" +
+		"ADD COLUMN z Nullable(String) FIRST,\n" +
+		"DROP COLUMN IF EXISTS old,\n" +
+		"MODIFY COLUMN x Decimal(10, 2),\n" +
+		"RENAME COLUMN a TO b,\n" +
+		"ADD INDEX idx x TYPE minmax GRANULARITY 1,\n" +
+		"UPDATE x = 1 WHERE 1;")
+	assert.NoError(t, err)
+	assert.Equal(t, TableRef{Database: `db`, Table: `t`}, alter.Table)
+	assert.Equal(t, []ColumnOperation{
+		{
+			Action: `ADD`,
+			Column: ColumnDefinition{Name: `x`, Type: `UInt64`, DefaultKind: `DEFAULT`, Default: `0`, Pos: Position{Offset: 58, Line: 2, Column: 26}},
+			After:  `y`,
+			Pos:    Position{Offset: 33, Line: 2, Column: 1},
+		},
+		{
+			Action: `ADD`,
+			Column: ColumnDefinition{Name: `z`, Type: `Nullable(String)`, Nullable: true, Pos: Position{Offset: 97, Line: 3, Column: 12}},
+			First:  true,
+			Pos:    Position{Offset: 86, Line: 3, Column: 1},
+		},
+		{
+			Action: `DROP`,
+			Column: ColumnDefinition{Name: `old`, Pos: Position{Offset: 145, Line: 4, Column: 23}},
+			Pos:    Position{Offset: 123, Line: 4, Column: 1},
+		},
+		{
+			Action: `MODIFY`,
+			Column: ColumnDefinition{Name: `x`, Type: `Decimal(10, 2)`, Pos: Position{Offset: 164, Line: 5, Column: 15}},
+			Pos:    Position{Offset: 150, Line: 5, Column: 1},
+		},
+		{
+			Action:  `RENAME`,
+			Column:  ColumnDefinition{Name: `a`, Pos: Position{Offset: 196, Line: 6, Column: 15}},
+			NewName: `b`,
+			Pos:     Position{Offset: 182, Line: 6, Column: 1},
+		},
+	}, alter.Operations)
+
+	_, err = ParseAlterTable(`ALTER USER u`)
+	assert.EqualError(t, err, `not an ALTER TABLE statement`)
+}
@@ -0,0 +1,123 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFunctions maps the functions converting a string to a date or time to
+// the layouts their argument is parsed with
+var dateFunctions = map[string][]string{
+	"todate":       {time.DateOnly},
+	"todate32":     {time.DateOnly},
+	"todatetime":   {time.DateTime, time.DateOnly},
+	"todatetime64": {"2006-01-02 15:04:05.999999999", time.DateOnly},
+}
+
+// Decode converts the value to a Go value: nil for NULL, int64, uint64 or
+// float64 for numbers, the unescaped string for strings, []any for arrays and
+// tuples, map[any]any for maps and time.Time, in UTC, for toDate('...') and
+// toDateTime('...') calls. Strings are never taken for dates, as only the
+// type of the column tells them apart. DEFAULT and other expressions can't be
+// decoded
+func (v Value) Decode() (any, error) {
+	switch v.Kind {
+	case ValueNull:
+		return nil, nil
+	case ValueLiteral:
+		return decodeLiteral(v.Tokens)
+	case ValueArray, ValueTuple:
+		elements := make([]any, 0, len(v.Elements))
+		for _, element := range v.Elements {
+			decoded, err := element.Decode()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, decoded)
+		}
+		return elements, nil
+	case ValueMap:
+		return decodeMap(v.Elements)
+	case ValueExpression:
+		if decoded, ok, err := decodeDate(v.Tokens); ok {
+			return decoded, err
+		}
+	}
+	return nil, fmt.Errorf("cannot decode %s value: %s", strings.ToLower(v.Kind.String()), v.Text)
+}
+
+// decodeLiteral decodes a string or a possibly signed number
+func decodeLiteral(tokens []Token) (any, error) {
+	if tokens[0].Kind == TokenString {
+		return tokens[0].DecodedValue()
+	}
+	sign := ""
+	if len(tokens) == 2 {
+		sign = tokens[0].Value
+	}
+	number := tokens[len(tokens)-1].Value
+	base := 10
+	if len(number) > 1 && number[0] == '0' && strings.ContainsRune("xXbB", rune(number[1])) {
+		// Leading zeros are otherwise decimal, as in ClickHouse
+		base = 0
+	}
+	if i, err := strconv.ParseInt(sign+number, base, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(strings.TrimPrefix(sign, "+")+number, base, 64); err == nil {
+		return u, nil
+	}
+	f, err := strconv.ParseFloat(sign+number, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode number: %s%s", sign, number)
+	}
+	return f, nil
+}
+
+// decodeMap decodes the alternating keys and values of a map
+func decodeMap(elements []Value) (map[any]any, error) {
+	if len(elements)%2 != 0 {
+		return nil, fmt.Errorf("map with a key without value: %s", elements[len(elements)-1].Text)
+	}
+	decoded := make(map[any]any, len(elements)/2)
+	for i := 0; i < len(elements); i += 2 {
+		key, err := elements[i].Decode()
+		if err != nil {
+			return nil, err
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("map key cannot be decoded to a comparable value: %s", elements[i].Text)
+		}
+		value, err := elements[i+1].Decode()
+		if err != nil {
+			return nil, err
+		}
+		decoded[key] = value
+	}
+	return decoded, nil
+}
+
+// decodeDate decodes toDate('...') and similar calls taking a string as
+// their only argument, reporting whether the tokens are such a call
+func decodeDate(tokens []Token) (time.Time, bool, error) {
+	if len(tokens) != 4 || tokens[1].Value != "(" || tokens[2].Kind != TokenString || tokens[3].Value != ")" {
+		return time.Time{}, false, nil
+	}
+	layouts, ok := dateFunctions[strings.ToLower(tokens[0].Value)]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	value, err := tokens[2].DecodedValue()
+	if err != nil {
+		return time.Time{}, true, err
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true, nil
+		}
+	}
+	return time.Time{}, true, fmt.Errorf("cannot decode %s: %s", tokens[0].Value, tokens[2].Value)
+}
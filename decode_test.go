@@ -0,0 +1,52 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  any
+		err   string
+	}{
+		{value: `42`, want: int64(42)},
+		{value: `-7`, want: int64(-7)},
+		{value: `0755`, want: int64(755)},
+		{value: `0x1F`, want: int64(31)},
+		{value: `18446744073709551615`, want: uint64(18446744073709551615)},
+		{value: `-2.5e3`, want: float64(-2500)},
+		{value: `'it''s\n'`, want: "it's\n"},
+		{value: `NULL`, want: nil},
+		{value: `[1, 'a', [NULL]]`, want: []any{int64(1), "a", []any{nil}}},
+		{value: `(1, 'a')`, want: []any{int64(1), "a"}},
+		{value: `map('k', 1, 'l', 2)`, want: map[any]any{"k": int64(1), "l": int64(2)}},
+		{value: `toDate('2024-01-31')`, want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: `toDateTime('2024-01-31 10:20:30')`, want: time.Date(2024, 1, 31, 10, 20, 30, 0, time.UTC)},
+		{value: `toDateTime64('2024-01-31 10:20:30.5')`, want: time.Date(2024, 1, 31, 10, 20, 30, 500000000, time.UTC)},
+		{value: `'2024-01-31'`, want: `2024-01-31`},
+		{value: `toDate('31/01/2024')`, err: `cannot decode toDate: '31/01/2024'`},
+		{value: `map([1], 2)`, err: `map key cannot be decoded to a comparable value: [1]`},
+		{value: `now()`, err: `cannot decode expression value: now()`},
+		{value: `DEFAULT`, err: `cannot decode default value: DEFAULT`},
+		{value: `[1, now()]`, err: `cannot decode expression value: now()`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			insert, err := ParseInsert(`INSERT INTO t VALUES (` + tt.value + `)`)
+			assert.NoError(t, err)
+			rows, err := insert.Rows()
+			assert.NoError(t, err)
+			decoded, err := rows[0][0].Decode()
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, decoded)
+		})
+	}
+}
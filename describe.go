@@ -0,0 +1,120 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// describeFields holds the fields of DESCRIBE TABLE in the order ClickHouse
+// outputs them, used when the output has no header row
+var describeFields = []string{"name", "type", "default_type", "default_expression", "comment"}
+
+// ParseDescribe parses the output of DESCRIBE TABLE, in the TabSeparated,
+// TabSeparatedWithNames, Pretty or PrettyCompact format, into the column
+// definitions ParseCreateTable returns for the same table. Positions are
+// left zero and names unquoted, as DESCRIBE outputs them
+func ParseDescribe(output string) ([]ColumnDefinition, error) {
+	columns := make([]ColumnDefinition, 0)
+	fields := describeFields
+	for n, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		cells, ok := describeCells(line)
+		if !ok {
+			continue
+		}
+		if len(cells) > 1 && cells[0] == "name" && cells[1] == "type" {
+			fields = cells
+			continue
+		}
+		if len(cells) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least a name and a type, got %q", n+1, line)
+		}
+		column := ColumnDefinition{}
+		for i, cell := range cells[:min(len(cells), len(fields))] {
+			switch fields[i] {
+			case "name":
+				column.Name = cell
+			case "type":
+				column.Type = cell
+				column.Nullable = strings.HasPrefix(strings.ToLower(cell), "nullable(")
+			case "default_type":
+				column.DefaultKind = strings.ToUpper(cell)
+			case "default_expression":
+				column.Default = cell
+			case "comment":
+				column.Comment = cell
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// describeCells splits a line of DESCRIBE output into its unescaped cells,
+// reporting false for blank lines and the borders of Pretty formats
+func describeCells(line string) ([]string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil, false
+	}
+	switch first, _ := firstRune(trimmed); first {
+	case '│', '┃':
+		cells := strings.FieldsFunc(trimmed, func(r rune) bool { return r == '│' || r == '┃' })
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		return cells, true
+	case '┌', '┏':
+		// PrettyCompact has the names in the top border, e.g. ┌─name─┬─type─┐
+		cells := strings.FieldsFunc(trimmed, func(r rune) bool {
+			return strings.ContainsRune("┌┏┬┳┐┓─━ ", r)
+		})
+		return cells, len(cells) > 1 && cells[0] == "name"
+	case '├', '┡', '└', '┗', '╞':
+		return nil, false
+	}
+	cells := strings.Split(line, "\t")
+	for i := range cells {
+		cells[i] = unescapeTabSeparated(cells[i])
+	}
+	return cells, true
+}
+
+// firstRune returns the first rune of s
+func firstRune(s string) (rune, bool) {
+	for _, r := range s {
+		return r, true
+	}
+	return 0, false
+}
+
+// unescapeTabSeparated decodes the escapes of a TabSeparated field
+func unescapeTabSeparated(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] != '\\' || i+1 == len(field) {
+			b.WriteByte(field[i])
+			continue
+		}
+		i++
+		switch field[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		default:
+			b.WriteByte(field[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDescribe(t *testing.T) {
+	expected := []ColumnDefinition{
+		{Name: `id`, Type: `UInt64`},
+		{Name: `name`, Type: `Nullable(String)`, Nullable: true, Comment: "user\tname"},
+		{Name: `day`, Type: `Date`, DefaultKind: `MATERIALIZED`, Default: `toDate(ts)`},
+	}
+	for name, output := range map[string]string{
+		`TabSeparated`: "id\tUInt64\t\t\t\t\t\n" +
+			"name\tNullable(String)\t\t\tuser\\tname\t\t\n" +
+			"day\tDate\tMATERIALIZED\ttoDate(ts)\t\t\t\n",
+		`TabSeparatedWithNames`: "name\ttype\tdefault_type\tdefault_expression\tcomment\tcodec_expression\tttl_expression\n" +
+			"id\tUInt64\t\t\t\t\t\n" +
+			"name\tNullable(String)\t\t\tuser\\tname\t\t\n" +
+			"day\tDate\tMATERIALIZED\ttoDate(ts)\t\t\t\n",
+		`PrettyCompact`: "" +
+			"┌─name─┬─type─────────────┬─default_type─┬─default_expression─┬─comment───┐\n" +
+			"│ id   │ UInt64           │              │                    │           │\n" +
+			"│ name │ Nullable(String) │              │                    │ user\tname │\n" +
+			"│ day  │ Date             │ MATERIALIZED │ toDate(ts)         │           │\n" +
+			"└──────┴──────────────────┴──────────────┴────────────────────┴───────────┘\n",
+		`Pretty`: "" +
+			"┏━━━━━━┳━━━━━━━━━━━━━━━━━━┳━━━━━━━━━━━━━━┳━━━━━━━━━━━━━━━━━━━━┳━━━━━━━━━━━┓\n" +
+			"┃ name ┃ type             ┃ default_type ┃ default_expression ┃ comment   ┃\n" +
+			"┡━━━━━━╇━━━━━━━━━━━━━━━━━━╇━━━━━━━━━━━━━━╇━━━━━━━━━━━━━━━━━━━━╇━━━━━━━━━━━┩\n" +
+			"│ id   │ UInt64           │              │                    │           │\n" +
+			"├──────┼──────────────────┼──────────────┼────────────────────┼───────────┤\n" +
+			"│ name │ Nullable(String) │              │                    │ user\tname │\n" +
+			"├──────┼──────────────────┼──────────────┼────────────────────┼───────────┤\n" +
+			"│ day  │ Date             │ MATERIALIZED │ toDate(ts)         │           │\n" +
+			"└──────┴──────────────────┴──────────────┴────────────────────┴───────────┘\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			columns, err := ParseDescribe(output)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, columns)
+		})
+	}
+
+	t.Run(`same as CREATE TABLE`, func(t *testing.T) {
+		create, err := ParseCreateTable("CREATE TABLE t (id UInt64, name Nullable(String) COMMENT 'user\\tname', day Date MATERIALIZED toDate(ts))")
+		assert.NoError(t, err)
+		for i := range create.Columns {
+			create.Columns[i].Pos = Position{}
+		}
+		assert.Equal(t, expected, create.Columns)
+	})
+
+	t.Run(`missing type`, func(t *testing.T) {
+		_, err := ParseDescribe("id\tUInt64\nname\n")
+		assert.EqualError(t, err, `line 2: expected at least a name and a type, got "name"`)
+	})
+}
@@ -0,0 +1,241 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+	"sync"
+)
+
+// EscapeStyle selects how a quote character is written inside a quoted token
+type EscapeStyle int
+
+const (
+	BackslashEscapes    EscapeStyle = 1 << iota // \' and \\ as in ClickHouse and MySQL
+	DoubledQuoteEscapes                         // '' as in standard SQL
+)
+
+// Dialect controls the lexical rules that differ between SQL dialects: the
+// quote characters of identifiers and strings, how quotes are escaped and
+// the comment syntax. Everything else is tokenized the ClickHouse way
+type Dialect interface {
+	// Name identifies the dialect in the registry
+	Name() string
+	// QuoteKind reports whether quote opens a quoted token and whether that
+	// token is a TokenQuotedIdentifier or a TokenString
+	QuoteKind(quote rune) (TokenKind, bool)
+	// Escapes returns the escape styles accepted in quoted tokens
+	Escapes() EscapeStyle
+	// LineComment returns the length of the line comment marker s starts
+	// with, or 0 if it doesn't start a line comment
+	LineComment(s string) int
+	// NestedComments reports whether /* */ comments nest
+	NestedComments() bool
+}
+
+// ClickHouse is the default dialect: backtick and double quoted identifiers,
+// single quoted strings with backslash or doubled quote escapes, -- line
+// comments and nested block comments
+var ClickHouse Dialect = clickHouseDialect{}
+
+type clickHouseDialect struct{}
+
+func (clickHouseDialect) Name() string {
+	return "clickhouse"
+}
+
+func (clickHouseDialect) QuoteKind(quote rune) (TokenKind, bool) {
+	switch quote {
+	case '`', '"':
+		return TokenQuotedIdentifier, true
+	case '\'':
+		return TokenString, true
+	}
+	return 0, false
+}
+
+func (clickHouseDialect) Escapes() EscapeStyle {
+	return BackslashEscapes | DoubledQuoteEscapes
+}
+
+func (clickHouseDialect) LineComment(s string) int {
+	if strings.HasPrefix(s, "--") {
+		return 2
+	}
+	return 0
+}
+
+func (clickHouseDialect) NestedComments() bool {
+	return true
+}
+
+// QuoteEscaper is implemented by dialects whose escape styles depend on the
+// quote character, QuoteEscapes then overriding Escapes
+type QuoteEscaper interface {
+	QuoteEscapes(quote rune) EscapeStyle
+}
+
+// Feature is a set of syntax only some dialects have
+type Feature int
+
+const (
+	ServerParameters     Feature = 1 << iota // {name:Type} query parameters, as in ClickHouse
+	NumberedPlaceholders                     // $1 placeholders, as in PostgreSQL
+	EscapeStrings                            // E'...' strings with backslash escapes, as in PostgreSQL
+	OnDuplicateKeyUpdate                     // ON DUPLICATE KEY UPDATE after the rows of an INSERT, as in MySQL
+	OnConflictReturning                      // ON CONFLICT and RETURNING after the rows of an INSERT, as in PostgreSQL
+)
+
+// FeatureDialect is implemented by dialects whose features differ from
+// ClickHouse's, which are those of dialects that don't implement it
+type FeatureDialect interface {
+	Features() Feature
+}
+
+// dialectFeatures returns the features of d
+func dialectFeatures(d Dialect) Feature {
+	if f, ok := d.(FeatureDialect); ok {
+		return f.Features()
+	}
+	return ServerParameters
+}
+
+// quoteEscapes returns the escape styles d accepts in tokens quoted with quote
+func quoteEscapes(d Dialect, quote rune) EscapeStyle {
+	if q, ok := d.(QuoteEscaper); ok {
+		return q.QuoteEscapes(quote)
+	}
+	return d.Escapes()
+}
+
+// MySQL is the MySQL dialect: backtick quoted identifiers, escaped by
+// doubling the backtick, single and double quoted strings with backslash or
+// doubled quote escapes, # and "-- " line comments and block comments that
+// don't nest. It has no {name:Type} query parameters and accepts ON DUPLICATE
+// KEY UPDATE after the rows of an INSERT
+var MySQL Dialect = mySQLDialect{}
+
+type mySQLDialect struct{}
+
+func (mySQLDialect) Name() string {
+	return "mysql"
+}
+
+func (mySQLDialect) QuoteKind(quote rune) (TokenKind, bool) {
+	switch quote {
+	case '`':
+		return TokenQuotedIdentifier, true
+	case '\'', '"':
+		return TokenString, true
+	}
+	return 0, false
+}
+
+func (mySQLDialect) Escapes() EscapeStyle {
+	return BackslashEscapes | DoubledQuoteEscapes
+}
+
+func (d mySQLDialect) QuoteEscapes(quote rune) EscapeStyle {
+	if quote == '`' {
+		return DoubledQuoteEscapes
+	}
+	return d.Escapes()
+}
+
+func (mySQLDialect) LineComment(s string) int {
+	switch {
+	case strings.HasPrefix(s, "#"):
+		return 1
+	// -- only starts a comment when followed by whitespace or a control
+	// character, so that 1--1 is a subtraction
+	case strings.HasPrefix(s, "--") && (len(s) == 2 || s[2] <= ' '):
+		return 2
+	}
+	return 0
+}
+
+func (mySQLDialect) NestedComments() bool {
+	return false
+}
+
+func (mySQLDialect) Features() Feature {
+	return OnDuplicateKeyUpdate
+}
+
+// Postgres is the PostgreSQL dialect: double quoted identifiers and single
+// quoted strings, both escaped by doubling the quote, E'...' strings with
+// backslash escapes, $1 placeholders, -- line comments and nested block
+// comments. It accepts ON CONFLICT and RETURNING after the rows of an INSERT.
+// $tag$ dollar-quoted strings are accepted as in every dialect
+var Postgres Dialect = postgresDialect{}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) QuoteKind(quote rune) (TokenKind, bool) {
+	switch quote {
+	case '"':
+		return TokenQuotedIdentifier, true
+	case '\'':
+		return TokenString, true
+	}
+	return 0, false
+}
+
+func (postgresDialect) Escapes() EscapeStyle {
+	return DoubledQuoteEscapes
+}
+
+func (postgresDialect) LineComment(s string) int {
+	if strings.HasPrefix(s, "--") {
+		return 2
+	}
+	return 0
+}
+
+func (postgresDialect) NestedComments() bool {
+	return true
+}
+
+func (postgresDialect) Features() Feature {
+	return NumberedPlaceholders | EscapeStrings | OnConflictReturning
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		ClickHouse.Name(): ClickHouse,
+		MySQL.Name():      MySQL,
+		Postgres.Name():   Postgres,
+	}
+)
+
+// RegisterDialect makes d available to LookupDialect under its name,
+// replacing any dialect registered under the same name
+func RegisterDialect(d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[strings.ToLower(d.Name())] = d
+}
+
+// LookupDialect returns the dialect registered under name, ignoring case
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[strings.ToLower(name)]
+	return d, ok
+}
+
+// quoteName names a quote character in errors about unclosed quotes
+func quoteName(quote rune) string {
+	switch quote {
+	case '`':
+		return "backtick"
+	case '\'':
+		return "single"
+	case '"':
+		return "double"
+	}
+	return string(quote)
+}
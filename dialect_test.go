@@ -0,0 +1,218 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mysqlLike quotes strings in double quotes too and starts comments with #
+type mysqlLike struct{}
+
+func (mysqlLike) Name() string {
+	return "MySQLLike"
+}
+
+func (mysqlLike) QuoteKind(quote rune) (TokenKind, bool) {
+	switch quote {
+	case '`':
+		return TokenQuotedIdentifier, true
+	case '\'', '"':
+		return TokenString, true
+	}
+	return 0, false
+}
+
+func (mysqlLike) Escapes() EscapeStyle {
+	return BackslashEscapes
+}
+
+func (mysqlLike) LineComment(s string) int {
+	switch {
+	case strings.HasPrefix(s, "#"):
+		return 1
+	case strings.HasPrefix(s, "-- "):
+		return 3
+	}
+	return 0
+}
+
+func (mysqlLike) NestedComments() bool {
+	return false
+}
+
+func TestClickHouseDialect(t *testing.T) {
+	t.Run(`double quoted identifiers`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO "my table" ("a b", "say ""hi""")`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenQuotedIdentifier, Value: `"my table"`}, e.tokens[2])
+		assert.Equal(t, []string{`"a b"`, `"say ""hi"""`}, e.columns())
+		assert.Equal(t, `say "hi"`, unquoteIdentifier(e.columns()[1]))
+	})
+
+	t.Run(`doubled single quotes`, func(t *testing.T) {
+		e := &columnExtractor{query: `SELECT 'it''s', 'a\'b'`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenString, Value: `'it''s'`}, e.tokens[1])
+		decoded, err := e.tokens[1].DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, `it's`, decoded)
+	})
+
+	t.Run(`unclosed double quote`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO t ("a)`}
+		assert.EqualError(t, e.parse(), `1:16: unclosed double quote`)
+	})
+}
+
+func TestCustomDialect(t *testing.T) {
+	RegisterDialect(mysqlLike{})
+	dialect, ok := LookupDialect(`mysqllike`)
+	assert.True(t, ok)
+	_, ok = LookupDialect(`ClickHouse`)
+	assert.True(t, ok)
+
+	t.Run(`quotes and comments`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:   "# generated\nINSERT INTO t (`a`, b) VALUES (\"x\", 'it''s') -- done",
+			dialect: dialect,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"`a`", `b`}, e.columns())
+		assert.Equal(t, Token{Kind: TokenString, Value: `"x"`}, e.tokens[10])
+		// Without doubled quote escapes 'it''s' is two adjacent strings
+		assert.Equal(t, Token{Kind: TokenString, Value: `'it'`}, e.tokens[12])
+		assert.Equal(t, Token{Kind: TokenString, Value: `'s'`}, e.tokens[13])
+		assert.Len(t, e.tokens, 15)
+	})
+
+	t.Run(`comments don't nest`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:   `SELECT /* a /* b */ 1`,
+			dialect: dialect,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []Token{{Kind: TokenKeyword, Value: `SELECT`}, {Kind: TokenNumber, Value: `1`}}, e.tokens)
+	})
+
+	t.Run(`scanner`, func(t *testing.T) {
+		s := NewScanner(`"x" # comment`)
+		s.Dialect = dialect
+		token, _ := s.Next()
+		assert.Equal(t, Token{Kind: TokenString, Value: `"x"`}, token)
+		_, ok := s.Next()
+		assert.False(t, ok)
+	})
+}
+
+func TestMySQLDialect(t *testing.T) {
+	dialect, ok := LookupDialect(`MySQL`)
+	assert.True(t, ok)
+	assert.Equal(t, MySQL, dialect)
+
+	t.Run(`quotes and comments`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:   "# generated\nINSERT INTO `my``table` (`a``b`, `c\\`) VALUES (\"x\"\"y\", 'it\\'s', 1--1) -- done\n/* a /* b */",
+			dialect: MySQL,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenQuotedIdentifier, Value: "`my``table`"}, e.tokens[2])
+		assert.Equal(t, []string{"`a``b`", "`c\\`"}, e.columns())
+		assert.Equal(t, Token{Kind: TokenString, Value: `"x""y"`}, e.tokens[10])
+		assert.Equal(t, Token{Kind: TokenString, Value: `'it\'s'`}, e.tokens[12])
+		assert.Equal(t, []Token{
+			{Kind: TokenNumber, Value: `1`}, {Kind: TokenOperator, Value: `-`}, {Kind: TokenOperator, Value: `-`}, {Kind: TokenNumber, Value: `1`},
+		}, e.tokens[14:18])
+		assert.Len(t, e.tokens, 19)
+	})
+
+	t.Run(`no query parameters`, func(t *testing.T) {
+		e := &columnExtractor{query: `SELECT {d '2024-01-01'}, ?`, dialect: MySQL}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []Token{
+			{Kind: TokenKeyword, Value: `SELECT`},
+			{Kind: TokenPunctuation, Value: `{`},
+			{Kind: TokenIdentifier, Value: `d`},
+			{Kind: TokenString, Value: `'2024-01-01'`},
+			{Kind: TokenPunctuation, Value: `}`},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenPlaceholder, Value: `?`, Ordinal: 1},
+		}, e.tokens)
+	})
+
+	t.Run(`parser`, func(t *testing.T) {
+		parser := Parser{Dialect: MySQL}
+		query := "INSERT INTO db.`t` (`id`, `na``me`) VALUES (1, \"a\") ON DUPLICATE KEY UPDATE `na``me` = VALUES(`na``me`) # upsert"
+		columns, err := parser.ExtractColumns(query)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"`id`", "`na``me`"}, columns)
+		table, err := parser.ExtractTable(query)
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Database: `db`, Table: `t`}, table)
+
+		_, err = Parser{}.ExtractColumns(query)
+		assert.EqualError(t, err, "1:105: unexpected rune: #")
+
+		insert, err := parser.ParseInsert("INSERT INTO t (a) VALUES (1) # c\n ON DUPLICATE KEY UPDATE a = 1")
+		assert.NoError(t, err)
+		rows, err := insert.Rows()
+		assert.NoError(t, err)
+		assert.Len(t, rows, 1)
+		_, err = ParseInsert("INSERT INTO t (a) VALUES (1) # c\n ON DUPLICATE KEY UPDATE a = 1")
+		assert.EqualError(t, err, "1:30: unexpected rune: #")
+	})
+}
+
+func TestPostgresDialect(t *testing.T) {
+	dialect, ok := LookupDialect(`postgres`)
+	assert.True(t, ok)
+	assert.Equal(t, Postgres, dialect)
+
+	t.Run(`quotes and placeholders`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:   `INSERT INTO "my""table" ("a""b", "e") VALUES ($1, $3, E'it\'s', e'', 'C:\', $tag$x'y$tag$, end) /* a /* b */ c */`,
+			dialect: Postgres,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenQuotedIdentifier, Value: `"my""table"`}, e.tokens[2])
+		assert.Equal(t, []string{`"a""b"`, `"e"`}, e.columns())
+		assert.Equal(t, []Token{
+			{Kind: TokenPunctuation, Value: `(`},
+			{Kind: TokenPlaceholder, Value: `$1`, Ordinal: 1},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenPlaceholder, Value: `$3`, Ordinal: 3},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenString, Value: `E'it\'s'`},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenString, Value: `e''`},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenString, Value: `'C:\'`},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenString, Value: `$tag$x'y$tag$`},
+			{Kind: TokenPunctuation, Value: `,`},
+			{Kind: TokenIdentifier, Value: `end`},
+			{Kind: TokenPunctuation, Value: `)`},
+		}, e.tokens[9:24])
+		assert.Equal(t, 3, e.placeholders)
+
+		decoded, err := e.tokens[14].DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, `it's`, decoded)
+		decoded, err = e.tokens[16].DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, ``, decoded)
+	})
+
+	t.Run(`parser`, func(t *testing.T) {
+		parser := Parser{Dialect: Postgres}
+		query := `INSERT INTO public.events ("id", "name") VALUES ($1, $2) RETURNING "id"`
+		columns, err := parser.ExtractColumns(query)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`"id"`, `"name"`}, columns)
+		table, err := parser.ExtractTable(query)
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Database: `public`, Table: `events`}, table)
+	})
+}
@@ -0,0 +1,68 @@
+package clickhouse_go_insert_statement_parsing
+
+import "fmt"
+
+// EditOp is the kind of change a TokenEdit makes
+type EditOp int
+
+const (
+	EditDelete EditOp = iota // the token only appears in the first statement
+	EditInsert               // the token only appears in the second statement
+)
+
+// TokenEdit is a single step of the edit script turning one token stream into
+// another. Index is the position of Token in the stream it belongs to
+type TokenEdit struct {
+	Op    EditOp
+	Index int
+	Token Token
+}
+
+func (edit TokenEdit) String() string {
+	op := "-"
+	if edit.Op == EditInsert {
+		op = "+"
+	}
+	return fmt.Sprintf("%s%d %s %s", op, edit.Index, edit.Token.Kind, edit.Token.Value)
+}
+
+// DiffTokens returns a minimal edit script turning the tokens of a into the
+// tokens of b. Whitespace and comments are ignored, as are tokenisation
+// errors. Deletions are listed before insertions at the same position
+func DiffTokens(a, b string) []TokenEdit {
+	tokensA, _ := (&columnExtractor{query: a}).allTokens()
+	tokensB, _ := (&columnExtractor{query: b}).allTokens()
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// tokensA[i:] and tokensB[j:]
+	lcs := make([][]int, len(tokensA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tokensB)+1)
+	}
+	for i := len(tokensA) - 1; i >= 0; i-- {
+		for j := len(tokensB) - 1; j >= 0; j-- {
+			if tokensA[i] == tokensB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]TokenEdit, 0)
+	i, j := 0, 0
+	for i < len(tokensA) || j < len(tokensB) {
+		switch {
+		case i < len(tokensA) && j < len(tokensB) && tokensA[i] == tokensB[j]:
+			i++
+			j++
+		case j == len(tokensB) || (i < len(tokensA) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, TokenEdit{Op: EditDelete, Index: i, Token: tokensA[i]})
+			i++
+		default:
+			edits = append(edits, TokenEdit{Op: EditInsert, Index: j, Token: tokensB[j]})
+			j++
+		}
+	}
+	return edits
+}
@@ -0,0 +1,45 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffTokens(t *testing.T) {
+	t.Run(`identical`, func(t *testing.T) {
+		assert.Empty(t, DiffTokens(`INSERT INTO t (a, b)`, `INSERT INTO t (a, b)`))
+	})
+
+	t.Run(`trivia is ignored`, func(t *testing.T) {
+		assert.Empty(t, DiffTokens("INSERT INTO t (a, b)", "-- generated\nINSERT  INTO t(a,\n\tb /* two */)"))
+	})
+
+	t.Run(`added column`, func(t *testing.T) {
+		edits := DiffTokens(`INSERT INTO t (a, b)`, `INSERT INTO t (a, b, c)`)
+		assert.Equal(t, []TokenEdit{
+			{Op: EditInsert, Index: 7, Token: Token{Kind: TokenPunctuation, Value: `,`}},
+			{Op: EditInsert, Index: 8, Token: Token{Kind: TokenIdentifier, Value: `c`}},
+		}, edits)
+	})
+
+	t.Run(`renamed column`, func(t *testing.T) {
+		edits := DiffTokens(`INSERT INTO t (a, b)`, "INSERT INTO t (a, `b`)")
+		assert.Equal(t, []TokenEdit{
+			{Op: EditDelete, Index: 6, Token: Token{Kind: TokenIdentifier, Value: `b`}},
+			{Op: EditInsert, Index: 6, Token: Token{Kind: TokenQuotedIdentifier, Value: "`b`"}},
+		}, edits)
+	})
+
+	t.Run(`removed statement`, func(t *testing.T) {
+		edits := DiffTokens(`SELECT 1; SELECT 2`, `SELECT 1`)
+		assert.Len(t, edits, 3)
+		assert.Equal(t, `-2 Punctuation ;`, edits[0].String())
+		assert.Equal(t, `-4 Number 2`, edits[2].String())
+	})
+
+	t.Run(`string`, func(t *testing.T) {
+		edit := TokenEdit{Op: EditInsert, Index: 3, Token: Token{Kind: TokenString, Value: `'x'`}}
+		assert.Equal(t, `+3 String 'x'`, edit.String())
+	})
+}
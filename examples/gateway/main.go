@@ -0,0 +1,212 @@
+// Command gateway is an HTTP service taking scripts of INSERT statements in
+// the body of POST requests. It checks each INSERT, merges the VALUES rows of
+// those sharing a header and forwards the result to ClickHouse's HTTP
+// interface, answering with the number of statements forwarded and rows
+// inserted
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	parsing "clickhouse_go_insert_statement_parsing"
+)
+
+// maxBody caps the size of the scripts the gateway accepts, larger loads
+// belonging in a FORMAT stream sent to ClickHouse directly
+const maxBody = 1 << 20
+
+func main() {
+	addr := flag.String("addr", ":8124", "address to serve the gateway on")
+	upstream := flag.String("upstream", "http://localhost:8123/", "ClickHouse HTTP interface to forward INSERTs to")
+	maxRows := flag.Int("max-rows", 0, "rows per INSERT forwarded, 0 for no limit")
+	flag.Parse()
+
+	handler := &gateway{
+		route:               func(parsing.TableRef) string { return *upstream },
+		maxRowsPerStatement: *maxRows,
+	}
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
+
+// gateway is the HTTP handler of the service
+type gateway struct {
+	// route returns the URL of the ClickHouse HTTP interface INSERTs into
+	// table go to, e.g. http://localhost:8123/
+	route func(table parsing.TableRef) string
+	// schema, if set, supplies the columns the INSERTs are validated against
+	schema parsing.SchemaResolver
+	// maxRowsPerStatement caps the rows of each INSERT forwarded, 0 for no cap
+	maxRowsPerStatement int
+	// client forwards the INSERTs, http.DefaultClient if nil
+	client *http.Client
+}
+
+// result is the JSON answer of the gateway
+type result struct {
+	Statements int `json:"statements"`
+	Rows       int `json:"rows"`
+}
+
+// insert is an INSERT to forward
+type insert struct {
+	table parsing.TableRef
+	query string
+	rows  int
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	script, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	inserts, err := g.plan(string(script))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var answer result
+	for _, insert := range inserts {
+		if err := g.forward(r, insert); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		answer.Statements++
+		answer.Rows += insert.rows
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answer)
+}
+
+// plan turns a script into the INSERTs to forward: the INSERT ... VALUES
+// sharing a header are merged, then split per maxRowsPerStatement, and
+// INSERT ... FORMAT name with inline data is passed on as it is
+func (g *gateway) plan(script string) ([]insert, error) {
+	statements, err := parsing.SplitStatements(script)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("no statements")
+	}
+
+	type group struct {
+		table  parsing.TableRef
+		header string
+		rows   []string
+	}
+	// INSERTs with different SETTINGS aren't merged
+	type groupKey struct {
+		header   parsing.HeaderKey
+		settings string
+	}
+	var groups []*group
+	byKey := make(map[groupKey]*group)
+	inserts := make([]insert, 0)
+	for n, statement := range statements {
+		parsed, err := g.check(statement.Text)
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", n+1, err)
+		}
+		if _, ok := parsed.Format(); ok {
+			inserts = append(inserts, insert{table: parsed.Table, query: statement.Text})
+			continue
+		}
+		rows, err := parsed.Rows()
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", n+1, err)
+		}
+		key := groupKey{parsing.NewHeaderKey(parsed.Table, parsed.Columns, ""), fmt.Sprint(parsed.Settings)}
+		if byKey[key] == nil {
+			offset, _ := parsed.DataOffset()
+			byKey[key] = &group{table: parsed.Table, header: strings.TrimRightFunc(statement.Text[:offset], unicode.IsSpace)}
+			groups = append(groups, byKey[key])
+		}
+		for _, row := range rows {
+			values := make([]string, 0, len(row))
+			for _, value := range row {
+				values = append(values, value.Text)
+			}
+			byKey[key].rows = append(byKey[key].rows, "("+strings.Join(values, ", ")+")")
+		}
+	}
+
+	for _, group := range groups {
+		size := len(group.rows)
+		if g.maxRowsPerStatement > 0 {
+			size = g.maxRowsPerStatement
+		}
+		for rows := group.rows; len(rows) > 0; {
+			chunk := rows[:min(size, len(rows))]
+			rows = rows[len(chunk):]
+			inserts = append(inserts, insert{
+				table: group.table,
+				query: group.header + " " + strings.Join(chunk, ", "),
+				rows:  len(chunk),
+			})
+		}
+	}
+	return inserts, nil
+}
+
+// check parses a statement of the script, accepting only INSERT ... VALUES
+// with well-formed rows and INSERT ... FORMAT name followed by its data, and
+// validates its columns against the schema
+func (g *gateway) check(statement string) (*parsing.Insert, error) {
+	parsed, err := parsing.ParseInsert(statement)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.IsSelect() || parsed.Infile != nil || !parsed.HasInlineData() {
+		return nil, errors.New("only INSERTs with inline VALUES rows or FORMAT data are accepted")
+	}
+	if err := parsed.ValidateRows(); err != nil {
+		return nil, err
+	}
+	if g.schema != nil && len(parsed.Columns) > 0 {
+		schema, err := g.schema.Columns(parsed.Table.Database, parsed.Table.Table)
+		if err != nil {
+			return nil, err
+		}
+		if err := parsing.ValidateColumns(statement, schema); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// forward posts an INSERT to the ClickHouse HTTP interface of its table
+func (g *gateway) forward(r *http.Request, insert insert) error {
+	request, err := http.NewRequestWithContext(r.Context(), http.MethodPost, g.route(insert.table), strings.NewReader(insert.query))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	client := g.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	parsing "clickhouse_go_insert_statement_parsing"
+)
+
+// mapSchema resolves columns from a map keyed by qualified table name
+type mapSchema map[string][]string
+
+func (m mapSchema) Columns(database, table string) ([]string, error) {
+	columns, ok := m[parsing.TableRef{Database: database, Table: table}.String()]
+	if !ok {
+		return nil, errors.New("unknown table")
+	}
+	return columns, nil
+}
+
+// clickHouseStub records the queries posted to it, failing those mentioning
+// the fail table
+type clickHouseStub struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *clickHouseStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query, _ := io.ReadAll(r.Body)
+	if strings.Contains(string(query), "fail") {
+		http.Error(w, "Code: 60. DB::Exception: Table default.fail does not exist", http.StatusNotFound)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, string(query))
+}
+
+func TestGateway(t *testing.T) {
+	stub := &clickHouseStub{}
+	clickHouse := httptest.NewServer(stub)
+	defer clickHouse.Close()
+	server := httptest.NewServer(&gateway{
+		route:               func(parsing.TableRef) string { return clickHouse.URL },
+		schema:              mapSchema{`t`: {`a`, `b`}, `u`: {`x`}, `fail`: {`x`}},
+		maxRowsPerStatement: 2,
+	})
+	defer server.Close()
+
+	post := func(script string) (int, string) {
+		response, err := http.Post(server.URL, "text/plain", strings.NewReader(script))
+		assert.NoError(t, err)
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return response.StatusCode, strings.TrimSpace(string(body))
+	}
+
+	t.Run(`merges rows sharing a header`, func(t *testing.T) {
+		status, body := post("INSERT INTO t (a, b) VALUES (1, 'x');\n" +
+			"INSERT INTO u (x) VALUES (0);\n" +
+			"INSERT INTO t (`a`, b) VALUES (2, 'y'), (3, 'z');\n" +
+			"INSERT INTO u (x) FORMAT CSV\n1\n")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `{"statements":4,"rows":4}`, body)
+		assert.Equal(t, []string{
+			"INSERT INTO u (x) FORMAT CSV\n1\n",
+			`INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y')`,
+			`INSERT INTO t (a, b) VALUES (3, 'z')`,
+			`INSERT INTO u (x) VALUES (0)`,
+		}, stub.queries)
+	})
+
+	t.Run(`rejects invalid INSERTs`, func(t *testing.T) {
+		stub.queries = nil
+		for script, message := range map[string]string{
+			`INSERT INTO t (a, c) VALUES (1, 2)`: `statement 1: unknown column: c, did you mean a, b?`,
+			`INSERT INTO t (a, b) VALUES (1)`:    `statement 1: 1:29: row 1 has 1 values, expected 2`,
+			`INSERT INTO t (a) SELECT 1`:         `statement 1: only INSERTs with inline VALUES rows or FORMAT data are accepted`,
+			`INSERT INTO t (a) VALUES`:           `statement 1: only INSERTs with inline VALUES rows or FORMAT data are accepted`,
+			`SELECT 1`:                           `statement 1: not an INSERT statement`,
+			`INSERT INTO t (a, b) VALUES (1, 'x') ON DUPLICATE KEY UPDATE a = 1`: `statement 1: 1:38: row 2: expected ( but found ON`,
+			` `: `no statements`,
+		} {
+			status, body := post(script)
+			assert.Equal(t, http.StatusBadRequest, status, script)
+			assert.Equal(t, message, body, script)
+		}
+		assert.Empty(t, stub.queries)
+
+		status, _ := post(strings.Repeat("INSERT INTO u (x) VALUES (0);\n", maxBody/30+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		assert.Empty(t, stub.queries)
+	})
+
+	t.Run(`reports ClickHouse errors`, func(t *testing.T) {
+		status, body := post(`INSERT INTO fail (x) VALUES (1)`)
+		assert.Equal(t, http.StatusBadGateway, status)
+		assert.Equal(t, `404 Not Found: Code: 60. DB::Exception: Table default.fail does not exist`, body)
+
+		response, err := http.Get(server.URL)
+		assert.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+	})
+}
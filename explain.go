@@ -0,0 +1,149 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExplainNode is a node of the tree printed by EXPLAIN AST, e.g.
+// Function plus (alias s) (children 1)
+type ExplainNode struct {
+	Kind     string // e.g. SelectQuery, Identifier, Function or Literal
+	Value    string // e.g. the name of an identifier or function, empty if none
+	Alias    string
+	Line     int // line of the output, starting at 1
+	Children []*ExplainNode
+}
+
+// explainSuffix matches the alias and children count ending a line of
+// EXPLAIN AST output
+var explainSuffix = regexp.MustCompile(`(?:\s+\(alias (.+?)\))?(?:\s+\(children (\d+)\))?$`)
+
+// ParseExplainAST parses the output of EXPLAIN AST, where each node is on a
+// line indented one space deeper than its parent. The children counts
+// printed by the server are checked against the nodes that follow. The
+// output of EXPLAIN SYNTAX is a query, to be parsed as such
+func ParseExplainAST(output string) (*ExplainNode, error) {
+	var root *ExplainNode
+	parents := make([]*ExplainNode, 0)
+	counts := make(map[*ExplainNode]int)
+	for n, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, " "))
+		node, count := explainNode(strings.TrimSpace(line))
+		node.Line = n + 1
+		switch {
+		case depth == 0 && root != nil:
+			return nil, fmt.Errorf("line %d: more than one root node", n+1)
+		case depth > len(parents):
+			return nil, fmt.Errorf("line %d: indented %d levels, expected at most %d", n+1, depth, len(parents))
+		case depth == 0:
+			root = node
+		default:
+			parent := parents[depth-1]
+			parent.Children = append(parent.Children, node)
+		}
+		parents = append(parents[:depth], node)
+		counts[node] = count
+	}
+	if root == nil {
+		return nil, fmt.Errorf("empty EXPLAIN AST output")
+	}
+	var check func(node *ExplainNode) error
+	check = func(node *ExplainNode) error {
+		if len(node.Children) != counts[node] {
+			return fmt.Errorf("line %d: %s has %d children, expected %d", node.Line, node.Kind, len(node.Children), counts[node])
+		}
+		for _, child := range node.Children {
+			if err := check(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := check(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// explainNode parses a line of EXPLAIN AST output, without its indentation,
+// returning the node and the number of children it announces
+func explainNode(line string) (*ExplainNode, int) {
+	node := &ExplainNode{}
+	match := explainSuffix.FindStringSubmatchIndex(line)
+	rest := line[:match[0]]
+	if match[2] >= 0 {
+		node.Alias = line[match[2]:match[3]]
+	}
+	count := 0
+	if match[4] >= 0 {
+		count, _ = strconv.Atoi(line[match[4]:match[5]])
+	}
+	node.Kind, node.Value, _ = strings.Cut(rest, " ")
+	node.Value = strings.TrimSpace(node.Value)
+	return node, count
+}
+
+// Find returns the nodes of the tree of the given kind, in depth-first order
+func (n *ExplainNode) Find(kind string) []*ExplainNode {
+	found := make([]*ExplainNode, 0)
+	var find func(node *ExplainNode)
+	find = func(node *ExplainNode) {
+		if node.Kind == kind {
+			found = append(found, node)
+		}
+		for _, child := range node.Children {
+			find(child)
+		}
+	}
+	find(n)
+	return found
+}
+
+// Tables returns the tables and table functions the tree reads or writes,
+// in order of appearance, for comparison with ReferencedTables
+func (n *ExplainNode) Tables() []TableRef {
+	refs := make([]TableRef, 0)
+	var find func(node *ExplainNode)
+	find = func(node *ExplainNode) {
+		var ref TableRef
+		switch node.Kind {
+		case "TableIdentifier":
+			if database, table, ok := strings.Cut(node.Value, "."); ok {
+				ref = TableRef{Database: database, Table: table}
+			} else {
+				ref = TableRef{Table: node.Value}
+			}
+		case "TableExpression":
+			for _, child := range node.Children {
+				if child.Kind == "Function" {
+					ref = TableRef{Function: child.Value}
+				}
+			}
+		}
+		if ref != (TableRef{}) && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+		for _, child := range node.Children {
+			find(child)
+		}
+	}
+	find(n)
+	return refs
+}
+
+// Identifiers returns the names of the Identifier nodes of the tree, in
+// order of appearance, e.g. the columns a query refers to
+func (n *ExplainNode) Identifiers() []string {
+	identifiers := make([]string, 0)
+	for _, node := range n.Find("Identifier") {
+		identifiers = append(identifiers, node.Value)
+	}
+	return identifiers
+}
@@ -0,0 +1,66 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// explainSelect is the output of EXPLAIN AST
+// SELECT a, b + 1 AS s FROM db.t JOIN numbers(10) AS n ON a = n.number WHERE c = 'x y'
+const explainSelect = `SelectWithUnionQuery (children 1)
+ ExpressionList (children 1)
+  SelectQuery (children 3)
+   ExpressionList (children 2)
+    Identifier a
+    Function plus (alias s) (children 1)
+     ExpressionList (children 2)
+      Identifier b
+      Literal UInt64_1
+   TablesInSelectQuery (children 2)
+    TablesInSelectQueryElement (children 1)
+     TableExpression (children 1)
+      TableIdentifier db.t
+    TablesInSelectQueryElement (children 2)
+     TableExpression (children 1)
+      Function numbers (alias n) (children 1)
+       ExpressionList (children 1)
+        Literal UInt64_10
+     TableJoin (children 1)
+      Function equals (children 1)
+       ExpressionList (children 2)
+        Identifier a
+        Identifier n.number
+   Function equals (children 1)
+    ExpressionList (children 2)
+     Identifier c
+     Literal 'x y'
+`
+
+func TestParseExplainAST(t *testing.T) {
+	root, err := ParseExplainAST(explainSelect)
+	assert.NoError(t, err)
+	assert.Equal(t, `SelectWithUnionQuery`, root.Kind)
+
+	plus := root.Find(`Function`)[0]
+	assert.Equal(t, ExplainNode{Kind: `Function`, Value: `plus`, Alias: `s`, Line: 6, Children: plus.Children}, *plus)
+	assert.Equal(t, `'x y'`, root.Find(`Literal`)[2].Value)
+	assert.Equal(t, []string{`a`, `b`, `a`, `n.number`, `c`}, root.Identifiers())
+
+	t.Run(`matches ReferencedTables`, func(t *testing.T) {
+		query := `SELECT a, b + 1 AS s FROM db.t JOIN numbers(10) AS n ON a = n.number WHERE c = 'x y'`
+		assert.Equal(t, ReferencedTables(query), root.Tables())
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		for output, message := range map[string]string{
+			``:                                       `empty EXPLAIN AST output`,
+			"Identifier a\nIdentifier b":             `line 2: more than one root node`,
+			"ExpressionList (children 1)\n  Literal": `line 2: indented 2 levels, expected at most 1`,
+			"ExpressionList (children 2)\n Literal":  `line 1: ExpressionList has 1 children, expected 2`,
+		} {
+			_, err := ParseExplainAST(output)
+			assert.EqualError(t, err, message)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ColumnExtractor extracts the target of an INSERT statement. Applications
+// depending on the interface rather than on Parser can mock it in tests or
+// swap in another implementation, e.g. a regexp fallback or a remote service
+type ColumnExtractor interface {
+	// ExtractColumns returns the columns listed by an INSERT statement
+	ExtractColumns(query string) ([]string, error)
+	// ExtractTable returns the table an INSERT statement writes to
+	ExtractTable(query string) (TableRef, error)
+}
+
+// SchemaResolver supplies the columns of a table, e.g. backed by DESCRIBE
+// TABLE or a cached schema. The database is empty for unqualified tables
+type SchemaResolver interface {
+	Columns(database, table string) ([]string, error)
+}
+
+// Parser is the ColumnExtractor backed by this package's tokenizer
+type Parser struct {
+	// Schema, if set, supplies the columns of an INSERT into a table without
+	// a column list
+	Schema SchemaResolver
+	// RejectEmptyColumnList makes INSERT INTO t () ... fail with
+	// ErrEmptyColumnList rather than yield no columns
+	RejectEmptyColumnList bool
+	// RejectTrailingComma makes INSERT INTO t (a, b, ) fail with
+	// ErrTrailingComma rather than ignore the comma
+	RejectTrailingComma bool
+	// Dialect sets the quoting, escaping and comment rules, ClickHouse if nil
+	Dialect Dialect
+}
+
+var _ ColumnExtractor = Parser{}
+
+// ExtractColumns returns the columns listed by the first statement of query,
+// or those Schema resolves for the target table of an INSERT without a list
+func (p Parser) ExtractColumns(query string) ([]string, error) {
+	e := p.extractor(query)
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	if p.Schema == nil || e.targetEnd() < 0 || e.columnListStart() >= 0 {
+		return e.columns(), nil
+	}
+	refs := tableRefs(e.tokens[e.skipComments(0):])
+	if len(refs) == 0 || refs[0].Table == "" {
+		return e.columns(), nil
+	}
+	columns, err := p.Schema.Columns(refs[0].Database, refs[0].Table)
+	if err != nil {
+		return nil, fmt.Errorf("resolve columns of %s: %w", refs[0], err)
+	}
+	return columns, nil
+}
+
+// extractor returns an extractor of query with the options and dialect of p
+func (p Parser) extractor(query string) *columnExtractor {
+	e := &columnExtractor{
+		query:               query,
+		rejectTrailingComma: p.RejectTrailingComma,
+		dialect:             p.Dialect,
+	}
+	if p.RejectEmptyColumnList {
+		e.emptyColumnList = rejectEmptyColumnList
+	}
+	return e
+}
+
+// ExtractTable returns the table or table function the first statement of
+// query inserts into
+func (p Parser) ExtractTable(query string) (TableRef, error) {
+	e := &columnExtractor{
+		query:   query,
+		dialect: p.Dialect,
+	}
+	if err := e.parse(); err != nil {
+		return TableRef{}, err
+	}
+	if len(e.tokens) == 0 || !e.tokens[0].IsKeyword("INSERT") {
+		return TableRef{}, errors.New("not an INSERT statement")
+	}
+	refs := tableRefs(e.tokens)
+	if len(refs) == 0 {
+		return TableRef{}, errors.New("no table found")
+	}
+	return refs[0], nil
+}
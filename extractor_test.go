@@ -0,0 +1,115 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockExtractor shows how applications can stand in for the parser
+type mockExtractor struct {
+	columns []string
+	err     error
+}
+
+func (m mockExtractor) ExtractColumns(string) ([]string, error) {
+	return m.columns, m.err
+}
+
+func (m mockExtractor) ExtractTable(string) (TableRef, error) {
+	return TableRef{Table: `mocked`}, m.err
+}
+
+func TestParser(t *testing.T) {
+	var extractor ColumnExtractor = Parser{}
+
+	t.Run(`columns`, func(t *testing.T) {
+		columns, err := extractor.ExtractColumns("INSERT INTO db.t (a, `b c`)")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`a`, "`b c`"}, columns)
+		_, err = extractor.ExtractColumns(`INSERT INTO t (a €)`)
+		assert.EqualError(t, err, `1:18: unexpected rune: €`)
+	})
+
+	t.Run(`table`, func(t *testing.T) {
+		table, err := extractor.ExtractTable("INSERT INTO `db`.t (a)")
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Database: `db`, Table: `t`}, table)
+		table, err = extractor.ExtractTable(`INSERT INTO FUNCTION s3('url') (a)`)
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Function: `s3`}, table)
+	})
+
+	t.Run(`table errors`, func(t *testing.T) {
+		_, err := extractor.ExtractTable(`SELECT 1`)
+		assert.EqualError(t, err, `not an INSERT statement`)
+		_, err = extractor.ExtractTable(`INSERT INTO (a)`)
+		assert.EqualError(t, err, `no table found`)
+	})
+}
+
+func TestMockExtractor(t *testing.T) {
+	var extractor ColumnExtractor = mockExtractor{err: errors.New(`unavailable`)}
+	_, err := extractor.ExtractColumns(`INSERT INTO t (a)`)
+	assert.EqualError(t, err, `unavailable`)
+}
+
+// mapSchema resolves columns from a map keyed by qualified table name
+type mapSchema map[string][]string
+
+func (m mapSchema) Columns(database, table string) ([]string, error) {
+	columns, ok := m[TableRef{Database: database, Table: table}.String()]
+	if !ok {
+		return nil, errors.New("unknown table")
+	}
+	return columns, nil
+}
+
+func TestParserSchema(t *testing.T) {
+	parser := Parser{Schema: mapSchema{
+		`t`:    {`a`, `b`},
+		`db.t`: {`x`},
+	}}
+	tests := []struct {
+		query   string
+		columns []string
+		err     string
+	}{
+		{query: `INSERT INTO t VALUES (1, 2)`, columns: []string{`a`, `b`}},
+		{query: "INSERT INTO `db`.t FORMAT CSV", columns: []string{`x`}},
+		{query: `INSERT INTO t (b) VALUES (2)`, columns: []string{`b`}},
+		{query: `INSERT INTO FUNCTION s3('url') VALUES`, columns: []string{}},
+		{query: `SELECT 1`, columns: []string{}},
+		{query: `INSERT INTO other VALUES`, err: `resolve columns of other: unknown table`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			columns, err := parser.ExtractColumns(tt.query)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.columns, columns)
+		})
+	}
+}
+
+func TestParserRejectEmptyColumnList(t *testing.T) {
+	columns, err := Parser{}.ExtractColumns(`INSERT INTO t () VALUES ()`)
+	assert.NoError(t, err)
+	assert.Empty(t, columns)
+
+	parser := Parser{RejectEmptyColumnList: true}
+	_, err = parser.ExtractColumns(`INSERT INTO t () VALUES ()`)
+	assert.ErrorIs(t, err, ErrEmptyColumnList)
+	columns, err = parser.ExtractColumns(`INSERT INTO t VALUES ()`)
+	assert.NoError(t, err)
+	assert.Empty(t, columns)
+}
+
+func TestParserRejectTrailingComma(t *testing.T) {
+	_, err := Parser{RejectTrailingComma: true}.ExtractColumns(`INSERT INTO t (a, b, )`)
+	assert.EqualError(t, err, `1:20: trailing comma in column list`)
+}
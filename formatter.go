@@ -0,0 +1,134 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"strings"
+)
+
+// ColumnListStyle selects how the formatter lays out a column list
+type ColumnListStyle int
+
+const (
+	SingleLine ColumnListStyle = iota // all columns on the line of the table name
+	OnePerLine                        // one column per line, aligned under the first
+	Wrapped                           // as many columns per line as fit in Width, aligned under the first
+)
+
+// defaultWidth is the line width Wrapped uses when none is set
+const defaultWidth = 80
+
+// KeywordCase selects the case keywords are written in
+type KeywordCase int
+
+const (
+	UpperKeywords KeywordCase = iota // INSERT INTO
+	LowerKeywords                    // insert into
+)
+
+// FormatStyle configures the formatter
+type FormatStyle struct {
+	ColumnList ColumnListStyle
+	// Width is the maximum line length for the Wrapped style, 0 means defaultWidth.
+	// Columns longer than the width are never split
+	Width    int
+	Keywords KeywordCase
+	// Indent, if set, puts the columns of the OnePerLine and Wrapped styles
+	// on lines of their own indented by as many spaces, the closing
+	// parenthesis on a line of its own, rather than aligning them under the
+	// first column
+	Indent int
+}
+
+// keyword returns keyword, given in upper case, in the case of the style
+func (style FormatStyle) keyword(keyword string) string {
+	if style.Keywords == LowerKeywords {
+		return strings.ToLower(keyword)
+	}
+	return keyword
+}
+
+// writeColumnList writes the parenthesized columns in the style, the line
+// being column runes long so far
+func (style FormatStyle) writeColumnList(b *strings.Builder, column int, columns []string) {
+	b.WriteString("(")
+	column++
+	indent := strings.Repeat(" ", column)
+	if style.Indent > 0 && style.ColumnList != SingleLine && len(columns) > 0 {
+		indent = strings.Repeat(" ", style.Indent)
+		b.WriteString("\n" + indent)
+		column = style.Indent
+	}
+	switch style.ColumnList {
+	case OnePerLine:
+		b.WriteString(strings.Join(columns, ",\n"+indent))
+	case Wrapped:
+		width := style.Width
+		if width <= 0 {
+			width = defaultWidth
+		}
+		lineLength := column
+		for i, column := range columns {
+			// Leave room for the separator or the closing parenthesis
+			columnLength := len([]rune(column)) + 1
+			switch {
+			case i == 0:
+			case lineLength+1+columnLength > width:
+				b.WriteString(",\n" + indent)
+				lineLength = len(indent)
+			default:
+				b.WriteString(", ")
+				lineLength += 2
+			}
+			b.WriteString(column)
+			lineLength += columnLength - 1
+		}
+	default:
+		b.WriteString(strings.Join(columns, ", "))
+	}
+	if style.Indent > 0 && style.ColumnList != SingleLine && len(columns) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(")")
+}
+
+// FormatInsertHeader rewrites the header of an INSERT statement, that is the
+// INSERT INTO keywords, the table and the column list, in the given style.
+// Whatever follows the column list, e.g. VALUES or FORMAT, is kept verbatim.
+// Formatting is idempotent and never changes the columns extracted
+func FormatInsertHeader(query string, style FormatStyle) (string, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return "", err
+	}
+	if len(e.tokens) < 2 || !e.tokens[0].IsKeyword("INSERT") || !e.tokens[1].IsKeyword("INTO") {
+		return "", errors.New("not an INSERT INTO statement")
+	}
+	open, end, ok := e.columnList()
+	if !ok {
+		return "", errors.New("no column list")
+	}
+	if end == len(e.tokens) {
+		return "", errors.New("unclosed column list")
+	}
+
+	prefix := style.keyword("INSERT INTO") + " " + joinTokens(e.tokens[2:open]) + " "
+	var b strings.Builder
+	b.WriteString(prefix)
+	style.writeColumnList(&b, len([]rune(prefix)), e.columns())
+	b.WriteString(e.query[e.offsets[end]+1:])
+	return b.String(), nil
+}
+
+// joinTokens renders tokens separated by spaces, except around dots
+func joinTokens(tokens []Token) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 && token.Value != "." && tokens[i-1].Value != "." {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.Value)
+	}
+	return b.String()
+}
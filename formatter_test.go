@@ -0,0 +1,137 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatInsertHeader(t *testing.T) {
+	query := "insert into db . events(id,`user name`,  'ts')  VALUES (1, 'a', now())"
+
+	t.Run(`single line`, func(t *testing.T) {
+		formatted, err := FormatInsertHeader(query, FormatStyle{})
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO db.events (id, `user name`, 'ts')  VALUES (1, 'a', now())", formatted)
+	})
+
+	t.Run(`one per line`, func(t *testing.T) {
+		formatted, err := FormatInsertHeader(query, FormatStyle{ColumnList: OnePerLine})
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO db.events (id,\n"+
+			"                       `user name`,\n"+
+			"                       'ts')  VALUES (1, 'a', now())", formatted)
+	})
+
+	t.Run(`wrapped`, func(t *testing.T) {
+		formatted, err := FormatInsertHeader(`INSERT INTO t (alpha, beta, gamma, delta, epsilon)`, FormatStyle{ColumnList: Wrapped, Width: 30})
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO t (alpha, beta,\n"+
+			"               gamma, delta,\n"+
+			"               epsilon)", formatted)
+		for _, line := range []string{"INSERT INTO t (alpha, beta,", "               gamma, delta,"} {
+			assert.LessOrEqual(t, len(line), 30)
+		}
+	})
+
+	t.Run(`indented and lower case`, func(t *testing.T) {
+		formatted, err := FormatInsertHeader(query, FormatStyle{ColumnList: OnePerLine, Indent: 2, Keywords: LowerKeywords})
+		assert.NoError(t, err)
+		assert.Equal(t, "insert into db.events (\n"+
+			"  id,\n"+
+			"  `user name`,\n"+
+			"  'ts'\n"+
+			")  VALUES (1, 'a', now())", formatted)
+	})
+
+	t.Run(`wrapped with default width`, func(t *testing.T) {
+		formatted, err := FormatInsertHeader(`INSERT INTO t (a, b)`, FormatStyle{ColumnList: Wrapped})
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO t (a, b)`, formatted)
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		_, err := FormatInsertHeader(`SELECT (a)`, FormatStyle{})
+		assert.EqualError(t, err, `not an INSERT INTO statement`)
+		_, err = FormatInsertHeader(`INSERT INTO t VALUES`, FormatStyle{})
+		assert.EqualError(t, err, `no column list`)
+		_, err = FormatInsertHeader(`INSERT INTO t (a`, FormatStyle{})
+		assert.EqualError(t, err, `unclosed column list`)
+	})
+}
+
+func TestFormatInsertHeaderIsStable(t *testing.T) {
+	queries := []string{
+		`INSERT INTO t (a, b)`,
+		"insert into `db`.t(`x (y)`, 'z,w', c) FORMAT JSONEachRow",
+		"INSERT INTO t /* hint */ (a, -- first\n b) VALUES",
+	}
+	styles := []FormatStyle{{}, {ColumnList: OnePerLine}, {ColumnList: Wrapped, Width: 20}}
+	for _, query := range queries {
+		for _, style := range styles {
+			once, err := FormatInsertHeader(query, style)
+			assert.NoError(t, err)
+			twice, err := FormatInsertHeader(once, style)
+			assert.NoError(t, err)
+			assert.Equal(t, once, twice, "formatting is idempotent")
+
+			original := &columnExtractor{query: query}
+			assert.NoError(t, original.parse())
+			formatted := &columnExtractor{query: once}
+			assert.NoError(t, formatted.parse())
+			assert.Equal(t, original.columns(), formatted.columns(), "formatting keeps the columns")
+		}
+	}
+}
+
+// TestFormattingIsIdempotentOverCorpus checks over the queries of the
+// snapshot corpus and of the other formatter tests that formatting a
+// formatted query changes nothing and that formatting never changes the
+// columns extracted
+func TestFormattingIsIdempotentOverCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "snapshots", "*.sql"))
+	assert.NoError(t, err)
+	queries := []string{
+		"INSERT INTO db.t (a, b) SETTINGS async_insert = 1 VALUES (1, -2), (3, [4, 5])",
+		"insert into t (x) values (1 + 2 * 3, not a, f(- -1, 'q'))",
+		"INSERT INTO t (a) FORMAT JSONEachRow {\"a\": 1}",
+	}
+	for _, path := range paths {
+		query, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		queries = append(queries, strings.TrimSuffix(string(query), "\n"))
+	}
+	styles := []FormatStyle{
+		{},
+		{ColumnList: OnePerLine, Keywords: LowerKeywords},
+		{ColumnList: Wrapped, Width: 20, Indent: 4},
+	}
+
+	columns := func(query string) []string {
+		e := &columnExtractor{query: query}
+		assert.NoError(t, e.parse())
+		return e.columns()
+	}
+	for _, query := range queries {
+		for _, style := range styles {
+			if once, err := FormatInsertHeader(query, style); err == nil {
+				twice, err := FormatInsertHeader(once, style)
+				assert.NoError(t, err)
+				assert.Equal(t, once, twice, "FormatInsertHeader is idempotent for %q", query)
+				assert.Equal(t, columns(query), columns(once), "FormatInsertHeader keeps the columns of %q", query)
+			}
+			if stmt, err := ParseInsertAST(query); err == nil {
+				once := Format(stmt, style)
+				reparsed, err := ParseInsertAST(once)
+				if !assert.NoError(t, err, "Format output of %q parses", query) {
+					continue
+				}
+				assert.Equal(t, once, Format(reparsed, style), "Format is idempotent for %q", query)
+				assert.Equal(t, columns(query), columns(once), "Format keeps the columns of %q", query)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package grammar holds the ClickHouse word lists the parser relies on:
+// keywords, input and output formats and built-in data type names. They are
+// exported as data for completion engines, highlighters and other tools that
+// would otherwise maintain their own copies
+package grammar
+
+// ClickHouseVersion is the ClickHouse release the lists were taken from
+const ClickHouseVersion = "24.8"
+
+// Keywords lists, upper cased and sorted, the words the parser tokenizes as
+// keywords. ClickHouse keywords aren't reserved, so words commonly used as
+// names, e.g. DEFAULT, are left out
+var Keywords = []string{
+	"ALTER", "AND", "AS", "ASC", "ATTACH", "BY", "CHECK", "CREATE", "DELETE",
+	"DESC", "DESCRIBE", "DETACH", "DICTIONARY", "DISTINCT", "DROP", "EXCHANGE",
+	"EXISTS", "FORMAT", "FROM", "FUNCTION", "GROUP", "HAVING", "IF", "IN",
+	"INFILE", "INSERT", "INTO", "JOIN", "LIMIT", "LIVE", "MATERIALIZED", "NOT",
+	"NULL", "ON", "OPTIMIZE", "OR", "ORDER", "RENAME", "REPLACE", "SELECT",
+	"SETTINGS", "SHOW", "TABLE", "TABLES", "TEMPORARY", "TO", "TRUNCATE",
+	"UNION", "USING", "VALUES", "VIEW", "WHERE", "WITH",
+}
+
+// Formats lists, sorted, the names accepted by the FORMAT clause, aliases
+// included
+var Formats = []string{
+	"Arrow", "ArrowStream", "Avro", "AvroConfluent", "BSONEachRow", "CSV",
+	"CSVWithNames", "CSVWithNamesAndTypes", "CapnProto", "CustomSeparated",
+	"CustomSeparatedWithNames", "CustomSeparatedWithNamesAndTypes", "DWARF",
+	"Form", "JSON", "JSONAsObject", "JSONAsString", "JSONColumns",
+	"JSONColumnsWithMetadata", "JSONCompact", "JSONCompactColumns",
+	"JSONCompactEachRow", "JSONCompactEachRowWithNames",
+	"JSONCompactEachRowWithNamesAndTypes", "JSONCompactStrings",
+	"JSONCompactStringsEachRow", "JSONCompactStringsEachRowWithNames",
+	"JSONCompactStringsEachRowWithNamesAndTypes", "JSONEachRow",
+	"JSONEachRowWithProgress", "JSONObjectEachRow", "JSONStrings",
+	"JSONStringsEachRow", "JSONStringsEachRowWithProgress", "LineAsString",
+	"Markdown", "MsgPack", "MySQLDump", "Native", "Npy", "Null", "ORC", "One",
+	"Parquet", "ParquetMetadata", "Pretty", "PrettyCompact",
+	"PrettyCompactMonoBlock", "PrettyCompactNoEscapes",
+	"PrettyCompactNoEscapesMonoBlock", "PrettyJSONEachRow", "PrettyMonoBlock",
+	"PrettyNoEscapes", "PrettyNoEscapesMonoBlock", "PrettySpace",
+	"PrettySpaceMonoBlock", "PrettySpaceNoEscapes",
+	"PrettySpaceNoEscapesMonoBlock", "Prometheus", "Protobuf", "ProtobufList",
+	"ProtobufSingle", "RawBLOB", "Regexp", "RowBinary", "RowBinaryWithDefaults",
+	"RowBinaryWithNames", "RowBinaryWithNamesAndTypes", "SQLInsert", "TSKV",
+	"TSV", "TSVRaw", "TSVRawWithNames", "TSVRawWithNamesAndTypes",
+	"TSVWithNames", "TSVWithNamesAndTypes", "TabSeparated", "TabSeparatedRaw",
+	"TabSeparatedRawWithNames", "TabSeparatedRawWithNamesAndTypes",
+	"TabSeparatedWithNames", "TabSeparatedWithNamesAndTypes", "Template",
+	"TemplateIgnoreSpaces", "Values", "Vertical", "XML",
+}
+
+// Types lists, sorted, the built-in data type names, parametric ones such
+// as Array or Decimal included without their parameters
+var Types = []string{
+	"AggregateFunction", "Array", "Bool", "Date", "Date32", "DateTime",
+	"DateTime64", "Decimal", "Decimal128", "Decimal256", "Decimal32",
+	"Decimal64", "Dynamic", "Enum", "Enum16", "Enum8", "FixedString",
+	"Float32", "Float64", "IPv4", "IPv6", "Int128", "Int16", "Int256", "Int32",
+	"Int64", "Int8", "IntervalDay", "IntervalHour", "IntervalMicrosecond",
+	"IntervalMillisecond", "IntervalMinute", "IntervalMonth",
+	"IntervalNanosecond", "IntervalQuarter", "IntervalSecond", "IntervalWeek",
+	"IntervalYear", "JSON", "LineString", "LowCardinality", "Map",
+	"MultiLineString", "MultiPolygon", "Nested", "Nothing", "Nullable",
+	"Object", "Point", "Polygon", "Ring", "SimpleAggregateFunction", "String",
+	"Tuple", "UInt128", "UInt16", "UInt256", "UInt32", "UInt64", "UInt8",
+	"UUID", "Variant",
+}
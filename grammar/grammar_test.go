@@ -0,0 +1,29 @@
+package grammar
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLists(t *testing.T) {
+	lists := map[string][]string{
+		`keywords`: Keywords,
+		`formats`:  Formats,
+		`types`:    Types,
+	}
+	for name, list := range lists {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, slices.IsSorted(list), `sorted`)
+			assert.Equal(t, len(list), len(slices.Compact(slices.Clone(list))), `unique`)
+		})
+	}
+
+	t.Run(`keywords are upper cased`, func(t *testing.T) {
+		for _, keyword := range Keywords {
+			assert.Equal(t, strings.ToUpper(keyword), keyword)
+		}
+	})
+}
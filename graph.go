@@ -0,0 +1,89 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphStatement is a node of the dependency graph
+type GraphStatement struct {
+	Index   int      `json:"index"`   // position of the statement in the script
+	Kind    string   `json:"kind"`    // CREATE, ALTER or INSERT
+	Target  string   `json:"target"`  // table created, altered or inserted into
+	Sources []string `json:"sources"` // other tables the statement reads from
+}
+
+// GraphEdge records that statement To must run after statement From because
+// it references the table From targets
+type GraphEdge struct {
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Table string `json:"table"`
+}
+
+// DependencyGraph links the statements of a script by the tables they reference
+type DependencyGraph struct {
+	Statements []GraphStatement `json:"statements"`
+	Edges      []GraphEdge      `json:"edges"`
+}
+
+// BuildDependencyGraph splits a script on semicolons and links its CREATE,
+// ALTER and INSERT statements by the tables they reference. Each statement
+// depends on the latest earlier statement targeting a table it references.
+// This is best effort, tokenisation errors are ignored
+func BuildDependencyGraph(script string) *DependencyGraph {
+	e := &columnExtractor{
+		query: script,
+	}
+	statements, _ := e.statements()
+
+	graph := &DependencyGraph{}
+	lastWriter := make(map[string]int)
+	index := 0
+	for _, tokens := range statements {
+		kind := strings.ToUpper(tokens[0].Value)
+		refs := tableRefs(tokens)
+		switch kind {
+		case "CREATE", "ALTER", "INSERT":
+		default:
+			refs = nil
+		}
+		// Table functions are never the target of a tracked statement
+		if len(refs) > 0 && refs[0].Function == "" {
+			statement := GraphStatement{
+				Index:   index,
+				Kind:    kind,
+				Target:  refs[0].String(),
+				Sources: make([]string, 0, len(refs)-1),
+			}
+			for _, ref := range refs[1:] {
+				if ref.Function == "" {
+					statement.Sources = append(statement.Sources, ref.String())
+				}
+			}
+			for _, table := range append([]string{statement.Target}, statement.Sources...) {
+				if from, ok := lastWriter[table]; ok {
+					graph.Edges = append(graph.Edges, GraphEdge{From: from, To: index, Table: table})
+				}
+			}
+			lastWriter[statement.Target] = index
+			graph.Statements = append(graph.Statements, statement)
+		}
+		index++
+	}
+	return graph
+}
+
+// DOT renders the graph in Graphviz format
+func (g *DependencyGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	for _, s := range g.Statements {
+		fmt.Fprintf(&b, "\ts%d [label=%q];\n", s.Index, fmt.Sprintf("%d: %s %s", s.Index, s.Kind, s.Target))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "\ts%d -> s%d [label=%q];\n", edge.From, edge.To, edge.Table)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const migrationScript = "CREATE TABLE db.events (id UInt64) ENGINE MergeTree ORDER BY id;\n" +
+	"CREATE TABLE db.daily AS db.events;\n" +
+	"ALTER TABLE db.events ADD COLUMN name String;\n" +
+	"INSERT INTO `db`.`daily` (id) SELECT id FROM db.events;\n" +
+	"SELECT count() FROM db.daily"
+
+func TestBuildDependencyGraph(t *testing.T) {
+	t.Run(`statements`, func(t *testing.T) {
+		graph := BuildDependencyGraph(migrationScript)
+		assert.Equal(t, []GraphStatement{
+			{Index: 0, Kind: `CREATE`, Target: `db.events`, Sources: []string{}},
+			{Index: 1, Kind: `CREATE`, Target: `db.daily`, Sources: []string{`db.events`}},
+			{Index: 2, Kind: `ALTER`, Target: `db.events`, Sources: []string{}},
+			{Index: 3, Kind: `INSERT`, Target: `db.daily`, Sources: []string{`db.events`}},
+		}, graph.Statements)
+	})
+
+	t.Run(`edges`, func(t *testing.T) {
+		graph := BuildDependencyGraph(migrationScript)
+		assert.Equal(t, []GraphEdge{
+			{From: 0, To: 1, Table: `db.events`},
+			{From: 0, To: 2, Table: `db.events`},
+			{From: 1, To: 3, Table: `db.daily`},
+			{From: 2, To: 3, Table: `db.events`},
+		}, graph.Edges)
+	})
+
+	t.Run(`materialized view`, func(t *testing.T) {
+		graph := BuildDependencyGraph(
+			"CREATE TABLE src (a UInt8); CREATE TABLE dst (a UInt8);" +
+				"CREATE MATERIALIZED VIEW IF NOT EXISTS mv TO dst AS SELECT a FROM src")
+		assert.Equal(t, []string{`dst`, `src`}, graph.Statements[2].Sources)
+		assert.Len(t, graph.Edges, 2)
+	})
+
+	t.Run(`table functions are not tables`, func(t *testing.T) {
+		graph := BuildDependencyGraph(`INSERT INTO t SELECT * FROM remote('host', db, t2)`)
+		assert.Empty(t, graph.Statements[0].Sources)
+	})
+
+	t.Run(`dot`, func(t *testing.T) {
+		graph := BuildDependencyGraph(`CREATE TABLE a (x UInt8); INSERT INTO a (x) SELECT 1`)
+		assert.Equal(t, "digraph dependencies {\n"+
+			"\ts0 [label=\"0: CREATE a\"];\n"+
+			"\ts1 [label=\"1: INSERT a\"];\n"+
+			"\ts0 -> s1 [label=\"a\"];\n"+
+			"}\n", graph.DOT())
+	})
+
+	t.Run(`json`, func(t *testing.T) {
+		graph := BuildDependencyGraph(`CREATE TABLE a (x UInt8); INSERT INTO a (x) SELECT 1`)
+		data, err := json.Marshal(graph)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"statements": [
+				{"index": 0, "kind": "CREATE", "target": "a", "sources": []},
+				{"index": 1, "kind": "INSERT", "target": "a", "sources": []}
+			],
+			"edges": [{"from": 0, "to": 1, "table": "a"}]
+		}`, string(data))
+	})
+}
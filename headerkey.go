@@ -0,0 +1,100 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"hash/fnv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// columnSeparator joins the columns of a HeaderKey. NUL can't appear in an
+// identifier, so the joined list splits back unambiguously
+const columnSeparator = "\x00"
+
+// HeaderKey identifies the header of an INSERT: the target table, its
+// normalized column list and the input format. Keys are comparable, so they
+// can be used as map keys wherever INSERT headers are cached or grouped
+type HeaderKey struct {
+	Table   TableRef
+	columns string
+	Format  string // empty for VALUES and INSERT ... SELECT
+}
+
+// NewHeaderKey builds a key from a table, columns and format. Backtick and
+// single quotes are removed from the columns and names are NFC normalized,
+// so `a` and a give the same key
+func NewHeaderKey(table TableRef, columns []string, format string) HeaderKey {
+	normalized := make([]string, len(columns))
+	for i, column := range columns {
+		normalized[i] = norm.NFC.String(unquoteIdentifier(column))
+	}
+	return HeaderKey{
+		Table:   table,
+		columns: strings.Join(normalized, columnSeparator),
+		Format:  format,
+	}
+}
+
+// HeaderKeyOf builds the key of the INSERT header of the first statement of
+// query
+func HeaderKeyOf(query string) (HeaderKey, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return HeaderKey{}, err
+	}
+	if len(e.tokens) == 0 || !e.tokens[0].IsKeyword("INSERT") {
+		return HeaderKey{}, errors.New("not an INSERT statement")
+	}
+	refs := tableRefs(e.tokens)
+	if len(refs) == 0 {
+		return HeaderKey{}, errors.New("no table found")
+	}
+
+	var columns []string
+	if _, end, ok := e.columnList(); ok {
+		if end == len(e.tokens) {
+			return HeaderKey{}, errors.New("unclosed column list")
+		}
+		columns = e.columns()
+	}
+	format, _ := e.format()
+	return NewHeaderKey(refs[0], columns, format), nil
+}
+
+// Columns returns the normalized column names of the key
+func (k HeaderKey) Columns() []string {
+	if k.columns == "" {
+		return []string{}
+	}
+	return strings.Split(k.columns, columnSeparator)
+}
+
+// String returns the key in INSERT header form, e.g. db.t (a, b) FORMAT CSV
+func (k HeaderKey) String() string {
+	var b strings.Builder
+	b.WriteString(k.Table.String())
+	if k.columns != "" {
+		b.WriteString(" (")
+		b.WriteString(strings.ReplaceAll(k.columns, columnSeparator, ", "))
+		b.WriteString(")")
+	}
+	if k.Format != "" {
+		b.WriteString(" FORMAT ")
+		b.WriteString(k.Format)
+	}
+	return b.String()
+}
+
+// Hash returns the 64-bit FNV-1a hash of the key, for sharding and external
+// caches that can't use HeaderKey itself
+func (k HeaderKey) Hash() uint64 {
+	h := fnv.New64a()
+	for _, part := range []string{k.Table.Database, k.Table.Table, k.Table.Function, k.columns, k.Format} {
+		h.Write([]byte(part))
+		h.Write([]byte{0xff}) // not valid UTF-8, so parts can't run into each other
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,61 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderKeyOf(t *testing.T) {
+	t.Run(`values`, func(t *testing.T) {
+		key, err := HeaderKeyOf("INSERT INTO db.t (`a`, b) VALUES (1, 2)")
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Database: `db`, Table: `t`}, key.Table)
+		assert.Equal(t, []string{`a`, `b`}, key.Columns())
+		assert.Equal(t, ``, key.Format)
+		assert.Equal(t, `db.t (a, b)`, key.String())
+	})
+
+	t.Run(`format`, func(t *testing.T) {
+		key, err := HeaderKeyOf("INSERT INTO t FORMAT JSONEachRow {\"a\": 1}")
+		assert.NoError(t, err)
+		assert.Empty(t, key.Columns())
+		assert.Equal(t, `t FORMAT JSONEachRow`, key.String())
+	})
+
+	t.Run(`equal headers give equal keys`, func(t *testing.T) {
+		a, err := HeaderKeyOf("INSERT INTO t (`a`, 'b') FORMAT CSV")
+		assert.NoError(t, err)
+		b, err := HeaderKeyOf(`insert into t (a, b) format CSV`)
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+		assert.Equal(t, a.Hash(), b.Hash())
+
+		keys := map[HeaderKey]int{a: 1}
+		assert.Equal(t, 1, keys[b])
+	})
+
+	t.Run(`different headers`, func(t *testing.T) {
+		keys := []HeaderKey{
+			NewHeaderKey(TableRef{Table: `t`}, []string{`a`, `b`}, ``),
+			NewHeaderKey(TableRef{Table: `t`}, []string{`a,b`}, ``),
+			NewHeaderKey(TableRef{Table: `t`}, []string{`b`, `a`}, ``),
+			NewHeaderKey(TableRef{Table: `t`}, []string{`a`, `b`}, `CSV`),
+			NewHeaderKey(TableRef{Database: `t`, Table: `a`}, nil, ``),
+			NewHeaderKey(TableRef{Table: `ta`}, nil, ``),
+		}
+		hashes := map[uint64]bool{}
+		for i, key := range keys {
+			hashes[key.Hash()] = true
+			for _, other := range keys[i+1:] {
+				assert.NotEqual(t, key, other)
+			}
+		}
+		assert.Len(t, hashes, len(keys))
+	})
+
+	t.Run(`not an insert`, func(t *testing.T) {
+		_, err := HeaderKeyOf(`SELECT 1`)
+		assert.EqualError(t, err, `not an INSERT statement`)
+	})
+}
@@ -0,0 +1,323 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Insert describes the first statement of a query that is an INSERT
+type Insert struct {
+	Table   TableRef
+	Columns []string
+	// EmptyColumnList is set for INSERT INTO t () ..., whose Columns are
+	// empty just as without a column list
+	EmptyColumnList bool
+	// Function holds the call of INSERT INTO FUNCTION, nil for tables
+	Function *TableFunction
+	// Infile holds the FROM INFILE clause, nil without one
+	Infile *Infile
+	// Settings holds the SETTINGS of the INSERT by name, nil without them
+	Settings map[string]string
+	// Select holds the select list of INSERT ... SELECT, nil otherwise
+	Select []SelectItem
+	// With holds the definitions of the WITH clause preceding the SELECT
+	With []CTE
+	// Tables holds the tables read by FROM and JOIN of INSERT ... SELECT
+	Tables []AliasedTable
+
+	e      *columnExtractor
+	values int // index of the VALUES keyword in e.tokens, -1 without one
+}
+
+// ParseInsert tokenizes the first statement of query and locates the parts
+// of the INSERT it has to be
+func ParseInsert(query string) (*Insert, error) {
+	return Parser{}.ParseInsert(query)
+}
+
+// ParseInsert is ParseInsert with the options and dialect of p
+func (p Parser) ParseInsert(query string) (*Insert, error) {
+	e := p.extractor(query)
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	i := e.skipComments(0)
+	if i == len(e.tokens) || !e.tokens[i].IsKeyword("INSERT") {
+		return nil, errors.New("not an INSERT statement")
+	}
+
+	insert := &Insert{
+		Columns:         e.columns(),
+		EmptyColumnList: e.hasEmptyColumnList(),
+		e:               e,
+		values:          -1,
+	}
+	if refs := tableRefs(e.tokens[i:]); len(refs) > 0 {
+		insert.Table = refs[0]
+	}
+	insert.Function = e.tableFunction()
+	insert.values = e.findClause(e.headerEnd(), "VALUES")
+	if insert.values < 0 {
+		if i := e.findClause(e.headerEnd(), "SELECT"); i >= 0 {
+			insert.Select = e.selectList(i)
+			start := i
+			if with := e.skipComments(e.headerEnd()); with < i && e.tokens[with].IsKeyword("WITH") {
+				insert.With = e.withClause(with, i)
+				start = with
+			}
+			insert.Tables = e.fromTables(start, insert.With)
+		}
+	}
+	infile, err := e.infile()
+	if err != nil {
+		return nil, err
+	}
+	insert.Infile = infile
+	settings, err := e.settings()
+	if err != nil {
+		return nil, err
+	}
+	insert.Settings = settings
+	return insert, nil
+}
+
+// IsSelect reports whether the rows inserted come from a SELECT
+func (s *Insert) IsSelect() bool {
+	return s.Select != nil
+}
+
+// TableFunction is a table function call such as s3('url', 'CSV')
+type TableFunction struct {
+	Name      string
+	Arguments []string // source text of each argument
+}
+
+// tableFunction returns the table function of INSERT INTO FUNCTION
+func (e *columnExtractor) tableFunction() *TableFunction {
+	i := skipTableKeyword(e.tokens, e.skipKeyword(e.skipComments(e.skipComments(0)+1), "INTO"))
+	if i >= len(e.tokens) || !e.tokens[i].IsKeyword("FUNCTION") {
+		return nil
+	}
+	name := e.skipComments(i + 1)
+	open := e.skipName(name)
+	if open == name || open == len(e.tokens) || e.tokens[open].Value != "(" {
+		return nil
+	}
+	end := e.matchingParenthesis(open)
+	if end < 0 {
+		return nil
+	}
+	function := &TableFunction{
+		Name:      e.sourceText(name, open-1),
+		Arguments: make([]string, 0),
+	}
+	for _, argument := range e.splitList(open+1, end) {
+		function.Arguments = append(function.Arguments, e.sourceText(argument[0], argument[len(argument)-1]))
+	}
+	return function
+}
+
+// Infile is the client-side file of INSERT ... FROM INFILE 'file'
+// [COMPRESSION 'method']
+type Infile struct {
+	Name        string
+	Compression string // empty without a COMPRESSION clause
+}
+
+// infile parses the FROM INFILE clause of an INSERT
+func (e *columnExtractor) infile() (*Infile, error) {
+	from := e.findClause(e.headerEnd(), "FROM")
+	if from < 0 {
+		return nil, nil
+	}
+	i := e.skipComments(from + 1)
+	if i == len(e.tokens) || !e.tokens[i].IsKeyword("INFILE") {
+		return nil, nil
+	}
+	name, err := e.stringAt(e.skipComments(i + 1))
+	if err != nil {
+		return nil, fmt.Errorf("INFILE: %w", err)
+	}
+	infile := &Infile{Name: name}
+	i = e.skipComments(e.skipComments(i+1) + 1)
+	if i < len(e.tokens) && e.tokens[i].Kind == TokenIdentifier && strings.EqualFold(e.tokens[i].Value, "COMPRESSION") {
+		if infile.Compression, err = e.stringAt(e.skipComments(i + 1)); err != nil {
+			return nil, fmt.Errorf("COMPRESSION: %w", err)
+		}
+	}
+	return infile, nil
+}
+
+// stringAt returns the decoded value of the string literal at index i
+func (e *columnExtractor) stringAt(i int) (string, error) {
+	if i == len(e.tokens) || e.tokens[i].Kind != TokenString {
+		return "", errors.New("missing string literal")
+	}
+	return e.tokens[i].DecodedValue()
+}
+
+// ValuesPos returns the position of the VALUES keyword, if there is one
+func (s *Insert) ValuesPos() (Position, bool) {
+	if s.values < 0 {
+		return Position{}, false
+	}
+	return s.e.tokenPosition(s.values), true
+}
+
+// HasInlineData reports whether rows follow VALUES, or data follows FORMAT
+// name, in the query itself, as opposed to INSERT ... VALUES with the data to
+// be sent separately, e.g. by a batch
+func (s *Insert) HasInlineData() bool {
+	if s.e.formatData >= 0 {
+		return s.e.formatData < len(s.e.query)
+	}
+	if s.values < 0 {
+		return false
+	}
+	next := s.e.skipComments(s.values + 1)
+	return next < len(s.e.tokens) && s.e.tokens[next] != statementTerminator
+}
+
+// NeedsExternalData reports whether the rows of the INSERT have to be sent
+// apart from the query, e.g. with a batch, rather than come from inline
+// VALUES rows or FORMAT data, a SELECT or FROM INFILE
+func (s *Insert) NeedsExternalData() bool {
+	return !s.IsSelect() && s.Infile == nil && !s.HasInlineData()
+}
+
+// DataOffset returns the byte offset in the query at which the inline data
+// starts, i.e. the first row after VALUES or the data following FORMAT name,
+// so that the header can be inspected and the data forwarded untouched. It
+// reports false without inline data
+func (s *Insert) DataOffset() (int, bool) {
+	switch {
+	case !s.HasInlineData():
+		return 0, false
+	case s.e.formatData >= 0:
+		return s.e.formatData, true
+	default:
+		return s.e.offsets[s.e.skipComments(s.values+1)], true
+	}
+}
+
+// Format returns the input format declared by FORMAT, e.g. JSONEachRow
+func (s *Insert) Format() (string, bool) {
+	return s.e.format()
+}
+
+// format returns the name following the FORMAT keyword of an INSERT
+func (e *columnExtractor) format() (string, bool) {
+	i := e.findClause(e.headerEnd(), "FORMAT")
+	if i < 0 {
+		return "", false
+	}
+	next := e.skipComments(i + 1)
+	if next == len(e.tokens) || (!e.tokens[next].isIdentifier() && e.tokens[next].Kind != TokenKeyword) {
+		return "", false
+	}
+	return e.tokens[next].Value, true
+}
+
+// settings parses the name = value pairs following the SETTINGS keyword of
+// an INSERT. String values are decoded, others are kept as written
+func (e *columnExtractor) settings() (map[string]string, error) {
+	i := e.findClause(e.headerEnd(), "SETTINGS")
+	if i < 0 {
+		return nil, nil
+	}
+	settings := make(map[string]string)
+	for {
+		name := e.skipComments(i + 1)
+		if name == len(e.tokens) || !e.tokens[name].isIdentifier() {
+			return nil, errors.New("missing setting name after SETTINGS")
+		}
+		equals := e.skipComments(name + 1)
+		if equals == len(e.tokens) || e.tokens[equals].Value != "=" {
+			return nil, fmt.Errorf("missing = after setting %s", e.tokens[name].Value)
+		}
+		value, end, ok := e.settingValue(equals + 1)
+		if !ok {
+			return nil, fmt.Errorf("missing value of setting %s", e.tokens[name].Value)
+		}
+		settings[e.tokens[name].Value] = value
+		if end == len(e.tokens) || e.tokens[end].Value != "," {
+			return settings, nil
+		}
+		i = end
+	}
+}
+
+// settingValue returns the value of a setting starting at start and the
+// index of the token ending it: a top-level comma, the clause that follows
+// or the end of the statement. It reports false if no token makes up the
+// value, as opposed to an empty string literal
+func (e *columnExtractor) settingValue(start int) (string, int, bool) {
+	var parts []string
+	first := -1
+	depth := 0
+	i := start
+	for ; i < len(e.tokens); i++ {
+		token := e.tokens[i]
+		if token.Kind == TokenComment {
+			continue
+		}
+		if depth == 0 && (token.Value == "," || token == statementTerminator ||
+			token.IsKeyword("VALUES") || token.IsKeyword("FORMAT") ||
+			token.IsKeyword("SELECT") || token.IsKeyword("WITH")) {
+			break
+		}
+		switch token.Value {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+		if first < 0 {
+			first = i
+		}
+		parts = append(parts, token.Value)
+	}
+	if len(parts) == 0 {
+		return "", i, false
+	}
+	if len(parts) == 1 && e.tokens[first].Kind == TokenString {
+		if decoded, err := e.tokens[first].DecodedValue(); err == nil {
+			return decoded, i, true
+		}
+	}
+	return strings.Join(parts, ""), i, true
+}
+
+// headerEnd returns the index of the first token after the table, or table
+// function, and column list of an INSERT
+func (e *columnExtractor) headerEnd() int {
+	if _, end, ok := e.columnList(); ok {
+		return min(end+1, len(e.tokens))
+	}
+	return max(e.targetEnd(), 0)
+}
+
+// findClause returns the index of keyword at the top level of the statement
+// from start on, or -1. The search stops at SELECT and WITH, whose bodies
+// belong to the query inserted from, except that a search for SELECT goes
+// past the definitions of a WITH clause
+func (e *columnExtractor) findClause(start int, keyword string) int {
+	depth := 0
+	for i := start; i < len(e.tokens); i++ {
+		token := e.tokens[i]
+		switch {
+		case token.Value == "(" || token.Value == "[":
+			depth++
+		case token.Value == ")" || token.Value == "]":
+			depth--
+		case depth > 0:
+		case token.IsKeyword(keyword):
+			return i
+		case token.IsKeyword("WITH") && strings.EqualFold(keyword, "SELECT"):
+		case token.IsKeyword("SELECT") || token.IsKeyword("WITH"):
+			return -1
+		}
+	}
+	return -1
+}
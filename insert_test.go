@@ -0,0 +1,347 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInsert(t *testing.T) {
+	t.Run(`inline data`, func(t *testing.T) {
+		insert, err := ParseInsert("INSERT INTO db.t (a, b)\nVALUES (1, 2)")
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Database: `db`, Table: `t`}, insert.Table)
+		assert.Equal(t, []string{`a`, `b`}, insert.Columns)
+		pos, ok := insert.ValuesPos()
+		assert.True(t, ok)
+		assert.Equal(t, Position{Offset: 24, Line: 2, Column: 1}, pos)
+		assert.True(t, insert.HasInlineData())
+	})
+
+	t.Run(`values for a batch`, func(t *testing.T) {
+		for _, query := range []string{`INSERT INTO t (a) VALUES`, `INSERT INTO t VALUES -- rows follow`, `INSERT INTO t VALUES;`} {
+			insert, err := ParseInsert(query)
+			assert.NoError(t, err)
+			_, ok := insert.ValuesPos()
+			assert.True(t, ok, query)
+			assert.False(t, insert.HasInlineData(), query)
+		}
+	})
+
+	t.Run(`without values`, func(t *testing.T) {
+		for _, query := range []string{
+			`INSERT INTO t (a)`,
+			`INSERT INTO t (a) FORMAT CSV`,
+			`INSERT INTO t (a) SELECT * FROM values('a Int', 1)`,
+			`INSERT INTO FUNCTION values('a Int') (a) SELECT 1`,
+		} {
+			insert, err := ParseInsert(query)
+			assert.NoError(t, err)
+			_, ok := insert.ValuesPos()
+			assert.False(t, ok, query)
+			assert.False(t, insert.HasInlineData(), query)
+		}
+	})
+
+	t.Run(`table function`, func(t *testing.T) {
+		insert, err := ParseInsert(`INSERT INTO FUNCTION remote('h', db, t) VALUES (1)`)
+		assert.NoError(t, err)
+		assert.Equal(t, TableRef{Function: `remote`}, insert.Table)
+		assert.True(t, insert.HasInlineData())
+	})
+
+	t.Run(`not an insert`, func(t *testing.T) {
+		_, err := ParseInsert(`SELECT 1`)
+		assert.EqualError(t, err, `not an INSERT statement`)
+	})
+}
+
+func TestDataOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		payload string
+	}{
+		{`values`, `INSERT INTO t (a) VALUES (1), (2)`, `(1), (2)`},
+		{`values after a comment`, "INSERT INTO t VALUES -- rows\n(1)", `(1)`},
+		{`format on the same line`, `INSERT INTO t FORMAT CSV 1,2`, `1,2`},
+		{`format then a line break`, "INSERT INTO t FORMAT CSV  \n 1,2\n", " 1,2\n"},
+		{`format data that isn't SQL`, "INSERT INTO t (a) FORMAT CSV\n\"unclosed,'€\n", "\"unclosed,'€\n"},
+		{`binary data`, "INSERT INTO t FORMAT RowBinary\n\x00\xff;", "\x00\xff;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			assert.NoError(t, err)
+			offset, ok := insert.DataOffset()
+			assert.True(t, ok)
+			assert.Equal(t, tt.payload, tt.query[offset:])
+		})
+	}
+
+	for _, query := range []string{`INSERT INTO t VALUES`, `INSERT INTO t FORMAT CSV`, `INSERT INTO t FORMAT CSV ;`, `INSERT INTO t SELECT 1`} {
+		t.Run(query, func(t *testing.T) {
+			insert, err := ParseInsert(query)
+			assert.NoError(t, err)
+			_, ok := insert.DataOffset()
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		query  string
+		format string
+		ok     bool
+	}{
+		{`INSERT INTO t (a, b) FORMAT JSONEachRow`, `JSONEachRow`, true},
+		{"insert into t format csv\n1,2", `csv`, true},
+		{`INSERT INTO t /* x */ FORMAT /* y */ Native`, `Native`, true},
+		{`INSERT INTO t VALUES (1)`, ``, false},
+		{`INSERT INTO t SELECT * FROM s3('x', format = 'CSV')`, ``, false},
+		{`INSERT INTO FUNCTION file('out', 'CSV') (a) FORMAT TSV`, `TSV`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			assert.NoError(t, err)
+			format, ok := insert.Format()
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.format, format)
+		})
+	}
+}
+
+func TestSettings(t *testing.T) {
+	tests := []struct {
+		query    string
+		settings map[string]string
+		err      string
+	}{
+		{
+			query:    `INSERT INTO t (a, b) SETTINGS async_insert=1, wait_for_async_insert=0 VALUES`,
+			settings: map[string]string{"async_insert": "1", "wait_for_async_insert": "0"},
+		},
+		{
+			query:    `INSERT INTO t SETTINGS format_csv_delimiter = ';', max_threads = -1 FORMAT CSV`,
+			settings: map[string]string{"format_csv_delimiter": ";", "max_threads": "-1"},
+		},
+		{
+			query:    `INSERT INTO t SETTINGS /* x */ insert_deduplicate = true SELECT 1`,
+			settings: map[string]string{"insert_deduplicate": "true"},
+		},
+		{
+			query:    `INSERT INTO t (a) SETTINGS x = '', y = /* z */ 'w' VALUES (1)`,
+			settings: map[string]string{"x": "", "y": "w"},
+		},
+		{
+			query: `INSERT INTO t (a) VALUES (1)`,
+		},
+		{
+			query: `INSERT INTO t SELECT * FROM s SETTINGS max_threads = 1`,
+		},
+		{
+			query: "INSERT INTO t FORMAT CSV\nSETTINGS,x = 1",
+		},
+		{
+			query: `INSERT INTO t SETTINGS async_insert VALUES`,
+			err:   `missing = after setting async_insert`,
+		},
+		{
+			query: `INSERT INTO t SETTINGS async_insert = VALUES`,
+			err:   `missing value of setting async_insert`,
+		},
+		{
+			query: `INSERT INTO t SETTINGS x = /* y */, z = 1 VALUES`,
+			err:   `missing value of setting x`,
+		},
+		{
+			query: `INSERT INTO t SETTINGS VALUES`,
+			err:   `missing setting name after SETTINGS`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.settings, insert.Settings)
+		})
+	}
+}
+
+func TestInsertSelect(t *testing.T) {
+	tests := []struct {
+		query   string
+		select_ []SelectItem
+	}{
+		{
+			query:   `INSERT INTO t (a, b) SELECT x, y FROM src`,
+			select_: []SelectItem{{Expr: `x`}, {Expr: `y`}},
+		},
+		{
+			query: `INSERT INTO t SELECT DISTINCT count(*) AS c, s.x + y total, toDate(d) ` + "`day`" + ` FROM s WHERE 1`,
+			select_: []SelectItem{
+				{Expr: `count(*)`, Alias: `c`},
+				{Expr: `s.x + y`, Alias: `total`},
+				{Expr: `toDate(d)`, Alias: "`day`"},
+			},
+		},
+		{
+			query: `INSERT INTO t SELECT a AS x, tuple(1, 2), [b, c] arr, 'lit' l; SELECT 1`,
+			select_: []SelectItem{
+				{Expr: `a`, Alias: `x`},
+				{Expr: `tuple(1, 2)`},
+				{Expr: `[b, c]`, Alias: `arr`},
+				{Expr: `'lit'`, Alias: `l`},
+			},
+		},
+		{
+			query: `INSERT INTO t (a) VALUES (1)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.select_, insert.Select)
+			assert.Equal(t, tt.select_ != nil, insert.IsSelect())
+		})
+	}
+}
+
+func TestInsertWith(t *testing.T) {
+	query := `INSERT INTO t (a, b) WITH cte AS (SELECT 1, (2)), 10 AS ten, totals AS (SELECT x FROM s) SELECT *, ten FROM cte`
+	insert, err := ParseInsert(query)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`a`, `b`}, insert.Columns)
+	assert.Equal(t, TableRef{Table: `t`}, insert.Table)
+	assert.Equal(t, []CTE{
+		{Name: `cte`, Expr: `SELECT 1, (2)`, Subquery: true},
+		{Name: `ten`, Expr: `10`},
+		{Name: `totals`, Expr: `SELECT x FROM s`, Subquery: true},
+	}, insert.With)
+	assert.Equal(t, []SelectItem{{Expr: `*`}, {Expr: `ten`}}, insert.Select)
+
+	t.Run(`output format of the select`, func(t *testing.T) {
+		insert, err := ParseInsert(`INSERT INTO t WITH x AS (SELECT 1) SELECT * FROM x FORMAT CSV`)
+		assert.NoError(t, err)
+		assert.False(t, insert.HasInlineData())
+		assert.Equal(t, []SelectItem{{Expr: `*`}}, insert.Select)
+	})
+}
+
+func TestInsertFunction(t *testing.T) {
+	tests := []struct {
+		query    string
+		function *TableFunction
+		columns  []string
+	}{
+		{
+			query: `INSERT INTO FUNCTION s3('https://bucket/data.csv', 'CSV', 'a UInt8, b String') (a, b) VALUES (1, 'x')`,
+			function: &TableFunction{
+				Name:      `s3`,
+				Arguments: []string{`'https://bucket/data.csv'`, `'CSV'`, `'a UInt8, b String'`},
+			},
+			columns: []string{`a`, `b`},
+		},
+		{
+			query: `INSERT INTO TABLE FUNCTION remote('host:9000', db.t, 'user', concat('p', 'w')) (x) VALUES`,
+			function: &TableFunction{
+				Name:      `remote`,
+				Arguments: []string{`'host:9000'`, `db.t`, `'user'`, `concat('p', 'w')`},
+			},
+			columns: []string{`x`},
+		},
+		{
+			query:    `INSERT INTO FUNCTION null() SELECT 1`,
+			function: &TableFunction{Name: `null`, Arguments: []string{}},
+			columns:  []string{},
+		},
+		{
+			query:   `INSERT INTO t (a) VALUES`,
+			columns: []string{`a`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.function, insert.Function)
+			assert.Equal(t, tt.columns, insert.Columns)
+		})
+	}
+}
+
+func TestInfile(t *testing.T) {
+	tests := []struct {
+		query  string
+		infile *Infile
+		err    string
+	}{
+		{
+			query:  `INSERT INTO t (a, b) FROM INFILE 'data.csv' FORMAT CSV`,
+			infile: &Infile{Name: `data.csv`},
+		},
+		{
+			query:  `insert into t from infile '/tmp/it''s.csv.gz' compression 'gzip' format CSV`,
+			infile: &Infile{Name: `/tmp/it's.csv.gz`, Compression: `gzip`},
+		},
+		{
+			query: `INSERT INTO t SELECT a FROM INFILE`,
+		},
+		{
+			query: `INSERT INTO t FROM INFILE data FORMAT CSV`,
+			err:   `INFILE: missing string literal`,
+		},
+		{
+			query: `INSERT INTO t FROM INFILE 'data.zst' COMPRESSION FORMAT CSV`,
+			err:   `COMPRESSION: missing string literal`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.infile, insert.Infile)
+		})
+	}
+}
+
+func TestInsertEmptyColumnList(t *testing.T) {
+	for query, empty := range map[string]bool{
+		`INSERT INTO t () VALUES ()`: true,
+		`INSERT INTO t VALUES ()`:    false,
+		`INSERT INTO t (a) VALUES`:   false,
+	} {
+		insert, err := ParseInsert(query)
+		assert.NoError(t, err)
+		assert.Equal(t, empty, insert.EmptyColumnList, query)
+	}
+}
+
+func TestNeedsExternalData(t *testing.T) {
+	for query, needs := range map[string]bool{
+		`INSERT INTO t (a) VALUES`:                        true,
+		`INSERT INTO t (a)`:                               true,
+		`INSERT INTO t (a) FORMAT Native`:                 true,
+		`INSERT INTO t (a) SETTINGS async_insert = 1;`:    true,
+		`INSERT INTO t (a) VALUES (1)`:                    false,
+		"INSERT INTO t (a) FORMAT CSV\n1\n":               false,
+		`INSERT INTO t (a) SELECT 1`:                      false,
+		`INSERT INTO t WITH 1 AS x SELECT x`:              false,
+		`INSERT INTO t FROM INFILE 'data.csv' FORMAT CSV`: false,
+	} {
+		insert, err := ParseInsert(query)
+		assert.NoError(t, err)
+		assert.Equal(t, needs, insert.NeedsExternalData(), query)
+	}
+}
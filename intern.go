@@ -0,0 +1,58 @@
+package clickhouse_go_insert_statement_parsing
+
+import "sync"
+
+// InternTable deduplicates token values across parses, so that the column
+// and table names repeated by thousands of queries share one string instead
+// of each pinning a copy or the whole query text in memory. It is safe for
+// concurrent use
+type InternTable struct {
+	mu      sync.RWMutex
+	values  map[string]string
+	maxSize int
+}
+
+// NewInternTable returns a table holding up to maxSize distinct values.
+// Once full, values not yet interned are returned as they are
+func NewInternTable(maxSize int) *InternTable {
+	return &InternTable{
+		values:  make(map[string]string),
+		maxSize: maxSize,
+	}
+}
+
+// Intern returns the interned copy of value, adding it if there is room
+func (t *InternTable) Intern(value string) string {
+	t.mu.RLock()
+	interned, ok := t.values[value]
+	t.mu.RUnlock()
+	if ok {
+		return interned
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if interned, ok := t.values[value]; ok {
+		return interned
+	}
+	if len(t.values) >= t.maxSize {
+		return value
+	}
+	// Clone so the table doesn't keep the query the value was sliced from alive
+	interned = string([]byte(value))
+	t.values[interned] = interned
+	return interned
+}
+
+// Len returns the number of interned values
+func (t *InternTable) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.values)
+}
+
+// interned reports whether tokens of the kind are worth interning. Literals
+// rarely repeat and would only fill the table
+func interned(kind TokenKind) bool {
+	return kind == TokenIdentifier || kind == TokenQuotedIdentifier || kind == TokenKeyword
+}
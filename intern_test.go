@@ -0,0 +1,53 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternTable(t *testing.T) {
+	t.Run(`shared across parses`, func(t *testing.T) {
+		intern := NewInternTable(16)
+		var values []string
+		for _, query := range []string{`INSERT INTO t (col, 'x')`, `insert into u (col, 'x')`} {
+			e := &columnExtractor{query: query, intern: intern}
+			assert.NoError(t, e.parse())
+			values = append(values, e.tokens[4].Value)
+		}
+		assert.Equal(t, []string{`col`, `col`}, values)
+		assert.Same(t, unsafe.StringData(values[0]), unsafe.StringData(values[1]))
+		// INSERT, INTO, insert, into, t, u, col and not the string
+		assert.Equal(t, 7, intern.Len())
+	})
+
+	t.Run(`doesn't keep the query alive`, func(t *testing.T) {
+		intern := NewInternTable(16)
+		query := `INSERT INTO t (col)`
+		e := &columnExtractor{query: query, intern: intern}
+		assert.NoError(t, e.parse())
+		value := e.tokens[4].Value
+		start := uintptr(unsafe.Pointer(unsafe.StringData(query)))
+		at := uintptr(unsafe.Pointer(unsafe.StringData(value)))
+		assert.False(t, at >= start && at < start+uintptr(len(query)))
+	})
+
+	t.Run(`bounded`, func(t *testing.T) {
+		intern := NewInternTable(2)
+		assert.Equal(t, `a`, intern.Intern(`a`))
+		assert.Equal(t, `b`, intern.Intern(`b`))
+		assert.Equal(t, `c`, intern.Intern(`c`))
+		assert.Equal(t, 2, intern.Len())
+	})
+
+	t.Run(`scanner`, func(t *testing.T) {
+		intern := NewInternTable(16)
+		first := NewScanner(strings.Repeat(`name `, 2))
+		first.Intern = intern
+		a, _ := first.Next()
+		b, _ := first.Next()
+		assert.Same(t, unsafe.StringData(a.Value), unsafe.StringData(b.Value))
+	})
+}
@@ -0,0 +1,64 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Position locates a byte offset of a query by its 1-based line and column.
+// Columns count runes, not bytes
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// LineIndex converts byte offsets of a query to line and column numbers in
+// O(log n). The table of line starts is built once, on first use, so an index
+// can be shared by every consumer of the same query
+type LineIndex struct {
+	query string
+	once  sync.Once
+	lines []int // byte offsets at which each line starts
+}
+
+// NewLineIndex returns a LineIndex for query
+func NewLineIndex(query string) *LineIndex {
+	return &LineIndex{
+		query: query,
+	}
+}
+
+// Position returns the line and column of offset, clamped to the query
+func (x *LineIndex) Position(offset int) Position {
+	x.once.Do(x.build)
+	offset = max(0, min(offset, len(x.query)))
+	// Index of the last line starting at or before offset
+	line := sort.Search(len(x.lines), func(i int) bool { return x.lines[i] > offset }) - 1
+	return Position{
+		Offset: offset,
+		Line:   line + 1,
+		Column: utf8.RuneCountInString(x.query[x.lines[line]:offset]) + 1,
+	}
+}
+
+// LineCount returns the number of lines of the query
+func (x *LineIndex) LineCount() int {
+	x.once.Do(x.build)
+	return len(x.lines)
+}
+
+func (x *LineIndex) build() {
+	x.lines = make([]int, 1, 16)
+	for i := 0; i < len(x.query); i++ {
+		if x.query[i] == '\n' {
+			x.lines = append(x.lines, i+1)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineIndex(t *testing.T) {
+	query := "INSERT INTO t\n(имя,\r\n b)\n"
+	x := NewLineIndex(query)
+
+	tests := []struct {
+		offset int
+		want   Position
+	}{
+		{0, Position{Offset: 0, Line: 1, Column: 1}},
+		{12, Position{Offset: 12, Line: 1, Column: 13}},
+		{13, Position{Offset: 13, Line: 1, Column: 14}},
+		{14, Position{Offset: 14, Line: 2, Column: 1}},
+		// after the three two byte runes of имя
+		{21, Position{Offset: 21, Line: 2, Column: 5}},
+		{25, Position{Offset: 25, Line: 3, Column: 2}},
+		{len(query), Position{Offset: len(query), Line: 4, Column: 1}},
+		{-5, Position{Offset: 0, Line: 1, Column: 1}},
+		{1000, Position{Offset: len(query), Line: 4, Column: 1}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, x.Position(tt.offset), "offset %d", tt.offset)
+	}
+	assert.Equal(t, 4, x.LineCount())
+	assert.Equal(t, `2:5`, x.Position(21).String())
+}
+
+func TestLineIndexIsBuiltOnce(t *testing.T) {
+	x := NewLineIndex("a\nb\nc")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, 3, x.Position(4).Line)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, x.LineCount())
+}
+
+func TestEmptyLineIndex(t *testing.T) {
+	x := NewLineIndex("")
+	assert.Equal(t, Position{Line: 1, Column: 1}, x.Position(0))
+	assert.Equal(t, 1, x.LineCount())
+}
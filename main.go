@@ -1,10 +1,16 @@
-package main
+package clickhouse_go_insert_statement_parsing
 
 import (
 	"errors"
 	"fmt"
-	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // Purpose is to reliably extract columns names
@@ -19,10 +25,116 @@ import (
 type columnExtractor struct {
 	query     string
 	currToken []rune
-	tokens    []string
+	tokens    []Token
 	byteIndex int
+
+	// keepComments surfaces -- and /* */ comments as tokens instead of skipping them
+	keepComments bool
+	// dialect sets the quoting, escaping and comment rules, ClickHouse if nil
+	dialect Dialect
+	// extraIdentifierChars extends isIdentifierRune with further runes
+	// allowed in unquoted identifiers, e.g. identifierCharsOf("$")
+	extraIdentifierChars func(rune) bool
+	// normalizeIdentifiers applies Unicode NFC normalization to extracted
+	// columns and identifier comparisons, so that visually identical names
+	// written in different normalization forms match
+	normalizeIdentifiers bool
+
+	// byteOrderMarkAsSpace treats U+FEFF anywhere in the query as whitespace
+	// rather than only skipping it at the start
+	byteOrderMarkAsSpace bool
+	// invalidUTF8 decides whether invalid UTF-8 in the query fails parse or is
+	// replaced by U+FFFD in the tokens
+	invalidUTF8 invalidUTF8Policy
+	// rejectControlChars fails parse on NUL and other C0 control characters
+	// outside string literals, a common sign of corrupted or malicious input
+	rejectControlChars bool
+	// emptyColumnList decides whether INSERT INTO t () is accepted silently,
+	// accepted with a warning or rejected by parse
+	emptyColumnList emptyColumnListPolicy
+	// rejectTrailingComma fails parse on a comma closing the column list, as
+	// in INSERT INTO t (a, b, ), which is otherwise ignored
+	rejectTrailingComma bool
+	// allowMissingInto accepts INSERT t (a) from legacy generators with a
+	// warning, instead of failing parse
+	allowMissingInto bool
+	// intern, when set, deduplicates identifier and keyword values across parses
+	intern *InternTable
+	// warnings collects problems found by parse that don't fail it
+	warnings []error
+
+	// deadline stops parse once passed, unless zero. now defaults to time.Now
+	deadline time.Time
+	now      func() time.Time
+
+	// placeholders counts the ? placeholders of the current statement, or
+	// holds the highest $N of the Postgres dialect
+	placeholders int
+	// offsets holds the byte offset in query at which each token starts
+	offsets []int
+	// ends holds the byte offset in query past each token. It is kept apart
+	// from the token values, which invalid UTF-8 replaced by U+FFFD makes
+	// longer than the source text they come from
+	ends       []int
+	tokenStart int
+	// lines converts offsets to line and column numbers, built on first use
+	lines *LineIndex
+	// formatData is the offset of the data following INSERT ... FORMAT name,
+	// which parse leaves untokenized, or -1
+	formatData int
+}
+
+// emptyColumnListPolicy selects how parse treats an explicitly empty column
+// list, which is almost always a bug in whatever generated the query
+type emptyColumnListPolicy int
+
+const (
+	allowEmptyColumnList emptyColumnListPolicy = iota
+	warnEmptyColumnList
+	rejectEmptyColumnList
+)
+
+// ErrDeadlineExceeded is returned with partial results when parsing takes
+// longer than the caller allowed
+var ErrDeadlineExceeded = errors.New("parse deadline exceeded")
+
+// deadlineCheckInterval is the number of runes scanned between checks of the
+// deadline, to keep the cost of reading the clock negligible
+const deadlineCheckInterval = 64
+
+// invalidUTF8Policy selects how parse treats bytes that aren't valid UTF-8
+type invalidUTF8Policy int
+
+const (
+	replaceInvalidUTF8 invalidUTF8Policy = iota
+	rejectInvalidUTF8
+)
+
+// ErrEmptyColumnList reports an INSERT with an explicitly empty column list
+var ErrEmptyColumnList = errors.New("empty column list")
+
+// ErrTrailingComma reports a comma right before the parenthesis closing a
+// column list
+var ErrTrailingComma = errors.New("trailing comma in column list")
+
+// SyntaxError is a tokenisation error along with the position of the token
+// it was found in
+type SyntaxError struct {
+	Pos Position
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Pos.String() + ": " + e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
 }
 
+// ErrMissingInto reports an INSERT whose table isn't preceded by INTO
+var ErrMissingInto = errors.New("missing INTO after INSERT")
+
 // Pre-allocate a map for faster character lookups
 var validIdentifierChars = make(map[rune]bool)
 
@@ -33,139 +145,905 @@ func init() {
 	}
 }
 
-func (e *columnExtractor) parseUntilClosingBackTick() ([]rune, error) {
-	if len(e.query) == e.byteIndex {
-		return nil, fmt.Errorf("unclosed backtick quote")
+// identifierCharsOf allows the runes of chars in unquoted identifiers, e.g. "$"
+func identifierCharsOf(chars string) func(rune) bool {
+	return func(r rune) bool {
+		return strings.ContainsRune(chars, r)
 	}
-	runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
-	e.byteIndex += width
-	e.currToken = append(e.currToken, runeValue)
-	if runeValue == '`' && e.currToken[len(e.currToken)-2] != '\\' {
-		return e.currToken, nil
-	}
-	return e.parseUntilClosingBackTick()
 }
 
-func (e *columnExtractor) parseUntilClosingSingleQuote() ([]rune, error) {
-	if len(e.query) == e.byteIndex {
-		return nil, fmt.Errorf("unclosed single quote")
+// isIdentifierRune reports whether r may appear in an unquoted identifier.
+// Like ClickHouse, non-ASCII letters, digits and combining marks are allowed
+// so that e.g. Cyrillic or CJK column names need not be quoted
+func isIdentifierRune(r rune) bool {
+	if r < utf8.RuneSelf {
+		return validIdentifierChars[r]
 	}
-	runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
-	e.byteIndex += width
-	e.currToken = append(e.currToken, runeValue)
-	if runeValue == '\'' && e.currToken[len(e.currToken)-2] != '\\' {
-		return e.currToken, nil
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func (e *columnExtractor) isIdentifierChar(r rune) bool {
+	return isIdentifierRune(r) || (e.extraIdentifierChars != nil && e.extraIdentifierChars(r))
+}
+
+// parseUntilClosingQuote appends runes to currToken up to and including the
+// closing quote. Depending on the escape style a quote is escaped by an odd
+// number of backslashes before it or by doubling it
+func (e *columnExtractor) parseUntilClosingQuote(quote rune, escapes EscapeStyle) ([]rune, error) {
+	escaped := false
+	for e.byteIndex < len(e.query) {
+		runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
+		e.byteIndex += width
+		e.currToken = append(e.currToken, runeValue)
+		switch {
+		case runeValue == quote && !escaped:
+			if escapes&DoubledQuoteEscapes != 0 && strings.HasPrefix(e.query[e.byteIndex:], string(quote)) {
+				e.byteIndex += width
+				e.currToken = append(e.currToken, quote)
+				continue
+			}
+			return e.currToken, nil
+		case runeValue == '\\' && escapes&BackslashEscapes != 0:
+			escaped = !escaped
+		default:
+			escaped = false
+		}
 	}
-	return e.parseUntilClosingSingleQuote()
+	return nil, fmt.Errorf("unclosed %s quote", quoteName(quote))
 }
 
 func (e *columnExtractor) parseNonQuotedIdentifier() ([]rune, error) {
-	if len(e.query) == e.byteIndex {
-		return e.currToken, nil
+	for e.byteIndex < len(e.query) {
+		runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
+		if !e.isIdentifierChar(runeValue) {
+			break
+		}
+		e.byteIndex += width
+		e.currToken = append(e.currToken, runeValue)
 	}
-	runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
-	if !validIdentifierChars[runeValue] {
-		return e.currToken, nil
+	return e.currToken, nil
+}
+
+// parseLineComment advances past a line comment up to and including the end of
+// line and returns the comment without the line break
+func (e *columnExtractor) parseLineComment(start int) string {
+	for e.byteIndex < len(e.query) {
+		runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
+		e.byteIndex += width
+		if runeValue == '\n' {
+			return e.query[start : e.byteIndex-width]
+		}
 	}
-	e.byteIndex += width
-	e.currToken = append(e.currToken, runeValue)
-	return e.parseNonQuotedIdentifier()
+	return e.query[start:]
 }
 
-func isSpace(r rune) bool {
-	return r == ' ' || r == '\t' || r == '\n'
+// parseBlockComment advances past a /* */ comment, ClickHouse allows these to nest
+func (e *columnExtractor) parseBlockComment(start int, nested bool) (string, error) {
+	depth := 1
+	for e.byteIndex < len(e.query) {
+		switch {
+		case nested && strings.HasPrefix(e.query[e.byteIndex:], "/*"):
+			depth++
+			e.byteIndex += 2
+		case strings.HasPrefix(e.query[e.byteIndex:], "*/"):
+			depth--
+			e.byteIndex += 2
+			if depth == 0 {
+				return e.query[start:e.byteIndex], nil
+			}
+		default:
+			_, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
+			e.byteIndex += width
+		}
+	}
+	return e.query[start:], fmt.Errorf("unclosed block comment")
 }
 
-func (e *columnExtractor) parse() error {
-	// Pre-allocate tokens slice with a reasonable capacity
-	e.tokens = make([]string, 0, len(e.query)/4) // Estimate 4 chars per token
-	e.currToken = make([]rune, 0, 32)            // Pre-allocate for typical token size
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
 
-	errs := make([]error, 0, 4) // Pre-allocate error slice
+func isHexDigit(r rune) bool {
+	return isDigit(r) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
 
+// acceptRunes advances past all consecutive runes satisfying accept and
+// reports whether any were consumed
+func (e *columnExtractor) acceptRunes(accept func(rune) bool) bool {
+	start := e.byteIndex
 	for e.byteIndex < len(e.query) {
 		runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
+		if !accept(runeValue) {
+			break
+		}
 		e.byteIndex += width
+	}
+	return e.byteIndex > start
+}
 
-		if isSpace(runeValue) {
-			continue
-		}
+// acceptPrefix advances past prefix if the remaining query starts with it
+func (e *columnExtractor) acceptPrefix(prefix string) bool {
+	if !strings.HasPrefix(e.query[e.byteIndex:], prefix) {
+		return false
+	}
+	e.byteIndex += len(prefix)
+	return true
+}
 
-		switch runeValue {
-		case '`':
-			e.currToken = append(e.currToken[:0], runeValue) // Reset slice
-			token, err := e.parseUntilClosingBackTick()
-			if err != nil {
-				errs = append(errs, err)
+// parseNumber scans an integer, decimal, scientific, hex (0x1F) or binary
+// (0b101) literal starting at start. Like ClickHouse, a number running
+// straight into identifier characters, e.g. 1st_place, is an identifier and
+// only integers follow a dot so that t.1.2 accesses nested tuple elements
+func (e *columnExtractor) parseNumber(start int) Token {
+	afterDot := len(e.tokens) > 0 && e.tokens[len(e.tokens)-1] == Token{Kind: TokenPunctuation, Value: "."}
+	e.byteIndex = start
+	switch {
+	case e.acceptPrefix("0x") || e.acceptPrefix("0X"):
+		if !e.acceptRunes(isHexDigit) {
+			e.byteIndex = start + 1
+		}
+	case e.acceptPrefix("0b") || e.acceptPrefix("0B"):
+		if !e.acceptRunes(func(r rune) bool { return r == '0' || r == '1' }) {
+			e.byteIndex = start + 1
+		}
+	case afterDot:
+		e.acceptRunes(isDigit)
+	default:
+		e.acceptRunes(isDigit)
+		if e.acceptPrefix(".") {
+			e.acceptRunes(isDigit)
+		}
+		if exponent := e.byteIndex; e.acceptPrefix("e") || e.acceptPrefix("E") {
+			if !e.acceptPrefix("+") {
+				e.acceptPrefix("-")
 			}
-			e.tokens = append(e.tokens, string(token))
-		case '\'':
-			e.currToken = append(e.currToken[:0], runeValue) // Reset slice
-			token, err := e.parseUntilClosingSingleQuote()
-			if err != nil {
-				errs = append(errs, err)
-			}
-			e.tokens = append(e.tokens, string(token))
-		case '(', ')', ',', '.':
-			e.tokens = append(e.tokens, string(runeValue))
-		default:
-			if validIdentifierChars[runeValue] {
-				e.currToken = append(e.currToken[:0], runeValue) // Reset slice
-				token, err := e.parseNonQuotedIdentifier()
-				if err != nil {
-					errs = append(errs, err)
-				}
-				e.tokens = append(e.tokens, string(token))
-			} else {
-				errs = append(errs, fmt.Errorf(`unexpected rune: %s`, string(runeValue)))
+			if !e.acceptRunes(isDigit) {
+				e.byteIndex = exponent
 			}
 		}
 	}
-	return errors.Join(errs...)
+	if e.acceptRunes(e.isIdentifierChar) {
+		return Token{Kind: TokenIdentifier, Value: e.query[start:e.byteIndex]}
+	}
+	return Token{Kind: TokenNumber, Value: e.query[start:e.byteIndex]}
 }
 
-func (e *columnExtractor) columns() []string {
-	// Pre-allocate columns slice with a reasonable capacity
-	columns := make([]string, 0, len(e.tokens)/2)
-	openingParenthesisObserved := false
-
-	for _, token := range e.tokens {
-		switch token {
-		case "(":
-			openingParenthesisObserved = true
-		case ")":
-			return columns
-		default:
-			if openingParenthesisObserved && token != "," {
-				columns = append(columns, token)
+// isParameter reports whether the brace at start opens a {name:Type} query
+// parameter, i.e. is followed by an identifier, a colon and a type name.
+// Other braces, as those of the map literal {'a': 1}, are punctuation
+func (e *columnExtractor) isParameter(start int) bool {
+	rest := strings.TrimLeftFunc(e.query[start+1:], isSpace)
+	name := identifierPrefix(rest)
+	if name == 0 {
+		return false
+	}
+	rest = strings.TrimLeftFunc(rest[name:], isSpace)
+	if !strings.HasPrefix(rest, ":") {
+		return false
+	}
+	return identifierPrefix(strings.TrimLeftFunc(rest[1:], isSpace)) > 0
+}
+
+// identifierPrefix returns the length of the unquoted identifier s starts
+// with, 0 if it doesn't start with one or starts with a digit
+func identifierPrefix(s string) int {
+	end := strings.IndexFunc(s, func(r rune) bool { return !isIdentifierRune(r) })
+	if end < 0 {
+		end = len(s)
+	}
+	if end > 0 && isDigit(rune(s[0])) {
+		return 0
+	}
+	return end
+}
+
+// parseParameter scans a {name:Type} query parameter up to the closing brace,
+// which may not appear in quotes inside the type, e.g. Enum('}' = 1)
+func (e *columnExtractor) parseParameter(start int) (Token, error) {
+	quoted, escaped := false, false
+	for e.byteIndex < len(e.query) {
+		b := e.query[e.byteIndex]
+		e.byteIndex++
+		switch {
+		case escaped:
+			escaped = false
+		case b == '\\' && quoted:
+			escaped = true
+		case b == '\'':
+			quoted = !quoted
+		case b == '}' && !quoted:
+			token := Token{Kind: TokenParameter, Value: e.query[start:e.byteIndex]}
+			if _, ok := token.Parameter(); !ok {
+				return token, fmt.Errorf("invalid query parameter: %s", token.Value)
 			}
+			return token, nil
 		}
 	}
-	return columns
+	return Token{Kind: TokenParameter, Value: e.query[start:]}, fmt.Errorf("unclosed query parameter")
+}
+
+// heredocDelimiter returns the opening $tag$ delimiter of a dollar-quoted
+// string starting at start, or "" if there is none
+func (e *columnExtractor) heredocDelimiter(start int) string {
+	end := start + 1
+	for end < len(e.query) && e.query[end] < utf8.RuneSelf && validIdentifierChars[rune(e.query[end])] {
+		end++
+	}
+	if end == len(e.query) || e.query[end] != '$' {
+		return ""
+	}
+	return e.query[start : end+1]
 }
 
-func main() {
-	queries := []string{
-		"INSERT INTO `DATA (BASE`.`A (TABLE)` ( `column \\`one`, columnTwo, 'col)umn\\' (three ')",
-		"INSERT INTO db.table (`ITEM`, `QTY (MT)`)",
+// parseHeredoc scans a $tag$ ... $tag$ string, its content is taken verbatim
+func (e *columnExtractor) parseHeredoc(start int, delimiter string) (string, error) {
+	e.byteIndex = start + len(delimiter)
+	end := strings.Index(e.query[e.byteIndex:], delimiter)
+	if end < 0 {
+		e.byteIndex = len(e.query)
+		return e.query[start:], fmt.Errorf("unclosed dollar-quoted string")
 	}
+	e.byteIndex += end + len(delimiter)
+	return e.query[start:e.byteIndex], nil
+}
 
-	for _, query := range queries {
-		extractor := &columnExtractor{
-			query: query,
+// operators lists the two rune operators, single rune operators are matched
+// in parse directly
+var operators = []string{"<=", ">=", "!=", "<>", "==", "||", "->", "::"}
+
+// parseOperator scans the longest operator starting at start
+func (e *columnExtractor) parseOperator(start int) (Token, bool) {
+	for _, operator := range operators {
+		if strings.HasPrefix(e.query[start:], operator) {
+			e.byteIndex = start + len(operator)
+			return Token{Kind: TokenOperator, Value: operator}, true
+		}
+	}
+	switch e.query[start] {
+	case '=', '<', '>', '+', '-', '*', '/', '%':
+		e.byteIndex = start + 1
+		return Token{Kind: TokenOperator, Value: e.query[start:e.byteIndex]}, true
+	}
+	return Token{}, false
+}
+
+const byteOrderMark = '\ufeff'
+
+// isSpace reports whether r separates tokens. Besides the usual ASCII
+// whitespace this covers the carriage returns of Windows line endings and the
+// non-breaking spaces that queries copied from documentation often contain
+func isSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\r', '\v', '\f', '\u00a0':
+		return true
+	}
+	return false
+}
+
+// parse tokenises the query from byteIndex up to and including the semicolon
+// terminating the current statement, or up to the end of the query. Calling
+// it again continues with the next statement
+func (e *columnExtractor) parse() error {
+	// Pre-allocate the tokens of the current statement, estimating 4 chars per
+	// token up to the next semicolon rather than over the whole script
+	size := len(e.query) - e.byteIndex
+	if i := strings.IndexByte(e.query[e.byteIndex:], ';'); i >= 0 {
+		size = i + 1
+	}
+	e.tokens = make([]Token, 0, size/4+1)
+	e.offsets = make([]int, 0, cap(e.tokens))
+	e.ends = make([]int, 0, cap(e.tokens))
+	e.warnings = nil
+	e.placeholders = 0
+	e.formatData = -1
+
+	errs := make([]error, 0, 4) // Pre-allocate error slice
+
+	if e.invalidUTF8 == rejectInvalidUTF8 {
+		if err := e.validateUTF8(); err != nil {
+			return err
+		}
+	}
+
+	for steps := 0; e.byteIndex < len(e.query); steps++ {
+		if steps%deadlineCheckInterval == 0 && e.deadlineExceeded() {
+			errs = append(errs, ErrDeadlineExceeded)
+			break
+		}
+		token, ok, err := e.scan()
+		err = e.syntaxError(err)
+		if e.rejectControlChars && token.Kind != TokenString {
+			if err := e.validateControlChars(); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
 		}
-		err := extractor.parse()
 		if err != nil {
-			panic(err)
+			errs = append(errs, err)
+		}
+		if !ok {
+			continue
+		}
+		e.emit(token)
+		if token == statementTerminator {
+			break
+		}
+		// A ; right after the format name ends a statement without data
+		if e.atFormatName() && !strings.HasPrefix(strings.TrimLeftFunc(e.query[e.byteIndex:], isSpace), ";") {
+			e.formatData = e.skipToFormatData()
+			e.byteIndex = len(e.query)
+			break
+		}
+	}
+
+	if e.hasMissingInto() {
+		if e.allowMissingInto {
+			e.warnings = append(e.warnings, ErrMissingInto)
+		} else {
+			errs = append(errs, ErrMissingInto)
+		}
+	}
+	if e.emptyColumnList != allowEmptyColumnList && e.hasEmptyColumnList() {
+		if e.emptyColumnList == rejectEmptyColumnList {
+			errs = append(errs, ErrEmptyColumnList)
+		} else {
+			e.warnings = append(e.warnings, ErrEmptyColumnList)
+		}
+	}
+	if e.rejectTrailingComma {
+		if i := e.trailingComma(); i >= 0 {
+			errs = append(errs, &SyntaxError{Pos: e.tokenPosition(i), Err: ErrTrailingComma})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// atFormatName reports whether the last token is the format name of an
+// INSERT ... FORMAT, after which the query holds data rather than SQL
+func (e *columnExtractor) atFormatName() bool {
+	n := len(e.tokens)
+	if n < 2 || !e.tokens[n-2].IsKeyword("FORMAT") {
+		return false
+	}
+	name := e.tokens[n-1]
+	if !name.isIdentifier() && name.Kind != TokenKeyword {
+		return false
+	}
+	first := e.skipComments(0)
+	return e.tokens[first].IsKeyword("INSERT") && e.findClause(first, "SELECT") < 0
+}
+
+// skipToFormatData returns the offset at which the data following a format
+// name starts. As in ClickHouse, that is after the first line break, if the
+// rest of the line is blank, or otherwise after the whitespace
+func (e *columnExtractor) skipToFormatData() int {
+	i := e.byteIndex
+	for i < len(e.query) {
+		r, width := utf8.DecodeRuneInString(e.query[i:])
+		if !isSpace(r) {
+			break
+		}
+		i += width
+		if r == '\n' {
+			break
+		}
+	}
+	return i
+}
+
+// scan advances past the next rune and whatever token it starts, reporting
+// whether that produced a token. Whitespace and, unless keepComments is set,
+// comments don't. Errors may come along with a partially scanned token
+func (e *columnExtractor) scan() (Token, bool, error) {
+	if e.currToken == nil {
+		e.currToken = make([]rune, 0, 32) // Pre-allocate for typical token size
+	}
+	e.tokenStart = e.byteIndex
+	runeValue, width := utf8.DecodeRuneInString(e.query[e.byteIndex:])
+	e.byteIndex += width
+
+	if isSpace(runeValue) {
+		return Token{}, false, nil
+	}
+	// Byte order marks are left at the start of files by some editors
+	if runeValue == byteOrderMark && (e.tokenStart == 0 || e.byteOrderMarkAsSpace) {
+		return Token{}, false, nil
+	}
+
+	dialect := e.dialectOrDefault()
+	if kind, ok := dialect.QuoteKind(runeValue); ok {
+		e.currToken = append(e.currToken[:0], runeValue) // Reset slice
+		token, err := e.parseUntilClosingQuote(runeValue, quoteEscapes(dialect, runeValue))
+		return Token{Kind: kind, Value: string(token)}, true, err
+	}
+	if n := dialect.LineComment(e.query[e.tokenStart:]); n > 0 {
+		comment := e.parseLineComment(e.tokenStart)
+		return Token{Kind: TokenComment, Value: comment}, e.keepComments, nil
+	}
+
+	switch runeValue {
+	case '(', ')', '[', ']', ',', '.':
+		return Token{Kind: TokenPunctuation, Value: string(runeValue)}, true, nil
+	case '?':
+		e.placeholders++
+		return Token{Kind: TokenPlaceholder, Value: "?", Ordinal: e.placeholders}, true, nil
+	case '@':
+		if e.acceptRunes(e.isIdentifierChar) {
+			return Token{Kind: TokenNamedPlaceholder, Value: e.query[e.tokenStart:e.byteIndex]}, true, nil
+		}
+	case '{':
+		if dialectFeatures(dialect)&ServerParameters != 0 && e.isParameter(e.tokenStart) {
+			token, err := e.parseParameter(e.tokenStart)
+			return token, true, err
+		}
+		return Token{Kind: TokenPunctuation, Value: "{"}, true, nil
+	case '}':
+		return Token{Kind: TokenPunctuation, Value: "}"}, true, nil
+	case ';':
+		e.placeholders = 0
+		return statementTerminator, true, nil
+	case '-':
+		token, _ := e.parseOperator(e.byteIndex - width)
+		return token, true, nil
+	case ':':
+		if token, ok := e.parseOperator(e.byteIndex - width); ok {
+			return token, true, nil
+		}
+		return Token{Kind: TokenPunctuation, Value: ":"}, true, nil
+	case '/':
+		if strings.HasPrefix(e.query[e.byteIndex:], "*") {
+			e.byteIndex++
+			comment, err := e.parseBlockComment(e.byteIndex-2, dialect.NestedComments())
+			return Token{Kind: TokenComment, Value: comment}, e.keepComments, err
+		}
+		return Token{Kind: TokenOperator, Value: "/"}, true, nil
+	case '=', '<', '>', '!', '+', '*', '%', '|':
+		if token, ok := e.parseOperator(e.byteIndex - width); ok {
+			return token, true, nil
+		}
+	case '$':
+		if delimiter := e.heredocDelimiter(e.byteIndex - width); delimiter != "" {
+			token, err := e.parseHeredoc(e.byteIndex-width, delimiter)
+			return Token{Kind: TokenString, Value: token}, true, err
+		}
+		if dialectFeatures(dialect)&NumberedPlaceholders != 0 && e.acceptRunes(isDigit) {
+			number, _ := strconv.Atoi(e.query[e.tokenStart+1 : e.byteIndex])
+			e.placeholders = max(e.placeholders, number)
+			return Token{Kind: TokenPlaceholder, Value: e.query[e.tokenStart:e.byteIndex], Ordinal: number}, true, nil
+		}
+		if e.isIdentifierChar(runeValue) {
+			e.currToken = append(e.currToken[:0], runeValue) // Reset slice
+			token, err := e.parseNonQuotedIdentifier()
+			return Token{Kind: TokenIdentifier, Value: string(token)}, true, err
+		}
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return e.parseNumber(e.byteIndex - width), true, nil
+	case 'E', 'e':
+		if dialectFeatures(dialect)&EscapeStrings != 0 && strings.HasPrefix(e.query[e.byteIndex:], "'") {
+			e.byteIndex++
+			e.currToken = append(e.currToken[:0], runeValue, '\'')
+			token, err := e.parseUntilClosingQuote('\'', BackslashEscapes|DoubledQuoteEscapes)
+			return Token{Kind: TokenString, Value: string(token)}, true, err
+		}
+		e.currToken = append(e.currToken[:0], runeValue) // Reset slice
+		token, err := e.parseNonQuotedIdentifier()
+		return e.identifierOrKeyword(string(token)), true, err
+	default:
+		if e.isIdentifierChar(runeValue) {
+			e.currToken = append(e.currToken[:0], runeValue) // Reset slice
+			token, err := e.parseNonQuotedIdentifier()
+			return e.identifierOrKeyword(string(token)), true, err
+		}
+	}
+	return Token{}, false, fmt.Errorf(`unexpected rune: %s`, string(runeValue))
+}
+
+// identifierOrKeyword classifies a bare word. Words after a dot, as in
+// db.values, name a table or column and are never keywords
+func (e *columnExtractor) identifierOrKeyword(word string) Token {
+	afterDot := len(e.tokens) > 0 && e.tokens[len(e.tokens)-1] == Token{Kind: TokenPunctuation, Value: "."}
+	if !afterDot && isKeyword(word) {
+		return Token{Kind: TokenKeyword, Value: word}
+	}
+	return Token{Kind: TokenIdentifier, Value: word}
+}
+
+func (e *columnExtractor) dialectOrDefault() Dialect {
+	if e.dialect == nil {
+		return ClickHouse
+	}
+	return e.dialect
+}
+
+func (e *columnExtractor) deadlineExceeded() bool {
+	if e.deadline.IsZero() {
+		return false
+	}
+	now := time.Now
+	if e.now != nil {
+		now = e.now
+	}
+	return !now().Before(e.deadline)
+}
+
+// ParseWithDeadline extracts the columns of the first statement of query,
+// giving up once d has passed. It then returns the columns extracted so far
+// along with ErrDeadlineExceeded, for callers preferring partial metadata
+// over blocking
+func ParseWithDeadline(query string, d time.Duration) ([]string, error) {
+	e := &columnExtractor{
+		query:    query,
+		deadline: time.Now().Add(d),
+	}
+	err := e.parse()
+	return e.columns(), err
+}
+
+// isControl reports whether r is a C0 control character other than the ones
+// accepted as whitespace
+func isControl(r rune) bool {
+	return r < 0x20 && !isSpace(r)
+}
+
+// validateControlChars reports the position of the first control character
+// in the last scanned rune or token
+func (e *columnExtractor) validateControlChars() error {
+	for i, r := range e.query[e.tokenStart:e.byteIndex] {
+		if isControl(r) {
+			offset := e.tokenStart + i
+			return fmt.Errorf("control character %U at offset %d (%s)", r, offset, NewLineIndex(e.query).Position(offset))
 		}
-		columns := extractor.columns()
-		fmt.Println("parser based", query, columns)
+	}
+	return nil
+}
+
+// validateUTF8 reports the position of the first invalid UTF-8 sequence in
+// the rest of the query
+func (e *columnExtractor) validateUTF8() error {
+	for i := e.byteIndex; i < len(e.query); {
+		r, width := utf8.DecodeRuneInString(e.query[i:])
+		if r == utf8.RuneError && width == 1 {
+			return fmt.Errorf("invalid UTF-8 at offset %d (%s)", i, NewLineIndex(e.query).Position(i))
+		}
+		i += width
+	}
+	return nil
+}
+
+// toValidUTF8 replaces every byte that isn't part of a valid UTF-8
+// sequence with U+FFFD, as decoding the query rune by rune does
+func toValidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// position returns the line and column of a byte offset of the query
+func (e *columnExtractor) position(offset int) Position {
+	if e.lines == nil {
+		e.lines = NewLineIndex(e.query)
+	}
+	return e.lines.Position(offset)
+}
+
+// tokenPosition returns the position of the first rune of the i-th token
+func (e *columnExtractor) tokenPosition(i int) Position {
+	return e.position(e.offsets[i])
+}
+
+// tokenEnd returns the byte offset in the query past the i-th token
+func (e *columnExtractor) tokenEnd(i int) int {
+	return e.ends[i]
+}
+
+// syntaxError attaches the position of the token being scanned to err
+func (e *columnExtractor) syntaxError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &SyntaxError{Pos: e.position(e.tokenStart), Err: err}
+}
 
-		matches := extractInsertColumnsMatch.FindStringSubmatch(query)
+// emit appends a token scanned from tokenStart up to byteIndex
+func (e *columnExtractor) emit(token Token) {
+	end := e.byteIndex
+	if token.Kind == TokenComment {
+		// Comments are sliced out of the query, line comments without the
+		// line break scanned past
+		end = e.tokenStart + len(token.Value)
+	}
+	// Tokens sliced straight out of the query may carry invalid UTF-8
+	token.Value = toValidUTF8(token.Value)
+	if e.intern != nil && interned(token.Kind) {
+		token.Value = e.intern.Intern(token.Value)
+	}
+	e.tokens = append(e.tokens, token)
+	e.offsets = append(e.offsets, e.tokenStart)
+	e.ends = append(e.ends, end)
+}
+
+// statementTerminator ends a statement, parse stops after it
+var statementTerminator = Token{Kind: TokenPunctuation, Value: ";"}
+
+// statements tokenises every statement of a semicolon separated script,
+// leaving out the terminators and empty statements
+func (e *columnExtractor) statements() ([][]Token, error) {
+	statements := make([][]Token, 0, 1)
+	errs := make([]error, 0)
+	for e.byteIndex < len(e.query) {
+		if err := e.parse(); err != nil {
+			errs = append(errs, err)
+		}
+		tokens := e.tokens
+		if len(tokens) > 0 && tokens[len(tokens)-1] == statementTerminator {
+			tokens = tokens[:len(tokens)-1]
+		}
+		if len(tokens) > 0 {
+			statements = append(statements, slices.Clone(tokens))
+		}
+	}
+	return statements, errors.Join(errs...)
+}
 
-		fmt.Println("regexp based", query, matches[1])
+// PlaceholderCount returns the number of ? placeholders in the first
+// statement of query, i.e. the number of arguments it must be bound with
+func PlaceholderCount(query string) (int, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return 0, err
 	}
+	return e.placeholders, nil
 }
 
-// copied from clickhouse-go source code
-var extractInsertColumnsMatch = regexp.MustCompile(`(?si)INSERT INTO .+\s\((?P<Columns>.+)\)$`)
+// Parameters returns the {name:Type} query parameters of every statement of
+// query in order of appearance
+func Parameters(query string) ([]Parameter, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	tokens, err := e.allTokens()
+	if err != nil {
+		return nil, err
+	}
+	parameters := make([]Parameter, 0)
+	for _, token := range tokens {
+		if parameter, ok := token.Parameter(); ok {
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters, nil
+}
+
+// allTokens tokenises every statement of the query, keeping the terminators
+func (e *columnExtractor) allTokens() ([]Token, error) {
+	tokens := make([]Token, 0, len(e.query)/4)
+	errs := make([]error, 0)
+	for e.byteIndex < len(e.query) {
+		if err := e.parse(); err != nil {
+			errs = append(errs, err)
+		}
+		tokens = append(tokens, e.tokens...)
+	}
+	return tokens, errors.Join(errs...)
+}
+
+// columnList returns the indexes of the parentheses opening and closing the
+// column list of an INSERT, skipping over nested parentheses. An unclosed
+// list extends to the end of the tokens
+func (e *columnExtractor) columnList() (int, int, bool) {
+	open := e.columnListStart()
+	if open < 0 {
+		return -1, len(e.tokens), false
+	}
+	if end := e.matchingParenthesis(open); end >= 0 {
+		return open, end, true
+	}
+	return open, len(e.tokens), true
+}
+
+// columnListStart returns the index of the parenthesis opening the column
+// list, which has to follow the table right after INSERT INTO, or the
+// arguments of INSERT INTO FUNCTION. It returns -1 without a column list
+func (e *columnExtractor) columnListStart() int {
+	i := e.targetEnd()
+	if i >= 0 && i < len(e.tokens) && e.tokens[i].Value == "(" {
+		return i
+	}
+	return -1
+}
+
+// targetEnd returns the index of the first token after the table or table
+// function an INSERT writes to, or -1 if the statement isn't an INSERT
+func (e *columnExtractor) targetEnd() int {
+	i := e.skipComments(0)
+	if i == len(e.tokens) || !e.tokens[i].IsKeyword("INSERT") {
+		return -1
+	}
+	i = skipTableKeyword(e.tokens, e.skipKeyword(e.skipComments(i+1), "INTO"))
+	if i < len(e.tokens) && e.tokens[i].IsKeyword("FUNCTION") {
+		i = e.skipName(e.skipComments(i + 1))
+		if i < len(e.tokens) && e.tokens[i].Value == "(" {
+			i = e.skipComments(e.skipParentheses(i))
+		}
+		return i
+	}
+	return e.skipName(i)
+}
+
+// skipComments returns the index of the first token from i on that isn't a
+// comment
+func (e *columnExtractor) skipComments(i int) int {
+	for i < len(e.tokens) && e.tokens[i].Kind == TokenComment {
+		i++
+	}
+	return i
+}
+
+// skipKeyword advances past keyword and the comments following it if the
+// token at i is keyword
+func (e *columnExtractor) skipKeyword(i int, keyword string) int {
+	if i < len(e.tokens) && e.tokens[i].IsKeyword(keyword) {
+		return e.skipComments(i + 1)
+	}
+	return i
+}
+
+// skipName advances past a possibly qualified name such as db.t, and the
+// comments following it. A {name:Identifier} parameter counts as a name
+func (e *columnExtractor) skipName(i int) int {
+	for i < len(e.tokens) {
+		token := e.tokens[i]
+		if !token.isIdentifier() && token.Kind != TokenKeyword && token.Kind != TokenParameter {
+			return i
+		}
+		i = e.skipComments(i + 1)
+		if i == len(e.tokens) || e.tokens[i].Value != "." {
+			return i
+		}
+		i = e.skipComments(i + 1)
+	}
+	return i
+}
+
+// skipParentheses advances past the parenthesis at open and everything up to
+// the one matching it
+func (e *columnExtractor) skipParentheses(open int) int {
+	if end := e.matchingParenthesis(open); end >= 0 {
+		return end + 1
+	}
+	return len(e.tokens)
+}
+
+// matchingParenthesis returns the index of the parenthesis, or square
+// bracket, closing the one at open, or -1 if it is never closed
+func (e *columnExtractor) matchingParenthesis(open int) int {
+	opening, closing := "(", ")"
+	if e.tokens[open].Value == "[" {
+		opening, closing = "[", "]"
+	}
+	depth := 0
+	for i := open; i < len(e.tokens); i++ {
+		switch e.tokens[i].Value {
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// hasEmptyColumnList reports whether the statement is an INSERT whose column
+// list is present but empty, or holds nothing but comments
+func (e *columnExtractor) hasEmptyColumnList() bool {
+	if i := e.skipComments(0); i == len(e.tokens) || !e.tokens[i].IsKeyword("INSERT") {
+		return false
+	}
+	open, end, ok := e.columnList()
+	return ok && end < len(e.tokens) && e.skipComments(open+1) == end
+}
+
+// trailingComma returns the index of a comma right before the parenthesis
+// closing the column list, or -1
+func (e *columnExtractor) trailingComma() int {
+	_, end, ok := e.columnList()
+	if !ok || end == len(e.tokens) {
+		return -1
+	}
+	i := end - 1
+	for e.tokens[i].Kind == TokenComment {
+		i--
+	}
+	if e.tokens[i].Value != "," {
+		return -1
+	}
+	return i
+}
+
+// hasMissingInto reports whether the statement is an INSERT going straight
+// to the table, as in INSERT t (a) VALUES (1)
+func (e *columnExtractor) hasMissingInto() bool {
+	i := e.skipComments(0)
+	if i == len(e.tokens) || !e.tokens[i].IsKeyword("INSERT") {
+		return false
+	}
+	next := e.skipComments(i + 1)
+	return next < len(e.tokens) && !e.tokens[next].IsKeyword("INTO")
+}
+
+func (e *columnExtractor) columns() []string {
+	listed := e.listedColumns()
+	columns := make([]string, 0, len(listed))
+	for _, column := range listed {
+		columns = append(columns, column.name)
+	}
+	return columns
+}
+
+// listedColumn is a column of the column list of an INSERT along with the
+// indexes of its first and last tokens
+type listedColumn struct {
+	name        string
+	first, last int
+}
+
+// listedColumns splits the column list of an INSERT into its columns
+func (e *columnExtractor) listedColumns() []listedColumn {
+	// Pre-allocate columns slice with a reasonable capacity
+	columns := make([]listedColumn, 0, len(e.tokens)/2)
+	open, end, ok := e.columnList()
+	if !ok {
+		return columns
+	}
+
+	dotted := false
+	for i := open + 1; i < end; i++ {
+		token := e.tokens[i]
+		switch {
+		case token.Value == ".":
+			dotted = len(columns) > 0
+		case e.isColumnsMatcher(i):
+			// COLUMNS('regexp') stays whole, see ParseColumnsMatcher
+			close := e.matchingParenthesis(i + 1)
+			if close < 0 || close > end {
+				close = end - 1
+			}
+			columns = append(columns, listedColumn{name: compactTokens(e.tokens[i : close+1]), first: i, last: close})
+			i = close
+		case token.Value == "(" || token.Value == ")" || token.Value == "," || token.Kind == TokenComment:
+		case dotted:
+			// Nested columns are addressed as parent.child
+			column := &columns[len(columns)-1]
+			column.name += "." + e.normalize(token.Value)
+			column.last = i
+			dotted = false
+		default:
+			columns = append(columns, listedColumn{name: e.normalize(token.Value), first: i, last: i})
+		}
+	}
+	return columns
+}
+
+// normalize returns identifier in NFC form if normalizeIdentifiers is set
+func (e *columnExtractor) normalize(identifier string) string {
+	if !e.normalizeIdentifiers {
+		return identifier
+	}
+	return norm.NFC.String(identifier)
+}
+
+// identifiersEqual compares two identifiers, after NFC normalization if
+// normalizeIdentifiers is set
+func (e *columnExtractor) identifiersEqual(a, b string) bool {
+	return e.normalize(a) == e.normalize(b)
+}
@@ -1,7 +1,10 @@
-package main
+package clickhouse_go_insert_statement_parsing
 
 import (
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,8 +18,8 @@ func TestExtractInsertColumns(t *testing.T) {
 		assert.NoError(t, err)
 		t.Log(e.tokens)
 		assert.Equal(t, 8, len(e.tokens))
-		assert.Equal(t, `column1`, e.tokens[4])
-		assert.Equal(t, `column2`, e.tokens[6])
+		assert.Equal(t, `column1`, e.tokens[4].Value)
+		assert.Equal(t, `column2`, e.tokens[6].Value)
 	})
 
 	t.Run(`table name with dots`, func(t *testing.T) {
@@ -26,8 +29,8 @@ func TestExtractInsertColumns(t *testing.T) {
 		err := e.parse()
 		assert.NoError(t, err)
 		assert.Equal(t, 10, len(e.tokens))
-		assert.Equal(t, `db`, e.tokens[2])
-		assert.Equal(t, `.`, e.tokens[3])
+		assert.Equal(t, `db`, e.tokens[2].Value)
+		assert.Equal(t, `.`, e.tokens[3].Value)
 	})
 
 	t.Run(`columns in single quotes`, func(t *testing.T) {
@@ -36,7 +39,7 @@ func TestExtractInsertColumns(t *testing.T) {
 		}
 		err := e.parse()
 		assert.NoError(t, err)
-		assert.Equal(t, `'column 1'`, e.tokens[4])
+		assert.Equal(t, `'column 1'`, e.tokens[4].Value)
 	})
 
 	t.Run(`columns in double backticks`, func(t *testing.T) {
@@ -45,7 +48,7 @@ func TestExtractInsertColumns(t *testing.T) {
 		}
 		err := e.parse()
 		assert.NoError(t, err)
-		assert.Equal(t, "`column1`", e.tokens[4])
+		assert.Equal(t, "`column1`", e.tokens[4].Value)
 	})
 
 	t.Run(`column containing backticks and single quotes inside quoted backticks`, func(t *testing.T) {
@@ -54,8 +57,8 @@ func TestExtractInsertColumns(t *testing.T) {
 		}
 		err := e.parse()
 		assert.NoError(t, err)
-		assert.Equal(t, "`colum\\`n1`", e.tokens[4])
-		assert.Equal(t, "`colu'mn2`", e.tokens[6])
+		assert.Equal(t, "`colum\\`n1`", e.tokens[4].Value)
+		assert.Equal(t, "`colu'mn2`", e.tokens[6].Value)
 	})
 
 	t.Run(`parentheses inside column names`, func(t *testing.T) {
@@ -64,7 +67,7 @@ func TestExtractInsertColumns(t *testing.T) {
 		}
 		err := e.parse()
 		assert.NoError(t, err)
-		assert.Equal(t, "`WEIGHT (kg)`", e.tokens[4])
+		assert.Equal(t, "`WEIGHT (kg)`", e.tokens[4].Value)
 	})
 
 	t.Run(`comma inside column names`, func(t *testing.T) {
@@ -73,7 +76,7 @@ func TestExtractInsertColumns(t *testing.T) {
 		}
 		err := e.parse()
 		assert.NoError(t, err)
-		assert.Equal(t, "`WEIGHT, in kg`", e.tokens[4])
+		assert.Equal(t, "`WEIGHT, in kg`", e.tokens[4].Value)
 	})
 
 	t.Run(`columns`, func(t *testing.T) {
@@ -94,8 +97,928 @@ func TestExtractInsertColumns(t *testing.T) {
 		err := e.parse()
 		assert.NoError(t, err)
 		assert.Equal(t, 8, len(e.tokens))
-		assert.Equal(t, `column1`, e.tokens[4])
-		assert.Equal(t, `column2`, e.tokens[6])
+		assert.Equal(t, `column1`, e.tokens[4].Value)
+		assert.Equal(t, `column2`, e.tokens[6].Value)
+	})
+}
+
+func TestEscapedBackslashBeforeClosingQuote(t *testing.T) {
+	t.Run(`backtick quoted identifier ending in a backslash`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO table (`name\\\\`, column2)",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, "`name\\\\`", e.tokens[4].Value)
+		assert.Equal(t, `column2`, e.tokens[6].Value)
+	})
+
+	t.Run(`single quoted identifier ending in a backslash`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO table ('name\\', column2)`,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, `'name\\'`, e.tokens[4].Value)
+		assert.Equal(t, `column2`, e.tokens[6].Value)
+	})
+
+	t.Run(`escaped quote after an escaped backslash`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO table (`a\\\\\\`b`)",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, "`a\\\\\\`b`", e.tokens[4].Value)
+	})
+
+	t.Run(`unclosed quote after an escaped quote`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO table ('abc\')`,
+		}
+		err := e.parse()
+		assert.Error(t, err)
+	})
+}
+
+func TestLineComments(t *testing.T) {
+	t.Run(`comment before the statement`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "-- generated by migrate\nINSERT INTO table (column1, column2)",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, 8, len(e.tokens))
+		assert.Equal(t, []string{`column1`, `column2`}, e.columns())
+	})
+
+	t.Run(`comments inside the statement`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO table -- target\n(column1, -- first\ncolumn2) -- trailing",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`column1`, `column2`}, e.columns())
+	})
+
+	t.Run(`dashes inside quotes are not comments`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO table (`a--b`, 'c -- d')",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"`a--b`", `'c -- d'`}, e.columns())
+	})
+
+	t.Run(`single dash`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO table (a - b)",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, Token{Kind: TokenOperator, Value: `-`}, e.tokens[5])
+	})
+}
+
+func TestBlockComments(t *testing.T) {
+	t.Run(`comment between table and columns`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t /* batch 42 */ (a, b)`,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, 8, len(e.tokens))
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`nested comments`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t /* outer /* inner */ still outer */ (a)`,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`a`}, e.columns())
+	})
+
+	t.Run(`unclosed comment`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a) /* outer /* inner */`,
+		}
+		err := e.parse()
+		assert.EqualError(t, err, `1:19: unclosed block comment`)
+	})
+
+	t.Run(`single slash`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a / b)`,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, Token{Kind: TokenOperator, Value: `/`}, e.tokens[5])
+	})
+
+	t.Run(`comments kept as tokens`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:        "-- header\nINSERT INTO t /* batch /* 42 */ */ (a, /* metric */ b)",
+			keepComments: true,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, `-- header`, e.tokens[0].Value)
+		assert.Equal(t, `/* batch /* 42 */ */`, e.tokens[4].Value)
+		assert.Equal(t, `/* metric */`, e.tokens[8].Value)
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+}
+
+func TestExtraIdentifierChars(t *testing.T) {
+	t.Run(`rejected by default`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (price$)`,
+		}
+		err := e.parse()
+		assert.EqualError(t, err, `1:21: unexpected rune: $`)
+	})
+
+	t.Run(`dollar`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:                `INSERT INTO t (price$, $total)`,
+			extraIdentifierChars: identifierCharsOf(`$`),
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`price$`, `$total`}, e.columns())
+	})
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	t.Run(`letters and digits`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO таблица (имя, 列名, x٣, café)`,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, `таблица`, e.tokens[2].Value)
+		assert.Equal(t, []string{`имя`, `列名`, `x٣`, `café`}, e.columns())
+	})
+
+	t.Run(`combining marks`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO t (cafe\u0301)",
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"cafe\u0301"}, e.columns())
+	})
+
+	t.Run(`symbols are still rejected`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a€)`,
+		}
+		err := e.parse()
+		assert.EqualError(t, err, `1:17: unexpected rune: €`)
+	})
+}
+
+func TestTokenKinds(t *testing.T) {
+	e := &columnExtractor{
+		query:        "INSERT INTO t (`a`, 'b') -- c",
+		keepComments: true,
+	}
+	err := e.parse()
+	assert.NoError(t, err)
+	assert.Equal(t, []Token{
+		{Kind: TokenKeyword, Value: `INSERT`},
+		{Kind: TokenKeyword, Value: `INTO`},
+		{Kind: TokenIdentifier, Value: `t`},
+		{Kind: TokenPunctuation, Value: `(`},
+		{Kind: TokenQuotedIdentifier, Value: "`a`"},
+		{Kind: TokenPunctuation, Value: `,`},
+		{Kind: TokenString, Value: `'b'`},
+		{Kind: TokenPunctuation, Value: `)`},
+		{Kind: TokenComment, Value: `-- c`},
+	}, e.tokens)
+	assert.Equal(t, `Number`, TokenNumber.String())
+	assert.Equal(t, `Unknown`, TokenKind(-1).String())
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []Token
+	}{
+		{`42`, []Token{{Kind: TokenNumber, Value: `42`}}},
+		{`3.14`, []Token{{Kind: TokenNumber, Value: `3.14`}}},
+		{`1.`, []Token{{Kind: TokenNumber, Value: `1.`}}},
+		{`1e10`, []Token{{Kind: TokenNumber, Value: `1e10`}}},
+		{`2.5E-3`, []Token{{Kind: TokenNumber, Value: `2.5E-3`}}},
+		{`0x1F`, []Token{{Kind: TokenNumber, Value: `0x1F`}}},
+		{`0b101`, []Token{{Kind: TokenNumber, Value: `0b101`}}},
+		{`1st_place`, []Token{{Kind: TokenIdentifier, Value: `1st_place`}}},
+		{`0xZ`, []Token{{Kind: TokenIdentifier, Value: `0xZ`}}},
+		{`t.1.2`, []Token{
+			{Kind: TokenIdentifier, Value: `t`},
+			{Kind: TokenPunctuation, Value: `.`},
+			{Kind: TokenNumber, Value: `1`},
+			{Kind: TokenPunctuation, Value: `.`},
+			{Kind: TokenNumber, Value: `2`},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			e := &columnExtractor{
+				query: tt.query,
+			}
+			err := e.parse()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, e.tokens)
+		})
+	}
+
+	t.Run(`values after the column list`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a, b, c) VALUES (1, 2.5, 0x1F)`,
+		}
+		err := e.parse()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`a`, `b`, `c`}, e.columns())
+	})
+}
+
+func TestNormalizeIdentifiers(t *testing.T) {
+	// "é" precomposed (NFC) and as "e" followed by a combining acute accent (NFD)
+	query := "INSERT INTO t (`caf\u00e9`, cafe\u0301)"
+
+	t.Run(`disabled by default`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: query,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{"`caf\u00e9`", "cafe\u0301"}, e.columns())
+		assert.False(t, e.identifiersEqual("caf\u00e9", "cafe\u0301"))
+	})
+
+	t.Run(`enabled`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:                query,
+			normalizeIdentifiers: true,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{"`caf\u00e9`", "caf\u00e9"}, e.columns())
+		assert.True(t, e.identifiersEqual("`cafe\u0301`", "`caf\u00e9`"))
+	})
+}
+
+func TestDollarQuotedStrings(t *testing.T) {
+	t.Run(`tagged`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO t (a) VALUES ($json${\"a\": 'x)'}$json$)",
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenString, Value: "$json${\"a\": 'x)'}$json$"}, e.tokens[8])
+		assert.Equal(t, []string{`a`}, e.columns())
+	})
+
+	t.Run(`untagged`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT $$a $b$ c$$, 1`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenString, Value: `$$a $b$ c$$`}, e.tokens[1])
+		assert.Equal(t, 4, len(e.tokens))
+	})
+
+	t.Run(`unclosed`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT $tag$ abc $other$`,
+		}
+		assert.EqualError(t, e.parse(), `1:8: unclosed dollar-quoted string`)
+	})
+
+	t.Run(`dollar identifiers`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:                `INSERT INTO t ($total, $x$y$x$)`,
+			extraIdentifierChars: identifierCharsOf(`$`),
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenIdentifier, Value: `$total`}, e.tokens[4])
+		assert.Equal(t, Token{Kind: TokenString, Value: `$x$y$x$`}, e.tokens[6])
+	})
+
+	t.Run(`lone dollar`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT $ 1`,
+		}
+		assert.EqualError(t, e.parse(), `1:8: unexpected rune: $`)
+	})
+}
+
+func TestOperators(t *testing.T) {
+	t.Run(`all operators`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `= == != <> < <= > >= + - * / % || -> ::`,
+		}
+		assert.NoError(t, e.parse())
+		operators := make([]string, 0, len(e.tokens))
+		for _, token := range e.tokens {
+			assert.Equal(t, TokenOperator, token.Kind)
+			operators = append(operators, token.Value)
+		}
+		assert.Equal(t, []string{`=`, `==`, `!=`, `<>`, `<`, `<=`, `>`, `>=`, `+`, `-`, `*`, `/`, `%`, `||`, `->`, `::`}, operators)
+	})
+
+	t.Run(`without spaces`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `a>=-1`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []Token{
+			{Kind: TokenIdentifier, Value: `a`},
+			{Kind: TokenOperator, Value: `>=`},
+			{Kind: TokenOperator, Value: `-`},
+			{Kind: TokenNumber, Value: `1`},
+		}, e.tokens)
+	})
+
+	t.Run(`lambdas and casts`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a, b) SELECT arrayMap(x -> x * 2, arr), y::Int32, -1 FROM s`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+		assert.Equal(t, []Token{
+			{Kind: TokenIdentifier, Value: `x`},
+			{Kind: TokenOperator, Value: `->`},
+			{Kind: TokenIdentifier, Value: `x`},
+		}, e.tokens[11:14])
+		assert.Equal(t, []Token{
+			{Kind: TokenIdentifier, Value: `y`},
+			{Kind: TokenOperator, Value: `::`},
+			{Kind: TokenIdentifier, Value: `Int32`},
+		}, e.tokens[20:23])
+	})
+
+	t.Run(`colon`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT json.a:Int64`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenPunctuation, Value: `:`}, e.tokens[4])
+	})
+
+	t.Run(`insert select`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a, b) SELECT x * 2, y || 'z' FROM s WHERE x >= 2 AND y != 'q'`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`incomplete operators`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `a ! b | c`,
+		}
+		assert.EqualError(t, e.parse(), "1:3: unexpected rune: !\n1:7: unexpected rune: |")
+	})
+}
+
+func TestSemicolon(t *testing.T) {
+	t.Run(`terminated statement`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a, b);`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, statementTerminator, e.tokens[len(e.tokens)-1])
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`parse stops at the end of the statement`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a); INSERT INTO u (b €)`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`}, e.columns())
+
+		assert.EqualError(t, e.parse(), `1:37: unexpected rune: €`)
+		assert.Equal(t, `u`, e.tokens[2].Value)
+		assert.Equal(t, []string{`b`}, e.columns())
+	})
+
+	t.Run(`quoted semicolons`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO t (`a;b`, 'c;d') -- ;\n/* ; */",
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, len(e.query), e.byteIndex)
+		assert.Equal(t, []string{"`a;b`", `'c;d'`}, e.columns())
+	})
+
+	t.Run(`statements`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `;INSERT INTO t (a);; SELECT 1;`,
+		}
+		statements, err := e.statements()
+		assert.NoError(t, err)
+		assert.Len(t, statements, 2)
+		assert.Equal(t, `INSERT`, statements[0][0].Value)
+		assert.Equal(t, []Token{{Kind: TokenKeyword, Value: `SELECT`}, {Kind: TokenNumber, Value: `1`}}, statements[1])
+	})
+}
+
+func TestPlaceholders(t *testing.T) {
+	t.Run(`ordinals`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a,b) VALUES (?, ?)`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenPlaceholder, Value: `?`, Ordinal: 1}, e.tokens[10])
+		assert.Equal(t, Token{Kind: TokenPlaceholder, Value: `?`, Ordinal: 2}, e.tokens[12])
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`count`, func(t *testing.T) {
+		count, err := PlaceholderCount(`SELECT * FROM t WHERE a = ? AND b IN (?, ?) AND c = '?'`)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run(`ordinals restart with each statement`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT ?; SELECT ?`,
+		}
+		statements, err := e.statements()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, statements[1][1].Ordinal)
+	})
+}
+
+func TestNamedPlaceholders(t *testing.T) {
+	t.Run(`named arguments`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t (a, b) VALUES (@first, @second_2)`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenNamedPlaceholder, Value: `@first`}, e.tokens[10])
+		assert.Equal(t, Token{Kind: TokenNamedPlaceholder, Value: `@second_2`}, e.tokens[12])
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`bare at sign`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT @ 1`,
+		}
+		assert.EqualError(t, e.parse(), `1:8: unexpected rune: @`)
+	})
+}
+
+func TestParameters(t *testing.T) {
+	t.Run(`tokens`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `SELECT * FROM t WHERE id = {id:UInt64} AND tags = { tags : Array(Tuple(String, UInt8)) }`,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Token{Kind: TokenParameter, Value: `{id:UInt64}`}, e.tokens[7])
+		parameter, ok := e.tokens[11].Parameter()
+		assert.True(t, ok)
+		assert.Equal(t, Parameter{Name: `tags`, Type: `Array(Tuple(String, UInt8))`}, parameter)
+		_, ok = e.tokens[0].Parameter()
+		assert.False(t, ok)
+	})
+
+	t.Run(`in order`, func(t *testing.T) {
+		parameters, err := Parameters(`INSERT INTO t (a, b) VALUES ({b:String}, {a:Enum('}' = 1, '\'' = 2)}); SELECT {c:Date}`)
+		assert.NoError(t, err)
+		assert.Equal(t, []Parameter{
+			{Name: `b`, Type: `String`},
+			{Name: `a`, Type: `Enum('}' = 1, '\'' = 2)`},
+			{Name: `c`, Type: `Date`},
+		}, parameters)
+	})
+
+	t.Run(`not parameters`, func(t *testing.T) {
+		for _, query := range []string{`SELECT {'a': 1}`, `SELECT {id}`, `SELECT {:String}`, `SELECT {a: 1, b: 2}`, `SELECT {1:String}`} {
+			parameters, err := Parameters(query)
+			assert.NoError(t, err, query)
+			assert.Empty(t, parameters, query)
+		}
+
+		e := &columnExtractor{query: `SELECT {'a': 1}`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []Token{
+			{Kind: TokenKeyword, Value: `SELECT`},
+			{Kind: TokenPunctuation, Value: `{`},
+			{Kind: TokenString, Value: `'a'`},
+			{Kind: TokenPunctuation, Value: `:`},
+			{Kind: TokenNumber, Value: `1`},
+			{Kind: TokenPunctuation, Value: `}`},
+		}, e.tokens)
+
+		_, ok := Token{Kind: TokenParameter, Value: `{'a': 1}`}.Parameter()
+		assert.False(t, ok)
+		_, ok = Token{Kind: TokenParameter, Value: `{a: 1}`}.Parameter()
+		assert.False(t, ok)
+	})
+
+	t.Run(`invalid`, func(t *testing.T) {
+		_, err := Parameters(`SELECT {id:String`)
+		assert.EqualError(t, err, `1:8: unclosed query parameter`)
+	})
+}
+
+func TestWhitespace(t *testing.T) {
+	e := &columnExtractor{
+		query: "INSERT INTO t\r\n(a,\vb,\fc,\u00a0d)\r\n",
+	}
+	assert.NoError(t, e.parse())
+	assert.Equal(t, []string{`a`, `b`, `c`, `d`}, e.columns())
+}
+
+func TestEmptyColumnList(t *testing.T) {
+	query := `INSERT INTO t () VALUES ()`
+
+	t.Run(`allowed by default`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: query,
+		}
+		assert.NoError(t, e.parse())
+		assert.Empty(t, e.warnings)
+		assert.Empty(t, e.columns())
+	})
+
+	t.Run(`warning`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:           query,
+			emptyColumnList: warnEmptyColumnList,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []error{ErrEmptyColumnList}, e.warnings)
+	})
+
+	t.Run(`error`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:           query,
+			emptyColumnList: rejectEmptyColumnList,
+		}
+		assert.ErrorIs(t, e.parse(), ErrEmptyColumnList)
+	})
+
+	t.Run(`comments kept as tokens`, func(t *testing.T) {
+		for _, query := range []string{"/* load */ INSERT INTO t () VALUES", "INSERT INTO t (/* none */) VALUES"} {
+			e := &columnExtractor{
+				query:           query,
+				keepComments:    true,
+				emptyColumnList: rejectEmptyColumnList,
+			}
+			assert.ErrorIs(t, e.parse(), ErrEmptyColumnList, query)
+		}
+	})
+
+	t.Run(`only for inserts with a column list`, func(t *testing.T) {
+		for _, query := range []string{`INSERT INTO t (a) VALUES ()`, `INSERT INTO t VALUES`, `SELECT now()`} {
+			e := &columnExtractor{
+				query:           query,
+				emptyColumnList: rejectEmptyColumnList,
+			}
+			assert.NoError(t, e.parse(), query)
+		}
+	})
+}
+
+func TestTrailingComma(t *testing.T) {
+	query := "INSERT INTO t (a, b, /* c */\n) VALUES (1, 2)"
+
+	t.Run(`ignored by default`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: query,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`error`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:               query,
+			keepComments:        true,
+			rejectTrailingComma: true,
+		}
+		err := e.parse()
+		assert.ErrorIs(t, err, ErrTrailingComma)
+		assert.EqualError(t, err, `1:20: trailing comma in column list`)
+	})
+
+	t.Run(`only at the end of the column list`, func(t *testing.T) {
+		for _, query := range []string{`INSERT INTO t (a, b) VALUES (1, 2, )`, `INSERT INTO t (a, f(b, )) VALUES`, `INSERT INTO t (,`} {
+			e := &columnExtractor{
+				query:               query,
+				rejectTrailingComma: true,
+			}
+			assert.NoError(t, e.parse(), query)
+		}
+	})
+}
+
+func TestByteOrderMark(t *testing.T) {
+	t.Run(`at the start`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "\ufeffINSERT INTO t (a)",
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, `INSERT`, e.tokens[0].Value)
+		assert.Equal(t, []string{`a`}, e.columns())
+	})
+
+	t.Run(`elsewhere`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: "INSERT INTO t (a,\ufeffb)",
+		}
+		assert.EqualError(t, e.parse(), "1:18: unexpected rune: \ufeff")
+	})
+
+	t.Run(`elsewhere as whitespace`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:                "SELECT 1;\ufeffINSERT INTO t (a,\ufeffb)",
+			byteOrderMarkAsSpace: true,
+		}
+		statements, err := e.statements()
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT`, statements[1][0].Value)
+	})
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	query := "INSERT INTO t\n(`a\xffb`, 'c\xfe') VALUES ($$\xff$$)"
+
+	t.Run(`replaced by default`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: query,
+		}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{"`a\ufffdb`", "'c\ufffd'"}, e.columns())
+		assert.Equal(t, "$$\ufffd$$", e.tokens[10].Value)
+	})
+
+	t.Run(`token ends follow the source`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: query,
+		}
+		assert.NoError(t, e.parse())
+		spans := make([]string, 0, len(e.tokens))
+		for i := range e.tokens {
+			spans = append(spans, query[e.offsets[i]:e.tokenEnd(i)])
+		}
+		assert.Equal(t, []string{
+			`INSERT`, `INTO`, `t`, `(`, "`a\xffb`", `,`, "'c\xfe'", `)`, `VALUES`, `(`, "$$\xff$$", `)`,
+		}, spans)
+	})
+
+	t.Run(`rejected`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:       query,
+			invalidUTF8: rejectInvalidUTF8,
+		}
+		assert.EqualError(t, e.parse(), `invalid UTF-8 at offset 17 (2:4)`)
+		assert.Empty(t, e.tokens)
+	})
+
+	t.Run(`valid input is accepted when rejecting`, func(t *testing.T) {
+		e := &columnExtractor{
+			query:       `INSERT INTO t (имя)`,
+			invalidUTF8: rejectInvalidUTF8,
+		}
+		assert.NoError(t, e.parse())
+	})
+}
+
+func TestParseWithDeadline(t *testing.T) {
+	t.Run(`in time`, func(t *testing.T) {
+		columns, err := ParseWithDeadline(`INSERT INTO t (a, b)`, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`a`, `b`}, columns)
+	})
+
+	t.Run(`already expired`, func(t *testing.T) {
+		columns, err := ParseWithDeadline(`INSERT INTO t (a, b)`, 0)
+		assert.ErrorIs(t, err, ErrDeadlineExceeded)
+		assert.Empty(t, columns)
+	})
+
+	t.Run(`partial results`, func(t *testing.T) {
+		// The clock advances a second per check, so the deadline passes on the third
+		start := time.Now()
+		checks := 0
+		e := &columnExtractor{
+			query:    `INSERT INTO t (` + strings.Repeat(`c, `, 100) + `last)`,
+			deadline: start.Add(2 * time.Second),
+			now: func() time.Time {
+				checks++
+				return start.Add(time.Duration(checks-1) * time.Second)
+			},
+		}
+		err := e.parse()
+		assert.ErrorIs(t, err, ErrDeadlineExceeded)
+		assert.Equal(t, 3, checks)
+		columns := e.columns()
+		assert.NotEmpty(t, columns)
+		assert.Less(t, len(columns), 101)
+	})
+}
+
+func TestRejectControlChars(t *testing.T) {
+	t.Run(`NUL between tokens`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t\x00 (a)", rejectControlChars: true}
+		err := e.parse()
+		assert.EqualError(t, err, `control character U+0000 at offset 13 (1:14)`)
+	})
+
+	t.Run(`inside an identifier`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t (`a\x1bb`)", rejectControlChars: true}
+		assert.ErrorContains(t, e.parse(), `control character U+001B`)
+	})
+
+	t.Run(`inside a comment`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t -- \x07\n(a)", rejectControlChars: true}
+		assert.ErrorContains(t, e.parse(), `control character U+0007`)
+	})
+
+	t.Run(`allowed in string literals`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t (a) VALUES ('\x00', $$\x01$$)", rejectControlChars: true}
+		assert.NoError(t, e.parse())
+	})
+
+	t.Run(`whitespace is allowed`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t\t(a,\r\nb)", rejectControlChars: true}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+	})
+
+	t.Run(`disabled by default`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t (a)\x00"}
+		assert.ErrorContains(t, e.parse(), `unexpected rune`)
+	})
+}
+
+func TestLargeTokens(t *testing.T) {
+	large := strings.Repeat(`x`, 1<<20)
+	tests := []struct {
+		name  string
+		query string
+		value string
+	}{
+		{`single quoted`, `INSERT INTO t VALUES ('` + large + `')`, `'` + large + `'`},
+		{`backtick quoted`, "INSERT INTO t (`" + large + "`)", "`" + large + "`"},
+		{`unquoted`, `INSERT INTO t (` + large + `)`, large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &columnExtractor{query: tt.query}
+			assert.NoError(t, e.parse())
+			assert.Equal(t, tt.value, e.tokens[len(e.tokens)-2].Value)
+		})
+	}
+
+	t.Run(`escaped quotes`, func(t *testing.T) {
+		e := &columnExtractor{query: `'a\\' 'b\\\' c'`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`'a\\'`, `'b\\\' c'`}, []string{e.tokens[0].Value, e.tokens[1].Value})
+	})
+}
+
+func TestMissingInto(t *testing.T) {
+	t.Run(`rejected by default`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT t (a, b) VALUES (1, 2)`}
+		assert.ErrorIs(t, e.parse(), ErrMissingInto)
+	})
+
+	t.Run(`lenient`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT t (a, b) VALUES (1, 2)`, allowMissingInto: true}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []error{ErrMissingInto}, e.warnings)
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+		assert.Equal(t, []TableRef{{Table: `t`}}, tableRefs(e.tokens))
+	})
+
+	t.Run(`with INTO`, func(t *testing.T) {
+		e := &columnExtractor{query: `insert into t (a)`, allowMissingInto: true}
+		assert.NoError(t, e.parse())
+		assert.Empty(t, e.warnings)
+	})
+
+	t.Run(`comments kept as tokens`, func(t *testing.T) {
+		e := &columnExtractor{query: "-- load\nINSERT /* batch */ INTO t (a, b)", keepComments: true}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+
+		e = &columnExtractor{query: `INSERT /* batch */ t (a)`, keepComments: true}
+		assert.ErrorIs(t, e.parse(), ErrMissingInto)
+	})
+}
+
+func TestPositions(t *testing.T) {
+	t.Run(`tokens`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t\n  (a,\n   ключ)"}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, e.tokenPosition(0))
+		assert.Equal(t, Position{Offset: 16, Line: 2, Column: 3}, e.tokenPosition(3))
+		assert.Equal(t, Position{Offset: 23, Line: 3, Column: 4}, e.tokenPosition(6))
+		assert.Equal(t, Position{Offset: 31, Line: 3, Column: 8}, e.tokenPosition(7))
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		e := &columnExtractor{query: "INSERT INTO t\n(a, 'b)"}
+		err := e.parse()
+		var syntaxErr *SyntaxError
+		assert.ErrorAs(t, err, &syntaxErr)
+		assert.Equal(t, Position{Offset: 18, Line: 2, Column: 5}, syntaxErr.Pos)
+		assert.EqualError(t, err, `2:5: unclosed single quote`)
+	})
+}
+
+func TestBrackets(t *testing.T) {
+	e := &columnExtractor{query: `INSERT INTO t (a, b) VALUES ([1, 2], arr[1])`}
+	assert.NoError(t, e.parse())
+	assert.Equal(t, []string{`a`, `b`}, e.columns())
+	assert.Equal(t, []Token{
+		{Kind: TokenPunctuation, Value: `[`},
+		{Kind: TokenNumber, Value: `1`},
+		{Kind: TokenPunctuation, Value: `,`},
+		{Kind: TokenNumber, Value: `2`},
+		{Kind: TokenPunctuation, Value: `]`},
+		{Kind: TokenPunctuation, Value: `,`},
+		{Kind: TokenIdentifier, Value: `arr`},
+		{Kind: TokenPunctuation, Value: `[`},
+		{Kind: TokenNumber, Value: `1`},
+		{Kind: TokenPunctuation, Value: `]`},
+	}, e.tokens[10:20])
+}
+
+func TestStar(t *testing.T) {
+	t.Run(`insert select`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO t (a, b) SELECT *, count(*) FROM s`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`a`, `b`}, e.columns())
+		assert.Equal(t, Token{Kind: TokenOperator, Value: `*`}, e.tokens[9])
+		assert.Equal(t, Token{Kind: TokenOperator, Value: `*`}, e.tokens[13])
+	})
+
+	t.Run(`no column list`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO t SELECT count(*) FROM s`}
+		assert.NoError(t, e.parse())
+		assert.Empty(t, e.columns())
+	})
+}
+
+func TestNestedColumns(t *testing.T) {
+	e := &columnExtractor{
+		query: "INSERT INTO t (id, n.a, `n`.`b c`, n . /* x */ d, x.y.z) VALUES",
+	}
+	assert.NoError(t, e.parse())
+	assert.Equal(t, []string{`id`, `n.a`, "`n`.`b c`", `n.d`, `x.y.z`}, e.columns())
+}
+
+func TestColumnListAnchoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+	}{
+		{`table function`, `INSERT INTO FUNCTION remote('host', db, t) (a, b) VALUES (1, 2)`, []string{`a`, `b`}},
+		{`table function without columns`, `INSERT INTO FUNCTION remote('host', db, t) VALUES (1, 2)`, []string{}},
+		{`insert into table`, `INSERT INTO TABLE db.t (a, b)`, []string{`a`, `b`}},
+		{`table named table`, `INSERT INTO table (a, b)`, []string{`a`, `b`}},
+		{`nested table function arguments`, `INSERT INTO TABLE FUNCTION s3(concat('a', 'b'), 'CSV') (x)`, []string{`x`}},
+		{`values without columns`, `INSERT INTO t VALUES (1, 2)`, []string{}},
+		{`qualified table`, "INSERT INTO `db`.`t` (a)", []string{`a`}},
+		{`table parameter`, `INSERT INTO {table:Identifier} (a)`, []string{`a`}},
+		{`comments`, `INSERT /* x */ INTO -- y` + "\n" + `db /* z */ . t (a)`, []string{`a`}},
+		{`missing INTO`, `INSERT t (a)`, []string{`a`}},
+		{`not an insert`, `SELECT f(a, b)`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &columnExtractor{query: tt.query, allowMissingInto: true, keepComments: true}
+			assert.NoError(t, e.parse())
+			assert.Equal(t, tt.columns, e.columns())
+		})
+	}
+}
+
+func TestNestedColumnList(t *testing.T) {
+	t.Run(`nested parentheses`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO t (a, (b, c), d) VALUES (1, (2, 3), 4)`}
+		assert.NoError(t, e.parse())
+		open, end, ok := e.columnList()
+		assert.True(t, ok)
+		assert.Equal(t, 3, open)
+		assert.Equal(t, 13, end)
+		assert.Equal(t, []string{`a`, `b`, `c`, `d`}, e.columns())
+	})
+
+	t.Run(`unclosed after a nested list`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO t (a, (b)`}
+		assert.NoError(t, e.parse())
+		_, end, ok := e.columnList()
+		assert.True(t, ok)
+		assert.Equal(t, len(e.tokens), end)
 	})
 }
 
@@ -111,6 +1034,45 @@ func BenchmarkParse(b *testing.B) {
 	}
 }
 
+func BenchmarkParseInterned(b *testing.B) {
+	query := `INSERT INTO table (column1, column2)`
+	intern := NewInternTable(1024)
+	for i := 0; i < b.N; i++ {
+		e := &columnExtractor{
+			query:  query,
+			intern: intern,
+		}
+		err := e.parse()
+		e.columns()
+		assert.NoError(b, err)
+	}
+}
+
+func BenchmarkScript(b *testing.B) {
+	script := strings.Repeat("INSERT INTO t (a) VALUES (1);\n", 16000)
+	b.Run(`SplitStatements`, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			statements, err := SplitStatements(script)
+			assert.NoError(b, err)
+			assert.Len(b, statements, 16000)
+		}
+	})
+	b.Run(`IsWriteStatement`, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			write, err := IsWriteStatement(script)
+			assert.NoError(b, err)
+			assert.True(b, write)
+		}
+	})
+	b.Run(`ExtractAll`, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			all, err := ExtractAll(script)
+			assert.NoError(b, err)
+			assert.Len(b, all, 16000)
+		}
+	})
+}
+
 func BenchmarkRegexp(b *testing.B) {
 	query := `INSERT INTO table (column1, column2)`
 	for i := 0; i < b.N; i++ {
@@ -118,3 +1080,6 @@ func BenchmarkRegexp(b *testing.B) {
 		assert.Equal(b, 2, len(matches))
 	}
 }
+
+// copied from clickhouse-go source code
+var extractInsertColumnsMatch = regexp.MustCompile(`(?si)INSERT INTO .+\s\((?P<Columns>.+)\)$`)
@@ -0,0 +1,146 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"strings"
+)
+
+// Maintenance describes a TRUNCATE, OPTIMIZE or EXCHANGE TABLES statement
+type Maintenance struct {
+	Kind string // TRUNCATE, OPTIMIZE or EXCHANGE, in upper case
+	// Tables holds the target table, or both tables of EXCHANGE TABLES. It is
+	// empty for TRUNCATE DATABASE and TRUNCATE ALL TABLES FROM, which set
+	// Database instead
+	Tables   []TableRef
+	Database string
+	Cluster  string // name following ON CLUSTER, empty if left out
+	// Partition holds the expression of OPTIMIZE ... PARTITION, and
+	// PartitionID the decoded id of PARTITION ID '...'
+	Partition   Clause
+	PartitionID string
+	Final       bool
+	Deduplicate bool
+}
+
+// Destructive reports whether the statement deletes or swaps data, which
+// TRUNCATE and EXCHANGE TABLES do while OPTIMIZE only merges parts
+func (m *Maintenance) Destructive() bool {
+	return m.Kind == "TRUNCATE" || m.Kind == "EXCHANGE"
+}
+
+// ParseMaintenance parses the first statement of query, which has to be
+// TRUNCATE, OPTIMIZE TABLE or EXCHANGE TABLES
+func ParseMaintenance(query string) (*Maintenance, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	i := e.skipComments(0)
+	if i == len(e.tokens) {
+		return nil, errors.New("not a maintenance statement")
+	}
+	statement := &Maintenance{
+		Kind:   strings.ToUpper(e.tokens[i].Value),
+		Tables: make([]TableRef, 0, 1),
+	}
+	i++
+	switch statement.Kind {
+	case "TRUNCATE":
+		switch {
+		case i+2 < len(e.tokens) && isWord(e.tokens[i], "ALL") && e.tokens[i+1].IsKeyword("TABLES") && e.tokens[i+2].IsKeyword("FROM"):
+			i = skipKeywords(e.tokens, i+3, "IF", "EXISTS")
+			return statement, e.truncateDatabase(statement, i)
+		case i < len(e.tokens) && isWord(e.tokens[i], "DATABASE"):
+			i = skipKeywords(e.tokens, i+1, "IF", "EXISTS")
+			return statement, e.truncateDatabase(statement, i)
+		}
+		i = skipKeywords(e.tokens, i, "TEMPORARY", "TABLE", "IF", "EXISTS")
+		table, next := readTableName(e.tokens, i)
+		if table.Table == "" {
+			return nil, errors.New("missing table name")
+		}
+		statement.Tables = append(statement.Tables, table)
+		statement.Cluster, _ = e.onCluster(next)
+	case "OPTIMIZE":
+		if i == len(e.tokens) || !e.tokens[i].IsKeyword("TABLE") {
+			return nil, errors.New("missing TABLE after OPTIMIZE")
+		}
+		table, next := readTableName(e.tokens, i+1)
+		if table.Table == "" {
+			return nil, errors.New("missing table name")
+		}
+		statement.Tables = append(statement.Tables, table)
+		statement.Cluster, i = e.onCluster(next)
+		if err := e.optimizeModifiers(statement, i); err != nil {
+			return nil, err
+		}
+	case "EXCHANGE":
+		if i == len(e.tokens) || !e.tokens[i].IsKeyword("TABLES") {
+			return nil, errors.New("missing TABLES after EXCHANGE")
+		}
+		first, next := readTableName(e.tokens, i+1)
+		if first.Table == "" || next == len(e.tokens) || !e.tokens[next].IsKeyword("AND") {
+			return nil, errors.New("expected EXCHANGE TABLES a AND b")
+		}
+		second, next := readTableName(e.tokens, next+1)
+		if second.Table == "" {
+			return nil, errors.New("expected EXCHANGE TABLES a AND b")
+		}
+		statement.Tables = append(statement.Tables, first, second)
+		statement.Cluster, _ = e.onCluster(next)
+	default:
+		return nil, errors.New("not a maintenance statement")
+	}
+	return statement, nil
+}
+
+// truncateDatabase fills in the database of TRUNCATE DATABASE or TRUNCATE
+// ALL TABLES FROM, whose name is at index i
+func (e *columnExtractor) truncateDatabase(statement *Maintenance, i int) error {
+	database, next := readTableName(e.tokens, i)
+	if database.Table == "" || database.Database != "" {
+		return errors.New("missing database name")
+	}
+	statement.Database = database.Table
+	statement.Cluster, _ = e.onCluster(next)
+	return nil
+}
+
+// optimizeModifiers fills in the PARTITION, FINAL and DEDUPLICATE modifiers
+// of OPTIMIZE TABLE from the token at index i on
+func (e *columnExtractor) optimizeModifiers(statement *Maintenance, i int) error {
+	end := len(e.tokens)
+	if settings := e.topLevelWord(i, end, "SETTINGS"); settings >= 0 {
+		end = settings
+	} else if end > 0 && e.tokens[end-1] == statementTerminator {
+		end--
+	}
+	final := e.topLevelWord(i, end, "FINAL")
+	deduplicate := e.topLevelWord(i, end, "DEDUPLICATE")
+	statement.Final = final >= 0
+	statement.Deduplicate = deduplicate >= 0
+	if i == end || !isWord(e.tokens[i], "PARTITION") {
+		return nil
+	}
+	partitionEnd := end
+	for _, modifier := range []int{final, deduplicate} {
+		if modifier >= 0 && modifier < partitionEnd {
+			partitionEnd = modifier
+		}
+	}
+	if i+1 == partitionEnd {
+		return e.errorAt(i, "missing partition expression")
+	}
+	if isWord(e.tokens[i+1], "ID") && i+2 < partitionEnd && e.tokens[i+2].Kind == TokenString {
+		id, err := e.tokens[i+2].DecodedValue()
+		if err != nil {
+			return err
+		}
+		statement.PartitionID = id
+		return nil
+	}
+	statement.Partition = e.clause(i+1, partitionEnd-1)
+	return nil
+}
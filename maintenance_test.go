@@ -0,0 +1,64 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMaintenance(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected *Maintenance
+	}{
+		{
+			query:    `TRUNCATE TABLE IF EXISTS db.events ON CLUSTER main`,
+			expected: &Maintenance{Kind: `TRUNCATE`, Tables: []TableRef{{Database: `db`, Table: `events`}}, Cluster: `main`},
+		},
+		{
+			query:    `truncate events;`,
+			expected: &Maintenance{Kind: `TRUNCATE`, Tables: []TableRef{{Table: `events`}}},
+		},
+		{
+			query:    `TRUNCATE ALL TABLES FROM IF EXISTS staging`,
+			expected: &Maintenance{Kind: `TRUNCATE`, Tables: []TableRef{}, Database: `staging`},
+		},
+		{
+			query:    `TRUNCATE DATABASE staging ON CLUSTER main`,
+			expected: &Maintenance{Kind: `TRUNCATE`, Tables: []TableRef{}, Database: `staging`, Cluster: `main`},
+		},
+		{
+			query: `OPTIMIZE TABLE db.events ON CLUSTER main PARTITION toYYYYMM(today()) FINAL DEDUPLICATE BY id`,
+			expected: &Maintenance{Kind: `OPTIMIZE`, Tables: []TableRef{{Database: `db`, Table: `events`}}, Cluster: `main`,
+				Partition: Clause{Expr: `toYYYYMM(today())`, Pos: Position{Offset: 51, Line: 1, Column: 52}}, Final: true, Deduplicate: true},
+		},
+		{
+			query:    `OPTIMIZE TABLE events PARTITION ID '202401' SETTINGS optimize_throw_if_noop = 1`,
+			expected: &Maintenance{Kind: `OPTIMIZE`, Tables: []TableRef{{Table: `events`}}, PartitionID: `202401`},
+		},
+		{
+			query:    "EXCHANGE TABLES db.a AND `b` ON CLUSTER main",
+			expected: &Maintenance{Kind: `EXCHANGE`, Tables: []TableRef{{Database: `db`, Table: `a`}, {Table: `b`}}, Cluster: `main`},
+		},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			statement, err := ParseMaintenance(test.query)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, statement)
+			assert.Equal(t, test.expected.Kind != `OPTIMIZE`, statement.Destructive())
+		})
+	}
+
+	t.Run(`errors`, func(t *testing.T) {
+		for query, message := range map[string]string{
+			`SELECT 1`:                   `not a maintenance statement`,
+			`OPTIMIZE events`:            `missing TABLE after OPTIMIZE`,
+			`OPTIMIZE TABLE t PARTITION`: `1:18: missing partition expression`,
+			`EXCHANGE TABLES a`:          `expected EXCHANGE TABLES a AND b`,
+			`TRUNCATE TABLE`:             `missing table name`,
+		} {
+			_, err := ParseMaintenance(query)
+			assert.EqualError(t, err, message, query)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"strings"
+)
+
+// Mutation describes a lightweight DELETE FROM or an ALTER TABLE ... UPDATE
+// or DELETE mutation
+type Mutation struct {
+	Kind string // DELETE or UPDATE
+	// Lightweight is set for DELETE FROM, as opposed to ALTER TABLE ... DELETE
+	Lightweight bool
+	Table       TableRef
+	Assignments []Assignment // assignments of UPDATE
+	Partition   Clause       // expression of IN PARTITION, empty if left out
+	Where       Clause
+	// WhereColumns holds the column references of the WHERE predicate, as
+	// Select.Where does
+	WhereColumns []string
+}
+
+// Assignment is a column = expression assignment of ALTER TABLE ... UPDATE
+type Assignment struct {
+	Column string // as written, quotes included
+	Expr   string
+	Pos    Position
+}
+
+// ParseMutation parses the first statement of query, which has to be
+// DELETE FROM t WHERE ..., ALTER TABLE t UPDATE c = v WHERE ... or
+// ALTER TABLE t DELETE WHERE ...
+func ParseMutation(query string) (*Mutation, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	mutation := &Mutation{
+		Assignments: make([]Assignment, 0),
+	}
+	i := e.skipComments(0)
+	switch {
+	case i+1 < len(e.tokens) && isWord(e.tokens[i], "DELETE") && e.tokens[i+1].IsKeyword("FROM"):
+		mutation.Kind = "DELETE"
+		mutation.Lightweight = true
+		mutation.Table, i = readTableName(e.tokens, i+2)
+		i = e.skipOnCluster(i)
+	case i+1 < len(e.tokens) && e.tokens[i].IsKeyword("ALTER") && e.tokens[i+1].IsKeyword("TABLE"):
+		mutation.Table, i = readTableName(e.tokens, i+2)
+		i = e.skipOnCluster(i)
+		if i == len(e.tokens) || (!isWord(e.tokens[i], "UPDATE") && !isWord(e.tokens[i], "DELETE")) {
+			return nil, errors.New("not an UPDATE or DELETE mutation")
+		}
+		mutation.Kind = strings.ToUpper(e.tokens[i].Value)
+		i++
+	default:
+		return nil, errors.New("not a mutation")
+	}
+	if mutation.Table.Table == "" {
+		return nil, errors.New("missing table name")
+	}
+
+	end := len(e.tokens)
+	if settings := e.topLevelWord(i, end, "SETTINGS"); settings >= 0 {
+		end = settings
+	} else if end > 0 && e.tokens[end-1] == statementTerminator {
+		end--
+	}
+	where := e.topLevelWord(i, end, "WHERE")
+	if where < 0 || where+1 == end {
+		return nil, errors.New("missing WHERE")
+	}
+	assignmentsEnd := where
+	if in := e.topLevelWord(i, where, "IN"); in >= 0 && in+2 < where && isWord(e.tokens[in+1], "PARTITION") {
+		mutation.Partition = e.clause(in+2, where-1)
+		assignmentsEnd = in
+	}
+	mutation.Where = e.clause(where+1, end-1)
+	mutation.WhereColumns = e.columnReferences(where+1, end)
+
+	if mutation.Kind == "UPDATE" {
+		items := e.splitList(i, assignmentsEnd)
+		if len(items) == 0 {
+			return nil, e.errorAt(i, "missing assignments")
+		}
+		for _, item := range items {
+			if len(item) < 3 || e.tokens[item[1]].Value != "=" {
+				return nil, e.errorAt(item[0], "expected column = expression")
+			}
+			mutation.Assignments = append(mutation.Assignments, Assignment{
+				Column: e.tokens[item[0]].Value,
+				Expr:   e.sourceText(item[2], item[len(item)-1]),
+				Pos:    e.tokenPosition(item[0]),
+			})
+		}
+	}
+	return mutation, nil
+}
+
+// topLevelWord returns the index of the first word from start up to end
+// outside parentheses, or -1
+func (e *columnExtractor) topLevelWord(start, end int, word string) int {
+	depth := 0
+	for i := start; i < end; i++ {
+		switch token := e.tokens[i]; {
+		case token.Value == "(" || token.Value == "[":
+			depth++
+		case token.Value == ")" || token.Value == "]":
+			depth--
+		case depth == 0 && isWord(token, word):
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,57 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMutation(t *testing.T) {
+	t.Run(`lightweight delete`, func(t *testing.T) {
+		mutation, err := ParseMutation(`DELETE FROM db.events ON CLUSTER main WHERE ts < today() - 30 AND user_id IN (SELECT id FROM banned);`)
+		assert.NoError(t, err)
+		assert.Equal(t, &Mutation{
+			Kind:         `DELETE`,
+			Lightweight:  true,
+			Table:        TableRef{Database: `db`, Table: `events`},
+			Assignments:  []Assignment{},
+			Where:        Clause{Expr: `ts < today() - 30 AND user_id IN (SELECT id FROM banned)`, Pos: Position{Offset: 44, Line: 1, Column: 45}},
+			WhereColumns: []string{`ts`, `user_id`},
+		}, mutation)
+	})
+
+	t.Run(`update`, func(t *testing.T) {
+		mutation, err := ParseMutation("ALTER TABLE t UPDATE `status` = 'done', n = if(n > 0, n - 1, 0) IN PARTITION 202401 WHERE id = {id:UInt64} SETTINGS mutations_sync = 1")
+		assert.NoError(t, err)
+		assert.Equal(t, `UPDATE`, mutation.Kind)
+		assert.False(t, mutation.Lightweight)
+		assert.Equal(t, []Assignment{
+			{Column: "`status`", Expr: `'done'`, Pos: Position{Offset: 21, Line: 1, Column: 22}},
+			{Column: `n`, Expr: `if(n > 0, n - 1, 0)`, Pos: Position{Offset: 40, Line: 1, Column: 41}},
+		}, mutation.Assignments)
+		assert.Equal(t, `202401`, mutation.Partition.Expr)
+		assert.Equal(t, `id = {id:UInt64}`, mutation.Where.Expr)
+		assert.Equal(t, []string{`id`}, mutation.WhereColumns)
+	})
+
+	t.Run(`alter delete`, func(t *testing.T) {
+		mutation, err := ParseMutation(`ALTER TABLE t DELETE WHERE x = 1`)
+		assert.NoError(t, err)
+		assert.Equal(t, `DELETE`, mutation.Kind)
+		assert.Equal(t, `x = 1`, mutation.Where.Expr)
+		assert.Empty(t, mutation.Assignments)
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		for query, message := range map[string]string{
+			`SELECT 1`:                         `not a mutation`,
+			`ALTER TABLE t DROP COLUMN c`:      `not an UPDATE or DELETE mutation`,
+			`DELETE FROM t`:                    `missing WHERE`,
+			`ALTER TABLE t UPDATE WHERE x = 1`: `1:22: missing assignments`,
+			`ALTER TABLE t UPDATE a WHERE 1`:   `1:22: expected column = expression`,
+		} {
+			_, err := ParseMutation(query)
+			assert.EqualError(t, err, message, query)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// Package parsertest provides assertions for tests against the output of the
+// parser, reporting mismatches as a line diff rather than two long dumps.
+// Tokens are compared as values and printed with their String method, so the
+// helpers work with any comparable token, column or node type
+package parsertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// EqualTokens reports a test error with a diff if got isn't want
+func EqualTokens[T comparable](t testing.TB, want, got []T) bool {
+	t.Helper()
+	return equal(t, "tokens", want, got)
+}
+
+// EqualColumns reports a test error with a diff if got isn't want
+func EqualColumns(t testing.TB, want, got []string) bool {
+	t.Helper()
+	return equal(t, "columns", want, got)
+}
+
+// EqualTree reports a test error with a diff if the printed trees differ.
+// Nodes are printed with %v, one line each, indented by depth
+func EqualTree[N any](t testing.TB, want, got N, children func(N) []N) bool {
+	t.Helper()
+	return equal(t, "trees", printTree(want, children), printTree(got, children))
+}
+
+func equal[T comparable](t testing.TB, what string, want, got []T) bool {
+	t.Helper()
+	if len(want) == len(got) {
+		same := true
+		for i := range want {
+			if want[i] != got[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return true
+		}
+	}
+	t.Errorf("%s differ (-want +got):\n%s", what, Diff(want, got))
+	return false
+}
+
+// Diff returns a line diff of two slices, printing each element with %v.
+// Lines only in want are prefixed with -, lines only in got with +
+func Diff[T comparable](want, got []T) string {
+	// lcs[i][j] is the length of the longest common subsequence of want[i:]
+	// and got[j:]
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			fmt.Fprintf(&b, "  %v\n", want[i])
+			i++
+			j++
+		case j == len(got) || (i < len(want) && lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&b, "- %v\n", want[i])
+			i++
+		default:
+			fmt.Fprintf(&b, "+ %v\n", got[j])
+			j++
+		}
+	}
+	return b.String()
+}
+
+func printTree[N any](node N, children func(N) []N) []string {
+	var lines []string
+	var walk func(N, int)
+	walk = func(node N, depth int) {
+		lines = append(lines, strings.Repeat("  ", depth)+fmt.Sprint(node))
+		for _, child := range children(node) {
+			walk(child, depth+1)
+		}
+	}
+	walk(node, 0)
+	return lines
+}
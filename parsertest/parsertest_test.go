@@ -0,0 +1,80 @@
+package parsertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder captures the failures reported by the helpers
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type token struct {
+	kind  string
+	value string
+}
+
+func (t token) String() string {
+	return t.kind + " " + t.value
+}
+
+func TestEqualTokens(t *testing.T) {
+	t.Run(`equal`, func(t *testing.T) {
+		r := &recorder{}
+		assert.True(t, EqualTokens(r, []token{{`Keyword`, `INSERT`}}, []token{{`Keyword`, `INSERT`}}))
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run(`diff`, func(t *testing.T) {
+		r := &recorder{}
+		want := []token{{`Keyword`, `INSERT`}, {`Keyword`, `INTO`}, {`Identifier`, `t`}}
+		got := []token{{`Keyword`, `INSERT`}, {`Identifier`, `t`}, {`Punctuation`, `(`}}
+		assert.False(t, EqualTokens(r, want, got))
+		assert.Equal(t, []string{"tokens differ (-want +got):\n" +
+			"  Keyword INSERT\n" +
+			"- Keyword INTO\n" +
+			"  Identifier t\n" +
+			"+ Punctuation (\n"}, r.errors)
+	})
+}
+
+func TestEqualColumns(t *testing.T) {
+	r := &recorder{}
+	assert.False(t, EqualColumns(r, []string{`a`, `b`}, []string{`a`}))
+	assert.Equal(t, []string{"columns differ (-want +got):\n  a\n- b\n"}, r.errors)
+}
+
+type node struct {
+	name     string
+	children []node
+}
+
+func (n node) String() string {
+	return n.name
+}
+
+func TestEqualTree(t *testing.T) {
+	children := func(n node) []node { return n.children }
+	want := node{`Insert`, []node{{`Table t`, nil}, {`Columns`, []node{{`a`, nil}}}}}
+	got := node{`Insert`, []node{{`Table t`, nil}, {`Columns`, []node{{`b`, nil}}}}}
+
+	r := &recorder{}
+	assert.True(t, EqualTree(r, want, want, children))
+	assert.False(t, EqualTree(r, want, got, children))
+	assert.Equal(t, []string{"trees differ (-want +got):\n" +
+		"  Insert\n" +
+		"    Table t\n" +
+		"    Columns\n" +
+		"-     a\n" +
+		"+     b\n"}, r.errors)
+}
@@ -0,0 +1,224 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+)
+
+// Format writes the syntax tree rooted at node back as SQL in the given
+// style. Keywords take the case of the style, while names, literals and the
+// parts kept as written, e.g. a SELECT or a RawExpr, are left alone. With
+// the OnePerLine and Wrapped styles the clauses of an INSERT and its VALUES
+// rows start lines of their own, so that changes to generated INSERTs show
+// up as line diffs
+func Format(node Node, style FormatStyle) string {
+	p := &printer{style: style}
+	p.node(node)
+	return p.String()
+}
+
+// castPrecedence is the precedence of :: and unary minus and plus, binding
+// tighter than any binary operator
+const castPrecedence = 8
+
+// printer accumulates the text of Format
+type printer struct {
+	strings.Builder
+	style FormatStyle
+}
+
+// line returns the length in runes of the line written so far
+func (p *printer) line() int {
+	text := p.String()
+	return len([]rune(text[strings.LastIndexByte(text, '\n')+1:]))
+}
+
+// separator returns what separates the clauses of a statement
+func (p *printer) separator() string {
+	if p.style.ColumnList == SingleLine {
+		return " "
+	}
+	return "\n"
+}
+
+func (p *printer) node(node Node) {
+	if isNilNode(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *InsertStmt:
+		p.insert(n)
+	case *TableName:
+		if n.Database != nil {
+			p.WriteString(n.Database.Name + ".")
+		}
+		p.WriteString(n.Table.Name)
+	case *ColumnList:
+		columns := make([]string, 0, len(n.Columns))
+		for _, column := range n.Columns {
+			columns = append(columns, Format(column, p.style))
+		}
+		p.style.writeColumnList(&p.Builder, p.line(), columns)
+	case *Setting:
+		p.WriteString(n.Name.Name + " = ")
+		p.node(n.Value)
+	case *ValuesSource:
+		p.WriteString(p.style.keyword("VALUES"))
+		separator, indent := " ", ""
+		if p.style.ColumnList != SingleLine && len(n.Rows) > 0 {
+			indent = strings.Repeat(" ", p.style.Indent)
+			separator = "\n" + indent
+		}
+		for i, row := range n.Rows {
+			if i > 0 {
+				p.WriteString(",")
+			}
+			p.WriteString(separator)
+			p.node(row)
+		}
+	case *Row:
+		p.WriteString("(")
+		p.list(n.Values)
+		p.WriteString(")")
+	case *SelectSource:
+		p.WriteString(n.Query)
+	case *InfileSource:
+		p.WriteString(p.style.keyword("FROM INFILE") + " " + n.File.Value)
+		if n.Compression != nil {
+			p.WriteString(" " + p.style.keyword("COMPRESSION") + " " + n.Compression.Value)
+		}
+	case *DataSource:
+		p.WriteString(n.Data)
+	case *Ident:
+		p.WriteString(n.Name)
+	case *BasicLit:
+		if n.Kind == TokenKeyword {
+			p.WriteString(p.style.keyword(strings.ToUpper(n.Value)))
+		} else {
+			p.WriteString(n.Value)
+		}
+	case *Param:
+		p.WriteString(n.Value)
+	case *Call:
+		p.WriteString(n.Name.Name + "(")
+		p.list(n.Args)
+		p.WriteString(")")
+	case *ArrayLit:
+		p.WriteString("[")
+		p.list(n.Elems)
+		p.WriteString("]")
+	case *TupleLit:
+		p.WriteString("(")
+		p.list(n.Elems)
+		if len(n.Elems) == 1 {
+			p.WriteString(",")
+		}
+		p.WriteString(")")
+	case *ParenExpr:
+		p.WriteString("(")
+		p.node(n.X)
+		p.WriteString(")")
+	case *UnaryExpr:
+		if n.Op == "NOT" {
+			p.WriteString(p.style.keyword("NOT") + " ")
+			p.operand(n.X, notPrecedence, false)
+		} else {
+			p.WriteString(n.Op)
+			if inner, ok := n.X.(*UnaryExpr); ok && inner.Op != "NOT" {
+				// - -1 rather than the comment --1
+				p.WriteString(" ")
+			}
+			p.operand(n.X, castPrecedence, false)
+		}
+	case *BinaryExpr:
+		if n.Op == "::" {
+			p.operand(n.X, castPrecedence, false)
+			p.WriteString("::")
+			p.node(n.Y)
+			return
+		}
+		precedence := binaryOperators[n.Op]
+		p.operand(n.X, precedence, false)
+		op := n.Op
+		if strings.ToUpper(op) != strings.ToLower(op) {
+			op = p.style.keyword(strings.ToUpper(op))
+		}
+		p.WriteString(" " + op + " ")
+		p.operand(n.Y, precedence, true)
+	case *RawExpr:
+		p.WriteString(n.Text)
+	}
+}
+
+// insert writes an INSERT statement, its clauses separated per the style
+func (p *printer) insert(n *InsertStmt) {
+	p.WriteString(p.style.keyword("INSERT INTO") + " ")
+	if n.Function != nil {
+		p.WriteString(p.style.keyword("FUNCTION") + " ")
+		p.node(n.Function)
+	} else {
+		p.node(n.Table)
+	}
+	if n.Columns != nil {
+		p.WriteString(" ")
+		p.node(n.Columns)
+	}
+	if len(n.Settings) > 0 {
+		p.WriteString(p.separator() + p.style.keyword("SETTINGS") + " ")
+		for i, setting := range n.Settings {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.node(setting)
+		}
+	}
+	data, isData := n.Source.(*DataSource)
+	if n.Source != nil && !isData {
+		p.WriteString(p.separator())
+		p.node(n.Source)
+	}
+	if n.Format != nil {
+		p.WriteString(p.separator() + p.style.keyword("FORMAT") + " " + n.Format.Name)
+	}
+	if n.Trailer != nil {
+		p.WriteString(p.separator())
+		p.node(n.Trailer)
+	}
+	if isData {
+		p.WriteString(p.separator())
+		p.node(data)
+	}
+}
+
+// list writes expressions separated by commas
+func (p *printer) list(exprs []Expr) {
+	for i, x := range exprs {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		p.node(x)
+	}
+}
+
+// operand writes x, an operand of an operator of the given precedence,
+// parenthesized if it binds looser, or as loose for the right operand of a
+// left associative operator, so that hand-built trees keep their meaning
+func (p *printer) operand(x Expr, precedence int, right bool) {
+	inner := precedence + 1
+	switch n := x.(type) {
+	case *BinaryExpr:
+		if n.Op != "::" {
+			inner = binaryOperators[n.Op]
+		}
+	case *UnaryExpr:
+		if n.Op == "NOT" {
+			inner = notPrecedence
+		}
+	}
+	if inner < precedence || (right && inner == precedence) {
+		p.WriteString("(")
+		p.node(x)
+		p.WriteString(")")
+		return
+	}
+	p.node(x)
+}
@@ -0,0 +1,80 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatNode(t *testing.T) {
+	query := "insert into db.t (id, name, tags) settings async_insert=1 values (1,'a',[1,2]), (2, NULL, [ ]) , (-3, not x in (1) , f(a)::UInt8)"
+	stmt, err := ParseInsertAST(query)
+	assert.NoError(t, err)
+
+	t.Run(`single line`, func(t *testing.T) {
+		assert.Equal(t, "INSERT INTO db.t (id, name, tags) SETTINGS async_insert = 1 "+
+			"VALUES (1, 'a', [1, 2]), (2, NULL, []), (-3, NOT x IN (1), f(a)::UInt8)", Format(stmt, FormatStyle{}))
+	})
+
+	t.Run(`lower case keywords`, func(t *testing.T) {
+		assert.Equal(t, "insert into db.t (id, name, tags) settings async_insert = 1 "+
+			"values (1, 'a', [1, 2]), (2, null, []), (-3, not x in (1), f(a)::UInt8)", Format(stmt, FormatStyle{Keywords: LowerKeywords}))
+	})
+
+	t.Run(`one per line`, func(t *testing.T) {
+		assert.Equal(t, "INSERT INTO db.t (id,\n"+
+			"                  name,\n"+
+			"                  tags)\n"+
+			"SETTINGS async_insert = 1\n"+
+			"VALUES\n"+
+			"(1, 'a', [1, 2]),\n"+
+			"(2, NULL, []),\n"+
+			"(-3, NOT x IN (1), f(a)::UInt8)", Format(stmt, FormatStyle{ColumnList: OnePerLine}))
+	})
+
+	t.Run(`indented`, func(t *testing.T) {
+		assert.Equal(t, "INSERT INTO db.t (\n"+
+			"    id,\n"+
+			"    name,\n"+
+			"    tags\n"+
+			")\n"+
+			"SETTINGS async_insert = 1\n"+
+			"VALUES\n"+
+			"    (1, 'a', [1, 2]),\n"+
+			"    (2, NULL, []),\n"+
+			"    (-3, NOT x IN (1), f(a)::UInt8)", Format(stmt, FormatStyle{ColumnList: OnePerLine, Indent: 4}))
+	})
+
+	t.Run(`reparses`, func(t *testing.T) {
+		for _, style := range []FormatStyle{{}, {ColumnList: OnePerLine}, {ColumnList: Wrapped, Width: 20, Indent: 2}, {Keywords: LowerKeywords}} {
+			formatted := Format(stmt, style)
+			again, err := ParseInsertAST(formatted)
+			assert.NoError(t, err)
+			assert.Equal(t, formatted, Format(again, style))
+		}
+	})
+
+	t.Run(`sources`, func(t *testing.T) {
+		for query, expected := range map[string]string{
+			`insert into t select a from s`:                                  `INSERT INTO t select a from s`,
+			`INSERT INTO t FROM INFILE 'f.gz' COMPRESSION 'gzip' FORMAT CSV`: `INSERT INTO t FROM INFILE 'f.gz' COMPRESSION 'gzip' FORMAT CSV`,
+			"INSERT INTO t FORMAT CSV 1,2\n":                                 "INSERT INTO t FORMAT CSV 1,2\n",
+			`INSERT INTO FUNCTION s3('u', 'CSV') VALUES`:                     `INSERT INTO FUNCTION s3('u', 'CSV') VALUES`,
+			`INSERT INTO t VALUES (CASE WHEN a THEN 1 END, (1,))`:            `INSERT INTO t VALUES (CASE WHEN a THEN 1 END, (1,))`,
+		} {
+			stmt, err := ParseInsertAST(query)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, Format(stmt, FormatStyle{}))
+		}
+	})
+
+	t.Run(`hand-built`, func(t *testing.T) {
+		// (a + b) * c and a - (b - c) need parentheses the tree doesn't hold
+		a, b, c := &Ident{Name: `a`}, &Ident{Name: `b`}, &Ident{Name: `c`}
+		assert.Equal(t, `(a + b) * c`, Format(&BinaryExpr{X: &BinaryExpr{X: a, Op: `+`, Y: b}, Op: `*`, Y: c}, FormatStyle{}))
+		assert.Equal(t, `a - (b - c)`, Format(&BinaryExpr{X: a, Op: `-`, Y: &BinaryExpr{X: b, Op: `-`, Y: c}}, FormatStyle{}))
+		assert.Equal(t, `a - b - c`, Format(&BinaryExpr{X: &BinaryExpr{X: a, Op: `-`, Y: b}, Op: `-`, Y: c}, FormatStyle{}))
+		assert.Equal(t, `- -a`, Format(&UnaryExpr{Op: `-`, X: &UnaryExpr{Op: `-`, X: a}}, FormatStyle{}))
+		assert.Equal(t, `-(a + b)`, Format(&UnaryExpr{Op: `-`, X: &BinaryExpr{X: a, Op: `+`, Y: b}}, FormatStyle{}))
+	})
+}
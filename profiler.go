@@ -0,0 +1,153 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"cmp"
+	"encoding/json"
+	"expvar"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxProfiles bounds the number of fingerprints a Profiler keeps, further
+// fingerprints are dropped so unbounded query shapes can't exhaust memory
+const maxProfiles = 1000
+
+// QueryProfile aggregates the sampled queries sharing a fingerprint
+type QueryProfile struct {
+	Fingerprint string   `json:"fingerprint"`
+	Count       int64    `json:"count"`    // sampled queries, not all queries observed
+	AvgSize     float64  `json:"avg_size"` // average query length in bytes
+	Tables      []string `json:"tables"`
+	Columns     []string `json:"columns"` // target columns of an INSERT
+}
+
+// Profiler samples a fraction of the queries it observes and aggregates them
+// by fingerprint. It is safe for concurrent use and implements expvar.Var
+type Profiler struct {
+	rate   float64
+	random func() float64
+
+	mu       sync.Mutex
+	profiles map[string]*queryProfile
+}
+
+type queryProfile struct {
+	QueryProfile
+	bytes int64
+}
+
+// NewProfiler returns a Profiler sampling the given fraction of queries,
+// between 0 and 1
+func NewProfiler(rate float64) *Profiler {
+	return &Profiler{
+		rate:     rate,
+		random:   rand.Float64,
+		profiles: make(map[string]*queryProfile),
+	}
+}
+
+// Observe records query if it is sampled. Unsampled queries aren't parsed,
+// keeping the overhead negligible at low rates
+func (p *Profiler) Observe(query string) {
+	if p.random() >= p.rate {
+		return
+	}
+	fingerprint := Fingerprint(query)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	profile, ok := p.profiles[fingerprint]
+	if !ok {
+		if len(p.profiles) >= maxProfiles {
+			return
+		}
+		profile = &queryProfile{QueryProfile: QueryProfile{
+			Fingerprint: fingerprint,
+			Tables:      make([]string, 0, 1),
+			Columns:     make([]string, 0),
+		}}
+		e := &columnExtractor{
+			query: query,
+		}
+		_ = e.parse()
+		for _, ref := range tableRefs(e.tokens) {
+			profile.Tables = append(profile.Tables, ref.String())
+		}
+		if len(e.tokens) > 0 && e.tokens[0].IsKeyword("INSERT") {
+			profile.Columns = e.columns()
+		}
+		p.profiles[fingerprint] = profile
+	}
+	profile.Count++
+	profile.bytes += int64(len(query))
+	profile.AvgSize = float64(profile.bytes) / float64(profile.Count)
+}
+
+// Snapshot returns the aggregated profiles, most frequent first
+func (p *Profiler) Snapshot() []QueryProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	snapshot := make([]QueryProfile, 0, len(p.profiles))
+	for _, profile := range p.profiles {
+		snapshot = append(snapshot, profile.QueryProfile)
+	}
+	slices.SortFunc(snapshot, func(a, b QueryProfile) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Fingerprint, b.Fingerprint))
+	})
+	return snapshot
+}
+
+// String returns the snapshot as JSON, as required by expvar.Var
+func (p *Profiler) String() string {
+	out, err := json.Marshal(p.Snapshot())
+	if err != nil {
+		return "null"
+	}
+	return string(out)
+}
+
+// Publish exposes the profiler under name on the expvar endpoint
+func (p *Profiler) Publish(name string) {
+	expvar.Publish(name, p)
+}
+
+// Fingerprint normalises the first statement of query so that queries
+// differing only in literal values share it: keywords are upper cased,
+// literals and ? placeholders become ? and the data following VALUES or
+// FORMAT is dropped. This is best effort, tokenisation errors are ignored
+func Fingerprint(query string) string {
+	e := &columnExtractor{
+		query: query,
+	}
+	_ = e.parse()
+
+	open, end := -1, -1
+	if len(e.tokens) > 0 && e.tokens[0].IsKeyword("INSERT") {
+		open, end, _ = e.columnList()
+	}
+	parts := make([]string, 0, len(e.tokens))
+	for i, token := range e.tokens {
+		switch {
+		case token == statementTerminator:
+		case token.IsKeyword("VALUES"):
+			return strings.Join(append(parts, "VALUES"), " ")
+		case token.IsKeyword("FORMAT"):
+			parts = append(parts, "FORMAT")
+			if i+1 < len(e.tokens) {
+				parts = append(parts, e.tokens[i+1].Value)
+			}
+			return strings.Join(parts, " ")
+		case token.Kind == TokenKeyword:
+			parts = append(parts, strings.ToUpper(token.Value))
+		case token.Kind == TokenString && (i <= open || i >= end),
+			token.Kind == TokenNumber, token.Kind == TokenPlaceholder:
+			// Single quoted tokens inside the column list are column names
+			parts = append(parts, "?")
+		default:
+			parts = append(parts, token.Value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
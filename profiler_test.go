@@ -0,0 +1,76 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{`values are dropped`, `insert into t (a, b) values (1, 'x'), (2, 'y')`, `INSERT INTO t ( a , b ) VALUES`},
+		{`format data is dropped`, "INSERT INTO t (a) FORMAT CSV\n1\n2", `INSERT INTO t ( a ) FORMAT CSV`},
+		{`literals`, `SELECT a FROM t WHERE b = 'x' AND c > 10 AND d = ?`, `SELECT a FROM t WHERE b = ? AND c > ? AND d = ?`},
+		{`quoted column names are kept`, `INSERT INTO t ('a', b) VALUES ('x', 1)`, `INSERT INTO t ( 'a' , b ) VALUES`},
+		{`first statement only`, `SELECT 1; SELECT 2`, `SELECT ?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Fingerprint(tt.query))
+		})
+	}
+}
+
+func TestProfiler(t *testing.T) {
+	t.Run(`aggregates by fingerprint`, func(t *testing.T) {
+		p := NewProfiler(1)
+		p.Observe(`INSERT INTO db.t (a, b) VALUES (1, 2)`)
+		p.Observe(`INSERT INTO db.t (a, b) VALUES (10, 20)`)
+		p.Observe(`SELECT a FROM u`)
+		assert.Equal(t, []QueryProfile{
+			{Fingerprint: `INSERT INTO db . t ( a , b ) VALUES`, Count: 2, AvgSize: 38, Tables: []string{`db.t`}, Columns: []string{`a`, `b`}},
+			{Fingerprint: `SELECT a FROM u`, Count: 1, AvgSize: 15, Tables: []string{`u`}, Columns: []string{}},
+		}, p.Snapshot())
+	})
+
+	t.Run(`samples a fraction`, func(t *testing.T) {
+		p := NewProfiler(0.5)
+		draws := []float64{0.1, 0.7, 0.4, 0.5}
+		p.random = func() float64 {
+			draw := draws[0]
+			draws = draws[1:]
+			return draw
+		}
+		for range 4 {
+			p.Observe(`SELECT 1`)
+		}
+		assert.Equal(t, int64(2), p.Snapshot()[0].Count)
+	})
+
+	t.Run(`zero rate`, func(t *testing.T) {
+		p := NewProfiler(0)
+		p.Observe(`SELECT 1`)
+		assert.Empty(t, p.Snapshot())
+	})
+
+	t.Run(`bounded`, func(t *testing.T) {
+		p := NewProfiler(1)
+		for i := range maxProfiles + 10 {
+			p.Observe(`SELECT a` + string(rune('a'+i%26)) + string(rune('a'+i/26%26)) + string(rune('a'+i/676)))
+		}
+		assert.Len(t, p.Snapshot(), maxProfiles)
+	})
+
+	t.Run(`expvar`, func(t *testing.T) {
+		p := NewProfiler(1)
+		p.Observe(`SELECT 1`)
+		var snapshot []QueryProfile
+		assert.NoError(t, json.Unmarshal([]byte(p.String()), &snapshot))
+		assert.Equal(t, p.Snapshot(), snapshot)
+	})
+}
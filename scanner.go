@@ -0,0 +1,93 @@
+package clickhouse_go_insert_statement_parsing
+
+import "errors"
+
+// Scanner hands out the tokens of a query one at a time, for callers writing
+// their own parsers on top of the tokenizer. Unlike parse it carries on past
+// the end of a statement, returning the terminating ; as a Punctuation token
+type Scanner struct {
+	// KeepComments returns comments as tokens instead of skipping them
+	KeepComments bool
+	// Intern, when set, deduplicates identifier and keyword values
+	Intern *InternTable
+	// Dialect sets the quoting, escaping and comment rules, ClickHouse if nil
+	Dialect Dialect
+
+	// e holds the tokens scanned so far, emitted as parse does so that both
+	// classify and replace invalid UTF-8 in tokens alike
+	e    columnExtractor
+	next int // index in e.tokens of the token Next returns
+	errs []error
+}
+
+// NewScanner returns a Scanner positioned at the start of query
+func NewScanner(query string) *Scanner {
+	return &Scanner{
+		e: columnExtractor{
+			query: query,
+		},
+	}
+}
+
+// Next returns the next token, or false once the query is exhausted
+func (s *Scanner) Next() (Token, bool) {
+	if !s.fill() {
+		return Token{}, false
+	}
+	s.next++
+	return s.e.tokens[s.next-1], true
+}
+
+// Peek returns the next token without consuming it
+func (s *Scanner) Peek() (Token, bool) {
+	if !s.fill() {
+		return Token{}, false
+	}
+	return s.e.tokens[s.next], true
+}
+
+// Backup steps back so that the token last returned by Next is returned again.
+// It may be called repeatedly to step back several tokens and reports false
+// when already at the start of the query
+func (s *Scanner) Backup() bool {
+	if s.next == 0 {
+		return false
+	}
+	s.next--
+	return true
+}
+
+// Pos returns the position of the token last returned by Next, or the start
+// of the query before the first call
+func (s *Scanner) Pos() Position {
+	if s.next == 0 {
+		return s.e.position(0)
+	}
+	return s.e.tokenPosition(s.next - 1)
+}
+
+// Err returns the errors met while scanning so far. Unexpected runes are
+// skipped, so scanning may carry on after an error
+func (s *Scanner) Err() error {
+	return errors.Join(s.errs...)
+}
+
+// fill scans until a token is available at next, reporting false at the end
+func (s *Scanner) fill() bool {
+	for s.next >= len(s.e.tokens) {
+		if s.e.byteIndex >= len(s.e.query) {
+			return false
+		}
+		s.e.keepComments = s.KeepComments
+		s.e.dialect = s.Dialect
+		s.e.intern = s.Intern
+		token, ok, err := s.e.scan()
+		if err != nil {
+			s.errs = append(s.errs, s.e.syntaxError(err))
+		}
+		if ok {
+			s.e.emit(token)
+		}
+	}
+	return true
+}
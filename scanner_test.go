@@ -0,0 +1,112 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanner(t *testing.T) {
+	t.Run(`next and peek`, func(t *testing.T) {
+		s := NewScanner(`INSERT INTO t (a)`)
+		token, ok := s.Peek()
+		assert.True(t, ok)
+		assert.Equal(t, `INSERT`, token.Value)
+		token, ok = s.Next()
+		assert.True(t, ok)
+		assert.Equal(t, `INSERT`, token.Value)
+		token, _ = s.Next()
+		assert.Equal(t, `INTO`, token.Value)
+
+		values := make([]string, 0)
+		for token, ok := s.Next(); ok; token, ok = s.Next() {
+			values = append(values, token.Value)
+		}
+		assert.Equal(t, []string{`t`, `(`, `a`, `)`}, values)
+		_, ok = s.Peek()
+		assert.False(t, ok)
+		assert.NoError(t, s.Err())
+	})
+
+	t.Run(`backup`, func(t *testing.T) {
+		s := NewScanner(`a b c`)
+		s.Next()
+		s.Next()
+		assert.True(t, s.Backup())
+		assert.True(t, s.Backup())
+		assert.False(t, s.Backup())
+		token, _ := s.Next()
+		assert.Equal(t, `a`, token.Value)
+		s.Next()
+		s.Next()
+		_, ok := s.Next()
+		assert.False(t, ok)
+		assert.True(t, s.Backup())
+		token, _ = s.Next()
+		assert.Equal(t, `c`, token.Value)
+	})
+
+	t.Run(`continues past statements and errors`, func(t *testing.T) {
+		s := NewScanner("SELECT ?; SELECT € ?")
+		tokens := make([]Token, 0)
+		for token, ok := s.Next(); ok; token, ok = s.Next() {
+			tokens = append(tokens, token)
+		}
+		assert.Equal(t, []Token{
+			{Kind: TokenKeyword, Value: `SELECT`},
+			{Kind: TokenPlaceholder, Value: `?`, Ordinal: 1},
+			statementTerminator,
+			{Kind: TokenKeyword, Value: `SELECT`},
+			{Kind: TokenPlaceholder, Value: `?`, Ordinal: 1},
+		}, tokens)
+		assert.EqualError(t, s.Err(), `1:18: unexpected rune: €`)
+	})
+
+	t.Run(`comments`, func(t *testing.T) {
+		s := NewScanner(`a /* b */ c`)
+		s.KeepComments = true
+		s.Next()
+		token, _ := s.Next()
+		assert.Equal(t, Token{Kind: TokenComment, Value: `/* b */`}, token)
+	})
+
+	t.Run(`classifies tokens as parse does`, func(t *testing.T) {
+		for _, query := range []string{
+			`SELECT db.values, t.1, x.insert FROM t`,
+			"INSERT INTO t (`a\xff`, b) VALUES ('\xfe', 1.5e3) -- done",
+		} {
+			e := &columnExtractor{query: query, keepComments: true}
+			assert.NoError(t, e.parse())
+			s := NewScanner(query)
+			s.KeepComments = true
+			tokens := make([]Token, 0)
+			for token, ok := s.Next(); ok; token, ok = s.Next() {
+				tokens = append(tokens, token)
+			}
+			assert.Equal(t, e.tokens, tokens, query)
+		}
+		s := NewScanner(`x.values`)
+		s.Next()
+		s.Next()
+		token, _ := s.Next()
+		assert.Equal(t, Token{Kind: TokenIdentifier, Value: `values`}, token)
+	})
+
+	t.Run(`empty`, func(t *testing.T) {
+		s := NewScanner(`  `)
+		_, ok := s.Next()
+		assert.False(t, ok)
+		assert.False(t, s.Backup())
+	})
+}
+
+func TestScannerPos(t *testing.T) {
+	s := NewScanner("SELECT\n  a")
+	assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, s.Pos())
+	s.Next()
+	assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, s.Pos())
+	s.Next()
+	assert.Equal(t, Position{Offset: 9, Line: 2, Column: 3}, s.Pos())
+	s.Backup()
+	assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, s.Pos())
+}
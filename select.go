@@ -0,0 +1,521 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Select describes the first statement of a query that is a SELECT
+type Select struct {
+	// Columns holds the expressions of the select list and their aliases
+	Columns []SelectItem
+	// With holds the definitions of the WITH clause preceding the SELECT
+	With []CTE
+	// Tables holds the tables and table functions read by FROM and JOIN,
+	// subqueries included, leaving out the common table expressions of With
+	Tables []AliasedTable
+	// Where holds the column references of the WHERE clause in order of
+	// appearance, qualified ones such as t.a included, best effort
+	Where []string
+	// GroupBy holds the expressions of GROUP BY and OrderBy those of ORDER BY
+	// with their direction
+	GroupBy []string
+	OrderBy []OrderItem
+
+	e        *columnExtractor
+	selectAt int // index of the SELECT keyword in e.tokens
+}
+
+// ParseSelect tokenizes the first statement of query, which has to be a
+// SELECT, possibly preceded by WITH, and parses its select list, which ends
+// at FROM or any other clause
+func ParseSelect(query string) (*Select, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	first := e.skipComments(0)
+	if first == len(e.tokens) || (!e.tokens[first].IsKeyword("SELECT") && !e.tokens[first].IsKeyword("WITH")) {
+		return nil, errors.New("not a SELECT statement")
+	}
+	i := e.findClause(first, "SELECT")
+	if i < 0 {
+		return nil, errors.New("missing SELECT after WITH")
+	}
+	s := &Select{
+		Columns:  e.selectList(i),
+		e:        e,
+		selectAt: i,
+	}
+	if e.tokens[first].IsKeyword("WITH") {
+		s.With = e.withClause(first, i)
+	}
+	s.Tables = e.fromTables(first, s.With)
+	if where := e.selectClause(i, "WHERE"); where >= 0 {
+		s.Where = e.columnReferences(where+1, e.clauseEnd(where+1))
+	}
+	if group := e.selectClause(i, "GROUP"); group >= 0 {
+		s.GroupBy = e.groupBy(group+2, e.clauseEnd(group+1))
+	}
+	if order := e.selectClause(i, "ORDER"); order >= 0 {
+		s.OrderBy = e.orderBy(order+2, e.clauseEnd(order+1))
+	}
+	return s, nil
+}
+
+// OrderItem is an expression of ORDER BY
+type OrderItem struct {
+	Expr string
+	Desc bool
+}
+
+// selectClause returns the index of the clause starting with keyword of the
+// SELECT at index i, or -1
+func (e *columnExtractor) selectClause(i int, keyword string) int {
+	for i = e.clauseEnd(i + 1); i < len(e.tokens); i = e.clauseEnd(i + 1) {
+		switch {
+		case e.tokens[i] == statementTerminator || e.tokens[i].IsKeyword("UNION"):
+			return -1
+		case isWord(e.tokens[i], keyword):
+			return i
+		}
+	}
+	return -1
+}
+
+// groupBy returns the expressions of GROUP BY from start up to end, leaving
+// out modifiers such as WITH TOTALS
+func (e *columnExtractor) groupBy(start, end int) []string {
+	expressions := make([]string, 0)
+	for _, item := range e.splitList(start, end) {
+		if with := slices.IndexFunc(item, func(i int) bool { return e.tokens[i].IsKeyword("WITH") }); with >= 0 {
+			item = item[:with]
+		}
+		if len(item) > 0 {
+			expressions = append(expressions, e.sourceText(item[0], item[len(item)-1]))
+		}
+	}
+	return expressions
+}
+
+// orderModifiers holds the words that can follow an expression of ORDER BY
+var orderModifiers = []string{"ASC", "ASCENDING", "DESC", "DESCENDING", "NULLS", "COLLATE", "WITH"}
+
+// orderBy returns the expressions of ORDER BY from start up to end
+func (e *columnExtractor) orderBy(start, end int) []OrderItem {
+	items := make([]OrderItem, 0)
+	for _, item := range e.splitList(start, end) {
+		n := slices.IndexFunc(item, func(i int) bool {
+			return slices.ContainsFunc(orderModifiers, func(word string) bool { return isWord(e.tokens[i], word) })
+		})
+		if n == 0 {
+			continue
+		}
+		order := OrderItem{}
+		if n < 0 {
+			n = len(item)
+		} else {
+			order.Desc = isWord(e.tokens[item[n]], "DESC") || isWord(e.tokens[item[n]], "DESCENDING")
+		}
+		order.Expr = e.sourceText(item[0], item[n-1])
+		items = append(items, order)
+	}
+	return items
+}
+
+// selectClauses holds the keywords ending a clause of a SELECT at the top
+// level
+var selectClauses = append([]string{"PREWHERE", "WINDOW", "QUALIFY"}, selectListEnd...)
+
+// clauseEnd returns the index of the token ending the clause of a SELECT
+// starting at start: the next clause at the top level, a ; or the end
+func (e *columnExtractor) clauseEnd(start int) int {
+	depth := 0
+	for i := start; i < len(e.tokens); i++ {
+		token := e.tokens[i]
+		switch {
+		case token == statementTerminator:
+			return i
+		case token.Value == "(" || token.Value == "[":
+			depth++
+		case token.Value == ")" || token.Value == "]":
+			depth--
+		case depth == 0 && slices.ContainsFunc(selectClauses, func(word string) bool { return isWord(token, word) }):
+			return i
+		}
+	}
+	return len(e.tokens)
+}
+
+// notColumns holds the words of expressions that aren't keywords and are
+// no column references either
+var notColumns = []string{
+	"LIKE", "ILIKE", "BETWEEN", "IS", "INTERVAL", "CASE", "WHEN", "THEN", "ELSE", "END", "GLOBAL", "TRUE", "FALSE",
+	"SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "QUARTER", "YEAR",
+}
+
+// columnReferences returns the identifiers from start up to end that refer
+// to columns, skipping function names, types following :: and subqueries
+func (e *columnExtractor) columnReferences(start, end int) []string {
+	references := make([]string, 0)
+	for i := start; i < end; i++ {
+		token := e.tokens[i]
+		switch {
+		case token.Value == "(" && i+1 < end && e.tokens[i+1].IsKeyword("SELECT"):
+			i = e.skipParentheses(i) - 1
+		case token.Value == "::":
+			// The type of a cast
+			i = e.skipName(i + 1)
+			if i < end && e.tokens[i].Value == "(" {
+				i = e.skipParentheses(i)
+			}
+			i--
+		case !token.isIdentifier():
+		case i+1 < end && e.tokens[i+1].Value == "(":
+			// A function name
+		case token.Kind == TokenIdentifier && slices.ContainsFunc(notColumns, func(word string) bool {
+			return strings.EqualFold(token.Value, word)
+		}):
+		case i > start && e.tokens[i-1].Value == "." && len(references) > 0:
+			references[len(references)-1] += "." + token.Value
+		default:
+			references = append(references, token.Value)
+		}
+	}
+	return references
+}
+
+// AliasedTable is a table or table function read by FROM or JOIN along with
+// its alias, empty without one
+type AliasedTable struct {
+	TableRef
+	Alias string
+}
+
+// notAliases holds the words that can follow a table in FROM or JOIN and
+// aren't keywords, so they aren't taken for its alias
+var notAliases = []string{
+	"FINAL", "SAMPLE", "PREWHERE", "ARRAY", "LEFT", "RIGHT", "INNER", "OUTER", "FULL", "CROSS", "GLOBAL",
+	"ANY", "ALL", "ASOF", "SEMI", "ANTI", "PASTE", "WINDOW", "QUALIFY", "INTERSECT", "EXCEPT",
+}
+
+// fromTables returns the tables following FROM and JOIN from the token at
+// index start on, at any depth, leaving out the names of ctes
+func (e *columnExtractor) fromTables(start int, ctes []CTE) []AliasedTable {
+	tables := make([]AliasedTable, 0)
+	for i := start; i < len(e.tokens); i++ {
+		if !e.tokens[i].IsKeyword("FROM") && !e.tokens[i].IsKeyword("JOIN") {
+			continue
+		}
+		ref, next := readTableRef(e.tokens, i+1)
+		if ref.Table == "" && ref.Function == "" {
+			continue
+		}
+		if ref.Database == "" && slices.ContainsFunc(ctes, func(cte CTE) bool { return cte.Subquery && cte.Name == ref.Table }) {
+			continue
+		}
+		if ref.Function != "" {
+			next = e.skipParentheses(next)
+		}
+		table := AliasedTable{TableRef: ref}
+		if next < len(e.tokens) && e.tokens[next].IsKeyword("AS") {
+			next++
+		}
+		if next < len(e.tokens) && e.tokens[next].isIdentifier() &&
+			!slices.ContainsFunc(notAliases, func(word string) bool { return strings.EqualFold(e.tokens[next].Value, word) }) {
+			table.Alias = e.tokens[next].Value
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// SelectItem is an expression of a select list along with its alias
+type SelectItem struct {
+	Expr  string // source text of the expression, e.g. count(*)
+	Alias string // name given with or without AS, as written, empty without one
+	// Except holds the columns of * EXCEPT (a, b) and Replace the expressions
+	// and the columns they replace of * REPLACE (expr AS a)
+	Except  []string
+	Replace []SelectItem
+	// Matcher is set for COLUMNS('regexp') and COLUMNS(a, b)
+	Matcher *ColumnsMatcher
+}
+
+// ColumnsMatcher is a COLUMNS('regexp') or COLUMNS(a, b) matcher, which
+// stands for the columns of a table whose names match the regular expression
+// or are listed
+type ColumnsMatcher struct {
+	Qualifier string   // table of t.COLUMNS(...), empty without one
+	Pattern   string   // decoded regular expression
+	Columns   []string // listed columns, as written
+}
+
+// Expand returns the columns of schema the matcher stands for, in schema
+// order, e.g. those a SchemaResolver returns for the table
+func (m *ColumnsMatcher) Expand(schema []string) ([]string, error) {
+	if m.Pattern == "" && len(m.Columns) > 0 {
+		expanded := make([]string, 0, len(m.Columns))
+		for _, column := range schema {
+			if slices.ContainsFunc(m.Columns, func(listed string) bool { return unquoteIdentifier(listed) == column }) {
+				expanded = append(expanded, column)
+			}
+		}
+		return expanded, nil
+	}
+	pattern, err := regexp.Compile(m.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	expanded := make([]string, 0, len(schema))
+	for _, column := range schema {
+		if pattern.MatchString(column) {
+			expanded = append(expanded, column)
+		}
+	}
+	return expanded, nil
+}
+
+// ParseColumnsMatcher parses expr, e.g. a select list expression or a column
+// of an INSERT, as a COLUMNS matcher, reporting false if it isn't one
+func ParseColumnsMatcher(expr string) (*ColumnsMatcher, bool) {
+	e := &columnExtractor{
+		query: expr,
+	}
+	if err := e.parse(); err != nil || len(e.tokens) == 0 {
+		return nil, false
+	}
+	items := make([]int, 0, len(e.tokens))
+	for i := range e.tokens {
+		items = append(items, i)
+	}
+	matcher := e.columnsMatcher(items)
+	return matcher, matcher != nil
+}
+
+// isColumnsMatcher reports whether the token at index i starts COLUMNS(
+func (e *columnExtractor) isColumnsMatcher(i int) bool {
+	return isWord(e.tokens[i], "COLUMNS") && i+1 < len(e.tokens) && e.tokens[i+1].Value == "("
+}
+
+// columnsMatcher parses the COLUMNS matcher starting the tokens at the given
+// indexes, possibly qualified, or returns nil
+func (e *columnExtractor) columnsMatcher(item []int) *ColumnsMatcher {
+	matcher := &ColumnsMatcher{}
+	n := 0
+	if len(item) > 2 && e.tokens[item[0]].isIdentifier() && e.tokens[item[1]].Value == "." {
+		matcher.Qualifier = e.tokens[item[0]].Value
+		n = 2
+	}
+	if !e.isColumnsMatcher(item[n]) {
+		return nil
+	}
+	close := e.matchingParenthesis(item[n] + 1)
+	if close < 0 {
+		return nil
+	}
+	arguments := e.splitList(item[n]+2, close)
+	if len(arguments) == 1 && len(arguments[0]) == 1 && e.tokens[arguments[0][0]].Kind == TokenString {
+		pattern, err := e.tokens[arguments[0][0]].DecodedValue()
+		if err != nil {
+			return nil
+		}
+		matcher.Pattern = pattern
+		return matcher
+	}
+	for _, argument := range arguments {
+		matcher.Columns = append(matcher.Columns, compactTokens(e.tokens[argument[0]:argument[len(argument)-1]+1]))
+	}
+	return matcher
+}
+
+// compactTokens renders tokens without spaces, except after commas
+func compactTokens(tokens []Token) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 && tokens[i-1].Value == "," {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.Value)
+	}
+	return b.String()
+}
+
+// CTE is a definition of a WITH clause, either a common table expression
+// written name AS (query) or a ClickHouse expression written expr AS name
+type CTE struct {
+	Name     string
+	Expr     string // query without its parentheses, or expression
+	Subquery bool   // whether Expr is a query
+}
+
+// withClause returns the definitions between the WITH keyword at index with
+// and the SELECT at index body
+func (e *columnExtractor) withClause(with, body int) []CTE {
+	var definitions []CTE
+	for _, item := range e.splitList(with+1, body) {
+		definitions = append(definitions, e.cte(item))
+	}
+	return definitions
+}
+
+// splitList groups the indexes of the tokens from start up to end at the
+// top-level commas, leaving out comments and empty items
+func (e *columnExtractor) splitList(start, end int) [][]int {
+	var items [][]int
+	var item []int
+	depth := 0
+	for i := start; i <= end; i++ {
+		if i == end || (depth == 0 && e.tokens[i].Value == ",") {
+			if len(item) > 0 {
+				items = append(items, item)
+			}
+			item = nil
+			continue
+		}
+		switch e.tokens[i].Value {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+		if e.tokens[i].Kind != TokenComment {
+			item = append(item, i)
+		}
+	}
+	return items
+}
+
+// cte splits the tokens of a WITH definition into its name and expression
+func (e *columnExtractor) cte(item []int) CTE {
+	n := len(item)
+	if n > 3 && e.tokens[item[1]].IsKeyword("AS") && e.tokens[item[2]].Value == "(" && e.tokens[item[n-1]].Value == ")" {
+		return CTE{
+			Name:     e.tokens[item[0]].Value,
+			Expr:     e.sourceText(item[3], item[n-2]),
+			Subquery: true,
+		}
+	}
+	if n > 2 && e.tokens[item[n-2]].IsKeyword("AS") {
+		return CTE{Name: e.tokens[item[n-1]].Value, Expr: e.sourceText(item[0], item[n-3])}
+	}
+	return CTE{Expr: e.sourceText(item[0], item[n-1])}
+}
+
+// selectListEnd holds the keywords ending a select list at the top level
+var selectListEnd = []string{
+	"FROM", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "UNION", "SETTINGS", "FORMAT",
+}
+
+// selectList returns the top-level expressions of the select list following
+// the SELECT keyword at index i
+func (e *columnExtractor) selectList(i int) []SelectItem {
+	start := e.skipComments(i + 1)
+	if start < len(e.tokens) && e.tokens[start].IsKeyword("DISTINCT") {
+		start++
+	}
+	var items []SelectItem
+	var item []int // indexes of the tokens of the current expression
+	depth := 0
+	for i := start; i <= len(e.tokens); i++ {
+		end := i == len(e.tokens) || e.tokens[i] == statementTerminator
+		if !end && depth == 0 {
+			for _, keyword := range selectListEnd {
+				end = end || e.tokens[i].IsKeyword(keyword)
+			}
+		}
+		if end || (depth == 0 && e.tokens[i].Value == ",") {
+			if len(item) > 0 {
+				items = append(items, e.selectItem(item))
+			}
+			if end {
+				break
+			}
+			item = item[:0]
+			continue
+		}
+		switch e.tokens[i].Value {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+		if e.tokens[i].Kind != TokenComment {
+			item = append(item, i)
+		}
+	}
+	return items
+}
+
+// selectItem splits the tokens of a select list expression into the
+// expression and its alias
+func (e *columnExtractor) selectItem(item []int) SelectItem {
+	if star := slices.IndexFunc(item, func(i int) bool { return e.tokens[i].Value == "*" }); star >= 0 && star+1 < len(item) &&
+		(isWord(e.tokens[item[star+1]], "EXCEPT") || isWord(e.tokens[item[star+1]], "REPLACE")) {
+		return e.starModifiers(item, star)
+	}
+	if matcher := e.columnsMatcher(item); matcher != nil {
+		selected := e.plainSelectItem(item)
+		selected.Matcher = matcher
+		return selected
+	}
+	return e.plainSelectItem(item)
+}
+
+// plainSelectItem splits the tokens of an expression into the expression
+// and its alias
+func (e *columnExtractor) plainSelectItem(item []int) SelectItem {
+	last := e.tokens[item[len(item)-1]]
+	if len(item) > 1 && last.isIdentifier() {
+		previous := e.tokens[item[len(item)-2]]
+		switch {
+		case previous.IsKeyword("AS") && len(item) > 2:
+			return SelectItem{Expr: e.sourceText(item[0], item[len(item)-3]), Alias: last.Value}
+		case previous.isIdentifier() || previous.Kind == TokenString ||
+			previous.Value == ")" || previous.Value == "]":
+			return SelectItem{Expr: e.sourceText(item[0], item[len(item)-2]), Alias: last.Value}
+		}
+	}
+	return SelectItem{Expr: e.sourceText(item[0], item[len(item)-1])}
+}
+
+// starModifiers parses * or t.* followed by EXCEPT (a, b) or REPLACE (expr
+// AS a), with or without parentheses around a single column
+func (e *columnExtractor) starModifiers(item []int, star int) SelectItem {
+	selected := SelectItem{Expr: e.sourceText(item[0], item[star])}
+	for n := star + 1; n+1 < len(item); {
+		modifier := e.tokens[item[n]]
+		first, last := n+1, n+1
+		if e.tokens[item[first]].Value == "(" {
+			last = slices.Index(item, e.matchingParenthesis(item[first]))
+			if last < 0 {
+				break
+			}
+			first++
+		} else {
+			last++
+		}
+		// first up to last, excluded, holds the columns of the modifier
+		for _, column := range e.splitList(item[first], item[last-1]+1) {
+			if isWord(modifier, "EXCEPT") {
+				selected.Except = append(selected.Except, e.sourceText(column[0], column[len(column)-1]))
+			} else {
+				selected.Replace = append(selected.Replace, e.selectItem(column))
+			}
+		}
+		n = last
+		if n < len(item) && e.tokens[item[n]].Value == ")" {
+			n++
+		}
+	}
+	return selected
+}
+
+// sourceText returns the query text from the first to the last token
+func (e *columnExtractor) sourceText(first, last int) string {
+	return e.query[e.offsets[first]:e.tokenEnd(last)]
+}
@@ -0,0 +1,201 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSelect(t *testing.T) {
+	tests := []struct {
+		query   string
+		columns []SelectItem
+		with    []CTE
+		err     string
+	}{
+		{
+			query:   `SELECT x AS a, y b, count(*) FROM t`,
+			columns: []SelectItem{{Expr: `x`, Alias: `a`}, {Expr: `y`, Alias: `b`}, {Expr: `count(*)`}},
+		},
+		{
+			query:   `select distinct t.a, if(b > 1, 'x', 'y') AS "flag" where 1`,
+			columns: []SelectItem{{Expr: `t.a`}, {Expr: `if(b > 1, 'x', 'y')`, Alias: `"flag"`}},
+		},
+		{
+			query:   `WITH 2 AS two, cte AS (SELECT 1 AS one) SELECT one * two AS n FROM cte`,
+			columns: []SelectItem{{Expr: `one * two`, Alias: `n`}},
+			with:    []CTE{{Name: `two`, Expr: `2`}, {Name: `cte`, Expr: `SELECT 1 AS one`, Subquery: true}},
+		},
+		{
+			query:   `SELECT 1 UNION ALL SELECT 2`,
+			columns: []SelectItem{{Expr: `1`}},
+		},
+		{
+			// invalid UTF-8, replaced in the tokens, is kept in the source text
+			query:   "SELECT 'a\xff', concat(`\xfe`, 'b') AS c",
+			columns: []SelectItem{{Expr: "'a\xff'"}, {Expr: "concat(`\xfe`, 'b')", Alias: `c`}},
+		},
+		{
+			query: `INSERT INTO t SELECT 1`,
+			err:   `not a SELECT statement`,
+		},
+		{
+			query: `WITH x AS (SELECT 1)`,
+			err:   `missing SELECT after WITH`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, err := ParseSelect(tt.query)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.columns, s.Columns)
+			assert.Equal(t, tt.with, s.With)
+		})
+	}
+}
+
+func TestFromTables(t *testing.T) {
+	tests := []struct {
+		query  string
+		tables []AliasedTable
+	}{
+		{
+			query: `SELECT * FROM db.events AS e FINAL JOIN users u ON u.id = e.user LEFT JOIN numbers(10) n USING (x)`,
+			tables: []AliasedTable{
+				{TableRef: TableRef{Database: `db`, Table: `events`}, Alias: `e`},
+				{TableRef: TableRef{Table: `users`}, Alias: `u`},
+				{TableRef: TableRef{Function: `numbers`}, Alias: `n`},
+			},
+		},
+		{
+			query: "SELECT a FROM (SELECT a FROM `t1`) AS s GLOBAL ANY JOIN t2 USING (a) WHERE a IN (SELECT a FROM t3)",
+			tables: []AliasedTable{
+				{TableRef: TableRef{Table: `t1`}},
+				{TableRef: TableRef{Table: `t2`}},
+				{TableRef: TableRef{Table: `t3`}},
+			},
+		},
+		{
+			query:  `WITH cte AS (SELECT 1 FROM src) SELECT * FROM cte`,
+			tables: []AliasedTable{{TableRef: TableRef{Table: `src`}}},
+		},
+		{
+			query:  `SELECT 1`,
+			tables: []AliasedTable{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, err := ParseSelect(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.tables, s.Tables)
+		})
+	}
+
+	t.Run(`insert select`, func(t *testing.T) {
+		insert, err := ParseInsert(`INSERT INTO dst (a) WITH x AS (SELECT a FROM src) SELECT a FROM x JOIN other o USING (a)`)
+		assert.NoError(t, err)
+		assert.Equal(t, []AliasedTable{
+			{TableRef: TableRef{Table: `src`}},
+			{TableRef: TableRef{Table: `other`}, Alias: `o`},
+		}, insert.Tables)
+	})
+}
+
+func TestWhereColumns(t *testing.T) {
+	tests := []struct {
+		query string
+		where []string
+	}{
+		{
+			query: `SELECT a FROM t WHERE b = 1 AND lower(c) LIKE 'x%' OR t.d IN (SELECT e FROM u WHERE f) ORDER BY a`,
+			where: []string{`b`, `c`, `t.d`},
+		},
+		{
+			query: "SELECT * FROM t WHERE `ts` > now() - INTERVAL 1 DAY AND x::Nullable(Int8) IS NULL AND \"y\" BETWEEN 1 AND 2 GROUP BY z",
+			where: []string{"`ts`", `x`, `"y"`},
+		},
+		{
+			query: `SELECT * FROM t WHERE b = 1 AND b > 0 LIMIT 1`,
+			where: []string{`b`, `b`},
+		},
+		{
+			query: `SELECT (SELECT 1 WHERE inner) FROM t`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, err := ParseSelect(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.where, s.Where)
+		})
+	}
+}
+
+func TestGroupByOrderBy(t *testing.T) {
+	s, err := ParseSelect(`SELECT a, count() FROM t WHERE b GROUP BY a, toDate(ts) WITH TOTALS HAVING count() > 1 ` +
+		`ORDER BY a DESC, toDate(ts) ASC NULLS FIRST, lower(c) COLLATE 'en', d LIMIT 10`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`a`, `toDate(ts)`}, s.GroupBy)
+	assert.Equal(t, []OrderItem{
+		{Expr: `a`, Desc: true},
+		{Expr: `toDate(ts)`},
+		{Expr: `lower(c)`},
+		{Expr: `d`},
+	}, s.OrderBy)
+
+	s, err = ParseSelect(`SELECT a FROM (SELECT a FROM t ORDER BY a) UNION ALL SELECT b FROM u GROUP BY b`)
+	assert.NoError(t, err)
+	assert.Nil(t, s.GroupBy)
+	assert.Nil(t, s.OrderBy)
+}
+
+func TestStarModifiers(t *testing.T) {
+	s, err := ParseSelect(`SELECT * EXCEPT (a, b), t.* REPLACE (x + 1 AS x, lower(y) AS y), * EXCEPT c, * EXCEPT (d) REPLACE (1 AS e), z FROM t`)
+	assert.NoError(t, err)
+	assert.Equal(t, []SelectItem{
+		{Expr: `*`, Except: []string{`a`, `b`}},
+		{Expr: `t.*`, Replace: []SelectItem{{Expr: `x + 1`, Alias: `x`}, {Expr: `lower(y)`, Alias: `y`}}},
+		{Expr: `*`, Except: []string{`c`}},
+		{Expr: `*`, Except: []string{`d`}, Replace: []SelectItem{{Expr: `1`, Alias: `e`}}},
+		{Expr: `z`},
+	}, s.Columns)
+}
+
+func TestColumnsMatcher(t *testing.T) {
+	s, err := ParseSelect(`SELECT COLUMNS('^metric_\\d+') APPLY(sum), t.COLUMNS(a, b), COLUMNS('x') AS c, columns FROM t`)
+	assert.NoError(t, err)
+	assert.Equal(t, []SelectItem{
+		{Expr: `COLUMNS('^metric_\\d+') APPLY(sum)`, Matcher: &ColumnsMatcher{Pattern: `^metric_\d+`}},
+		{Expr: `t.COLUMNS(a, b)`, Matcher: &ColumnsMatcher{Qualifier: `t`, Columns: []string{`a`, `b`}}},
+		{Expr: `COLUMNS('x')`, Alias: `c`, Matcher: &ColumnsMatcher{Pattern: `x`}},
+		{Expr: `columns`},
+	}, s.Columns)
+
+	t.Run(`insert`, func(t *testing.T) {
+		insert, err := ParseInsert(`INSERT INTO t (id, COLUMNS('^m'), COLUMNS(a,b)) VALUES`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`id`, `COLUMNS('^m')`, `COLUMNS(a, b)`}, insert.Columns)
+		matcher, ok := ParseColumnsMatcher(insert.Columns[1])
+		assert.True(t, ok)
+		assert.Equal(t, &ColumnsMatcher{Pattern: `^m`}, matcher)
+		_, ok = ParseColumnsMatcher(insert.Columns[0])
+		assert.False(t, ok)
+	})
+
+	t.Run(`expand`, func(t *testing.T) {
+		schema := []string{`id`, `metric_1`, `name`, `metric_2`}
+		columns, err := (&ColumnsMatcher{Pattern: `^metric_`}).Expand(schema)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`metric_1`, `metric_2`}, columns)
+		columns, err = (&ColumnsMatcher{Columns: []string{"`name`", `id`}}).Expand(schema)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`id`, `name`}, columns)
+		_, err = (&ColumnsMatcher{Pattern: `(`}).Expand(schema)
+		assert.Error(t, err)
+	})
+}
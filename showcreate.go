@@ -0,0 +1,112 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+)
+
+// ParseShowCreate parses the statement SHOW CREATE TABLE returns. Output
+// fetched in the TabSeparated format, with its line breaks escaped as \n, is
+// unescaped first. CreateTable.String writes the statement back the way
+// SHOW CREATE TABLE formats it
+func ParseShowCreate(output string) (*CreateTable, error) {
+	output = strings.TrimSpace(output)
+	if !strings.Contains(output, "\n") && strings.Contains(output, `\n`) {
+		output = unescapeTabSeparated(output)
+	}
+	return ParseCreateTable(output)
+}
+
+// String writes the statement the way SHOW CREATE TABLE formats it, with
+// one column per line and backtick quoted column names. Parsing the result
+// gives back the same columns and clauses
+func (c *CreateTable) String() string {
+	var b strings.Builder
+	b.WriteString("CREATE TABLE ")
+	b.WriteString(QuoteTable(c.Table))
+	if len(c.Columns) > 0 || len(c.Elements) > 0 {
+		b.WriteString("\n(")
+		for i, column := range c.Columns {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("\n    ")
+			b.WriteString(column.String())
+		}
+		for i, element := range c.Elements {
+			if i > 0 || len(c.Columns) > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("\n    ")
+			b.WriteString(element)
+		}
+		b.WriteString("\n)")
+	}
+	for _, clause := range []struct {
+		name   string
+		clause Clause
+	}{
+		{"ENGINE = ", c.Engine},
+		{"PARTITION BY ", c.PartitionBy},
+		{"PRIMARY KEY ", c.PrimaryKey},
+		{"ORDER BY ", c.OrderBy},
+		{"SAMPLE BY ", c.SampleBy},
+		{"TTL ", c.TTL},
+		{"SETTINGS ", c.Settings},
+	} {
+		if clause.clause.Expr != "" {
+			b.WriteString("\n")
+			b.WriteString(clause.name)
+			b.WriteString(clause.clause.Expr)
+		}
+	}
+	if c.Comment != "" {
+		b.WriteString("\nCOMMENT ")
+		b.WriteString(QuoteString(c.Comment))
+	}
+	return b.String()
+}
+
+// String writes the column definition the way SHOW CREATE TABLE does, e.g.
+// `amount` Decimal(18, 2) DEFAULT 0 COMMENT 'total' CODEC(ZSTD(1))
+func (c ColumnDefinition) String() string {
+	var b strings.Builder
+	b.WriteString(quoteColumnName(c.Name))
+	if c.Type != "" {
+		b.WriteString(" ")
+		b.WriteString(c.Type)
+	}
+	if c.Nullable && !strings.HasPrefix(strings.ToLower(c.Type), "nullable(") {
+		b.WriteString(" NULL")
+	}
+	if c.DefaultKind != "" {
+		b.WriteString(" ")
+		b.WriteString(c.DefaultKind)
+		if c.Default != "" {
+			b.WriteString(" ")
+			b.WriteString(c.Default)
+		}
+	}
+	if c.Comment != "" {
+		b.WriteString(" COMMENT ")
+		b.WriteString(QuoteString(c.Comment))
+	}
+	if c.Codec != "" {
+		b.WriteString(" CODEC(")
+		b.WriteString(c.Codec)
+		b.WriteString(")")
+	}
+	if c.TTL != "" {
+		b.WriteString(" TTL ")
+		b.WriteString(c.TTL)
+	}
+	return b.String()
+}
+
+// quoteColumnName backtick quotes a column name as written, leaving names
+// already quoted alone
+func quoteColumnName(name string) string {
+	if len(name) > 1 && (name[0] == '`' || name[0] == '"') && name[len(name)-1] == name[0] {
+		name = unquoteIdentifier(name)
+	}
+	return quote(name, '`')
+}
@@ -0,0 +1,54 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShowCreate(t *testing.T) {
+	statement := "CREATE TABLE default.events\n" +
+		"(\n" +
+		"    `id` UInt64,\n" +
+		"    `user name` Nullable(String) COMMENT 'who\\'s there',\n" +
+		"    `note` String NULL,\n" +
+		"    `day` Date MATERIALIZED toDate(ts) CODEC(Delta(2), ZSTD(1)),\n" +
+		"    `payload` String DEFAULT '' CODEC(ZSTD(3)) TTL day + toIntervalDay(7),\n" +
+		"    INDEX idx id TYPE minmax GRANULARITY 1\n" +
+		")\n" +
+		"ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/events', '{replica}')\n" +
+		"PARTITION BY toYYYYMM(day)\n" +
+		"ORDER BY (id, day)\n" +
+		"TTL day + toIntervalMonth(1)\n" +
+		"SETTINGS index_granularity = 8192\n" +
+		"COMMENT 'raw events'"
+
+	create, err := ParseShowCreate(statement)
+	assert.NoError(t, err)
+	assert.Equal(t, TableRef{Database: `default`, Table: `events`}, create.Table)
+	assert.Equal(t, "`user name`", create.Columns[1].Name)
+	assert.Equal(t, `who's there`, create.Columns[1].Comment)
+	assert.Equal(t, `Delta(2), ZSTD(1)`, create.Columns[3].Codec)
+	assert.Equal(t, `ZSTD(3)`, create.Columns[4].Codec)
+	assert.Equal(t, `day + toIntervalDay(7)`, create.Columns[4].TTL)
+	assert.Equal(t, []string{`INDEX idx id TYPE minmax GRANULARITY 1`}, create.Elements)
+	assert.Equal(t, `index_granularity = 8192`, create.Settings.Expr)
+	assert.Equal(t, `raw events`, create.Comment)
+	assert.Equal(t, statement, create.String())
+
+	t.Run(`TabSeparated`, func(t *testing.T) {
+		escaped, err := ParseShowCreate("CREATE TABLE t\\n(\\n    `id` UInt64 COMMENT 'a\\\\'b'\\n)\\nENGINE = Memory\n")
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE t\n(\n    `id` UInt64 COMMENT 'a\\'b'\n)\nENGINE = Memory", escaped.String())
+	})
+
+	t.Run(`written by hand`, func(t *testing.T) {
+		create, err := ParseShowCreate("create table t (id UInt64 codec(ZSTD), \"s\" String null) engine = Memory comment 'x'")
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE t\n(\n    `id` UInt64 CODEC(ZSTD),\n    `s` String NULL\n)\nENGINE = Memory\nCOMMENT 'x'", create.String())
+
+		again, err := ParseShowCreate(create.String())
+		assert.NoError(t, err)
+		assert.Equal(t, create.String(), again.String())
+	})
+}
@@ -0,0 +1,72 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Snapshots record how every query under testdata/snapshots is tokenised and
+// which columns are extracted. When a grammar change alters the behavior the
+// golden files are regenerated with
+//
+//	go test -run TestSnapshots -update
+//
+// and the diff is reviewed as part of the change, giving downstream driver
+// maintainers a precise record of what changed between releases
+var update = flag.Bool("update", false, "rewrite the golden files of the snapshot tests")
+
+func TestSnapshots(t *testing.T) {
+	queries, err := filepath.Glob(filepath.Join("testdata", "snapshots", "*.sql"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, queries)
+
+	for _, path := range queries {
+		name := strings.TrimSuffix(filepath.Base(path), ".sql")
+		t.Run(name, func(t *testing.T) {
+			query, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			snapshot := behaviorSnapshot(strings.TrimSuffix(string(query), "\n"))
+
+			golden := strings.TrimSuffix(path, ".sql") + ".golden"
+			if *update {
+				assert.NoError(t, os.WriteFile(golden, []byte(snapshot), 0o644))
+				return
+			}
+			want, err := os.ReadFile(golden)
+			assert.NoError(t, err, "run go test -run TestSnapshots -update to create it")
+			assert.Equal(t, string(want), snapshot)
+		})
+	}
+}
+
+// behaviorSnapshot renders the tokens, columns and errors of a query
+func behaviorSnapshot(query string) string {
+	e := &columnExtractor{
+		query:        query,
+		keepComments: true,
+	}
+	err := e.parse()
+
+	var b strings.Builder
+	b.WriteString("tokens:\n")
+	for _, token := range e.tokens {
+		fmt.Fprintf(&b, "  %-16s %s\n", token.Kind, token.Value)
+	}
+	b.WriteString("columns:\n")
+	for _, column := range e.columns() {
+		fmt.Fprintf(&b, "  %s\n", column)
+	}
+	b.WriteString("errors:\n")
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+)
+
+// Statement is a statement of a script along with its byte range, from its
+// first token up to its last, the terminating ; excluded
+type Statement struct {
+	Text  string
+	Start int
+	End   int
+	Pos   Position
+}
+
+// SplitStatements splits script on the semicolons ending its statements,
+// ignoring those inside string literals, quoted identifiers and comments.
+// Comments between statements and empty statements are dropped. The data
+// following INSERT ... FORMAT name runs up to the end of the script, as in
+// ClickHouse. Statements that fail to tokenize are returned all the same,
+// spanning what was scanned of them, along with their errors joined
+func SplitStatements(script string) ([]Statement, error) {
+	e := &columnExtractor{
+		query: script,
+	}
+	statements := make([]Statement, 0, 1)
+	errs := make([]error, 0)
+	for e.byteIndex < len(e.query) {
+		err := e.parse()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		last := len(e.tokens) - 1
+		if last >= 0 && e.tokens[last] == statementTerminator {
+			last--
+		}
+		if last < 0 {
+			continue
+		}
+		start, end := e.offsets[0], e.tokenEnd(last)
+		if e.formatData >= 0 {
+			end = len(e.query)
+		}
+		statements = append(statements, Statement{
+			Text:  e.query[start:end],
+			Start: start,
+			End:   end,
+			Pos:   e.tokenPosition(0),
+		})
+	}
+	return statements, errors.Join(errs...)
+}
@@ -0,0 +1,59 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- migration 42\n" +
+		"CREATE TABLE t (a String DEFAULT ';') ENGINE = Memory;\n" +
+		"/* ; */ INSERT INTO `x;y` VALUES ('a;b', $$;$$);;\n" +
+		"SELECT \"c;d\" -- trailing ;\n" +
+		"FROM t\n" +
+		";INSERT INTO t FORMAT CSV\n" +
+		"1;2\n"
+	statements, err := SplitStatements(script)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE t (a String DEFAULT ';') ENGINE = Memory",
+		"INSERT INTO `x;y` VALUES ('a;b', $$;$$)",
+		"SELECT \"c;d\" -- trailing ;\nFROM t",
+		"INSERT INTO t FORMAT CSV\n1;2\n",
+	}, texts(statements))
+	for _, statement := range statements {
+		assert.Equal(t, statement.Text, script[statement.Start:statement.End])
+	}
+	assert.Equal(t, Statement{Text: statements[1].Text, Start: 79, End: 118, Pos: Position{Offset: 79, Line: 3, Column: 9}}, statements[1])
+
+	t.Run(`no terminator`, func(t *testing.T) {
+		statements, err := SplitStatements("SELECT 1 ; \n SELECT 2 \n")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`SELECT 1`, `SELECT 2`}, texts(statements))
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		statements, err := SplitStatements("SELECT 1; SELECT 'unclosed")
+		assert.ErrorContains(t, err, `unclosed single quote`)
+		assert.Equal(t, []string{`SELECT 1`, `SELECT 'unclosed`}, texts(statements))
+
+		statements, err = SplitStatements("SELECT € 1; SELECT 2")
+		assert.EqualError(t, err, `1:8: unexpected rune: €`)
+		assert.Equal(t, []string{`SELECT € 1`, `SELECT 2`}, texts(statements))
+	})
+
+	t.Run(`invalid UTF-8`, func(t *testing.T) {
+		statements, err := SplitStatements("SELECT '\xff'; SELECT `\xfe`")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"SELECT '\xff'", "SELECT `\xfe`"}, texts(statements))
+	})
+}
+
+func texts(statements []Statement) []string {
+	texts := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		texts = append(texts, statement.Text)
+	}
+	return texts
+}
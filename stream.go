@@ -0,0 +1,104 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrHeaderTooLarge is returned by ReadInsertHeader when no complete INSERT
+// header is found within the limit
+var ErrHeaderTooLarge = errors.New("INSERT header too large")
+
+// streamChunkSize is the number of bytes ReadInsertHeader reads at a time
+const streamChunkSize = 4096
+
+// StreamHeader is the header of an INSERT read off a stream
+type StreamHeader struct {
+	// Query is the text of the header, up to and including VALUES or the
+	// FORMAT clause, or up to the SELECT of INSERT ... SELECT
+	Query string
+	Key   HeaderKey
+}
+
+// ReadInsertHeader reads an INSERT statement from r just far enough to
+// extract its header, so that a server can validate the table and columns
+// and reject the request before the client has uploaded its data. At most
+// limit bytes are buffered. The returned reader yields the rest of the
+// statement, starting right after the header
+func ReadInsertHeader(r io.Reader, limit int) (StreamHeader, io.Reader, error) {
+	buf := make([]byte, 0, min(limit, streamChunkSize))
+	eof := false
+	for {
+		if !eof {
+			n, err := r.Read(buf[len(buf):min(cap(buf), limit)])
+			buf = buf[:len(buf)+n]
+			if errors.Is(err, io.EOF) {
+				eof = true
+			} else if err != nil {
+				return StreamHeader{}, nil, err
+			}
+		}
+
+		end, complete, err := insertHeaderEnd(string(buf), eof)
+		if err != nil {
+			return StreamHeader{}, nil, err
+		}
+		if complete {
+			header := StreamHeader{Query: string(buf[:end])}
+			if header.Key, err = HeaderKeyOf(header.Query); err != nil {
+				return StreamHeader{}, nil, err
+			}
+			return header, io.MultiReader(bytes.NewReader(buf[end:]), r), nil
+		}
+		if eof {
+			return StreamHeader{}, nil, io.ErrUnexpectedEOF
+		}
+		if len(buf) >= limit {
+			return StreamHeader{}, nil, ErrHeaderTooLarge
+		}
+		if len(buf) == cap(buf) {
+			grown := make([]byte, len(buf), min(2*cap(buf), limit))
+			copy(grown, buf)
+			buf = grown
+		}
+	}
+}
+
+// insertHeaderEnd scans the start of an INSERT statement and returns the
+// offset at which its header ends. Until eof, the last token may be cut off
+// by the end of the buffer and is only trusted once something follows it
+func insertHeaderEnd(query string, eof bool) (int, bool, error) {
+	e := &columnExtractor{
+		query: query,
+	}
+	var previous Token
+	for e.byteIndex < len(e.query) {
+		token, ok, err := e.scan()
+		truncated := !eof && e.byteIndex == len(e.query)
+		if truncated {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, e.syntaxError(err)
+		}
+		if !ok {
+			continue
+		}
+		e.emit(token)
+		switch {
+		case len(e.tokens) == 1 && !token.IsKeyword("INSERT"):
+			return 0, false, errors.New("not an INSERT statement")
+		case token.IsKeyword("VALUES"):
+			return e.byteIndex, true, nil
+		case previous.IsKeyword("FORMAT"):
+			return e.byteIndex, true, nil
+		case token.IsKeyword("SELECT") || token.IsKeyword("WITH"):
+			return e.tokenStart, true, nil
+		case token == statementTerminator:
+			return e.tokenStart, true, nil
+		}
+		previous = token
+	}
+	return len(query), eof && len(e.tokens) > 0, nil
+}
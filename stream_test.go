@@ -0,0 +1,91 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadInsertHeader(t *testing.T) {
+	t.Run(`values`, func(t *testing.T) {
+		header, rest, err := ReadInsertHeader(strings.NewReader(`INSERT INTO db.t (a, b) VALUES (1, 2), (3, 4)`), 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO db.t (a, b) VALUES`, header.Query)
+		assert.Equal(t, `db.t (a, b)`, header.Key.String())
+		data, err := io.ReadAll(rest)
+		assert.NoError(t, err)
+		assert.Equal(t, ` (1, 2), (3, 4)`, string(data))
+	})
+
+	t.Run(`format`, func(t *testing.T) {
+		header, rest, err := ReadInsertHeader(strings.NewReader("INSERT INTO t (a) FORMAT CSV\n1\n'2\n"), 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO t (a) FORMAT CSV`, header.Query)
+		assert.Equal(t, `CSV`, header.Key.Format)
+		data, _ := io.ReadAll(rest)
+		assert.Equal(t, "\n1\n'2\n", string(data))
+	})
+
+	t.Run(`select`, func(t *testing.T) {
+		header, rest, err := ReadInsertHeader(strings.NewReader(`INSERT INTO t (a) SELECT x FROM s`), 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO t (a) `, header.Query)
+		data, _ := io.ReadAll(rest)
+		assert.Equal(t, `SELECT x FROM s`, string(data))
+	})
+
+	t.Run(`one byte at a time`, func(t *testing.T) {
+		query := "INSERT INTO t (`a b`, 'c') VALUES ('x')"
+		header, rest, err := ReadInsertHeader(iotest.OneByteReader(strings.NewReader(query)), 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`a b`, `c`}, header.Key.Columns())
+		data, _ := io.ReadAll(rest)
+		assert.Equal(t, query, header.Query+string(data))
+	})
+
+	t.Run(`stops before the data`, func(t *testing.T) {
+		failing := errors.New(`data read before the header was returned`)
+		body := io.MultiReader(
+			iotest.OneByteReader(strings.NewReader(`INSERT INTO t (a) FORMAT Native `)),
+			iotest.ErrReader(failing),
+		)
+		header, rest, err := ReadInsertHeader(body, 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO t (a) FORMAT Native`, header.Query)
+		_, err = io.ReadAll(rest)
+		assert.ErrorIs(t, err, failing)
+	})
+
+	t.Run(`rejects other statements early`, func(t *testing.T) {
+		_, _, err := ReadInsertHeader(strings.NewReader(`SELECT * FROM t`), 1024)
+		assert.EqualError(t, err, `not an INSERT statement`)
+	})
+
+	t.Run(`too large`, func(t *testing.T) {
+		_, _, err := ReadInsertHeader(strings.NewReader(`INSERT INTO t (`+strings.Repeat(`c, `, 100)+`d) VALUES`), 64)
+		assert.ErrorIs(t, err, ErrHeaderTooLarge)
+	})
+
+	t.Run(`truncated`, func(t *testing.T) {
+		_, _, err := ReadInsertHeader(strings.NewReader(`INSERT INTO t (a`), 1024)
+		assert.EqualError(t, err, `unclosed column list`)
+
+		_, _, err = ReadInsertHeader(strings.NewReader(``), 1024)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run(`header only`, func(t *testing.T) {
+		header, _, err := ReadInsertHeader(strings.NewReader(`INSERT INTO t (a)`), 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO t (a)`, header.Query)
+	})
+
+	t.Run(`syntax error`, func(t *testing.T) {
+		_, _, err := ReadInsertHeader(strings.NewReader(`INSERT INTO t (a€) VALUES`), 1024)
+		assert.EqualError(t, err, `1:17: unexpected rune: €`)
+	})
+}
@@ -0,0 +1,195 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"slices"
+	"strings"
+)
+
+// TableRef is a table referenced by a statement, either a possibly database
+// qualified table or a table function such as remote or s3
+type TableRef struct {
+	Database string `json:"database,omitempty"`
+	Table    string `json:"table,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// String returns the qualified table name, or the function name followed by
+// parentheses for table functions
+func (r TableRef) String() string {
+	switch {
+	case r.Function != "":
+		return r.Function + "()"
+	case r.Database != "":
+		return r.Database + "." + r.Table
+	default:
+		return r.Table
+	}
+}
+
+// ReferencedTables returns the tables a statement refers to in order of
+// appearance: the target of INSERT and DDL statements first, followed by
+// tables and table functions read via FROM and JOIN. This is best effort,
+// tokenisation errors are ignored
+func ReferencedTables(query string) []TableRef {
+	e := &columnExtractor{
+		query: query,
+	}
+	statements, _ := e.statements()
+
+	refs := make([]TableRef, 0, 2)
+	for _, tokens := range statements {
+		for _, ref := range tableRefs(tokens) {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// tableRefs returns the tables referenced by the tokens of a single statement
+// without duplicates. For INSERT and DDL statements the first one is the target
+func tableRefs(tokens []Token) []TableRef {
+	refs := make([]TableRef, 0, 2)
+	add := func(ref TableRef) {
+		if (ref.Table != "" || ref.Function != "") && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	if len(tokens) == 0 {
+		return refs
+	}
+
+	kind := strings.ToUpper(tokens[0].Value)
+	i := 1
+	var ref TableRef
+	switch kind {
+	case "INSERT":
+		i = skipTableKeyword(tokens, skipKeywords(tokens, i, "INTO"))
+		if i < len(tokens) && tokens[i].IsKeyword("FUNCTION") {
+			ref, i = readTableRef(tokens, i+1)
+		} else {
+			ref, i = readTableName(tokens, i)
+		}
+		add(ref)
+	case "CREATE", "ATTACH":
+		i = skipKeywords(tokens, i, "OR", "REPLACE", "TEMPORARY", "MATERIALIZED", "LIVE", "VIEW", "TABLE", "DICTIONARY", "IF", "NOT", "EXISTS")
+		ref, i = readTableName(tokens, i)
+		add(ref)
+	case "ALTER", "DROP", "DETACH", "TRUNCATE", "OPTIMIZE", "CHECK", "DESCRIBE", "DESC", "EXISTS":
+		i = skipKeywords(tokens, i, "TEMPORARY", "TABLE", "VIEW", "DICTIONARY", "IF", "EXISTS")
+		ref, i = readTableRef(tokens, i)
+		add(ref)
+	case "SHOW":
+		if i < len(tokens) && tokens[i].IsKeyword("CREATE") {
+			i = skipKeywords(tokens, i+1, "TEMPORARY", "TABLE", "VIEW", "DICTIONARY")
+			ref, i = readTableName(tokens, i)
+			add(ref)
+		}
+	case "RENAME", "EXCHANGE":
+		// RENAME TABLE a TO b, c TO d and EXCHANGE TABLES a AND b
+		i = skipKeywords(tokens, i, "TABLE", "TABLES", "DICTIONARY")
+		for i < len(tokens) {
+			ref, i = readTableName(tokens, i)
+			add(ref)
+			if i >= len(tokens) || !slices.Contains([]string{"TO", "AND", ","}, strings.ToUpper(tokens[i].Value)) {
+				break
+			}
+			i++
+		}
+		return refs
+	}
+
+	for ; i < len(tokens); i++ {
+		if tokens[i].Kind != TokenKeyword {
+			continue
+		}
+		switch strings.ToUpper(tokens[i].Value) {
+		case "FROM", "JOIN":
+			ref, _ = readTableRef(tokens, i+1)
+			add(ref)
+		case "TO":
+			// CREATE MATERIALIZED VIEW mv TO t, but not ALTER ... TO DISK 'd'
+			if kind == "CREATE" {
+				ref, _ = readTableName(tokens, i+1)
+				add(ref)
+			}
+		case "AS":
+			// CREATE TABLE t AS other copies the structure of another table
+			if kind == "CREATE" && i+1 < len(tokens) && !tokens[i+1].IsKeyword("SELECT") {
+				ref, _ = readTableRef(tokens, i+1)
+				add(ref)
+			}
+		}
+	}
+	return refs
+}
+
+// skipKeywords advances past any of the given keywords starting at i
+func skipKeywords(tokens []Token, i int, keywords ...string) int {
+	for i < len(tokens) && slices.ContainsFunc(keywords, func(keyword string) bool {
+		return tokens[i].IsKeyword(keyword)
+	}) {
+		i++
+	}
+	return i
+}
+
+// insertClauses holds the keywords that can follow the table of an INSERT
+var insertClauses = []string{"VALUES", "FORMAT", "SELECT", "WITH", "SETTINGS", "FROM"}
+
+// skipTableKeyword advances past the optional TABLE keyword of INSERT INTO
+// TABLE t, and the comments following it. Without a name after it, as in
+// INSERT INTO table (a), TABLE is the name of the table
+func skipTableKeyword(tokens []Token, i int) int {
+	if i >= len(tokens) || !tokens[i].IsKeyword("TABLE") {
+		return i
+	}
+	next := i + 1
+	for next < len(tokens) && tokens[next].Kind == TokenComment {
+		next++
+	}
+	if next == len(tokens) {
+		return i
+	}
+	token := tokens[next]
+	if token.isIdentifier() || token.Kind == TokenParameter ||
+		(token.Kind == TokenKeyword && !slices.ContainsFunc(insertClauses, token.IsKeyword)) {
+		return next
+	}
+	return i
+}
+
+// readTableRef reads a table name or, when followed by an opening
+// parenthesis, a table function starting at i. Subqueries yield an empty ref
+func readTableRef(tokens []Token, i int) (TableRef, int) {
+	ref, next := readTableName(tokens, i)
+	if next < len(tokens) && tokens[next].Value == "(" && ref.Table != "" {
+		return TableRef{Function: ref.Table}, next
+	}
+	return ref, next
+}
+
+// readTableName reads a possibly database qualified table name starting at i,
+// stripping backtick quotes. Non identifiers yield an empty ref
+func readTableName(tokens []Token, i int) (TableRef, int) {
+	parts := make([]string, 0, 2)
+	// Keywords aren't reserved, so INSERT INTO view (a) is accepted as well
+	for i < len(tokens) && (tokens[i].isIdentifier() || tokens[i].Kind == TokenKeyword) {
+		parts = append(parts, strings.Trim(tokens[i].Value, "`"))
+		i++
+		if len(parts) < 2 && i+1 < len(tokens) && tokens[i].Value == "." {
+			i++
+			continue
+		}
+		break
+	}
+	switch len(parts) {
+	case 1:
+		return TableRef{Table: parts[0]}, i
+	case 2:
+		return TableRef{Database: parts[0], Table: parts[1]}, i
+	default:
+		return TableRef{}, i
+	}
+}
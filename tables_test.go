@@ -0,0 +1,102 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedTables(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []TableRef
+	}{
+		{
+			name:  `insert`,
+			query: "INSERT INTO `db`.events (a, b)",
+			want:  []TableRef{{Database: `db`, Table: `events`}},
+		},
+		{
+			name:  `insert into table`,
+			query: `INSERT INTO TABLE db.events (a, b)`,
+			want:  []TableRef{{Database: `db`, Table: `events`}},
+		},
+		{
+			name:  `insert into a table named table`,
+			query: `INSERT INTO table (a, b) VALUES`,
+			want:  []TableRef{{Table: `table`}},
+		},
+		{
+			name:  `insert into table named table`,
+			query: `INSERT INTO TABLE /* x */ table VALUES`,
+			want:  []TableRef{{Table: `table`}},
+		},
+		{
+			name:  `insert into function`,
+			query: `INSERT INTO FUNCTION remote('host', db, t) (a) SELECT a FROM src`,
+			want:  []TableRef{{Function: `remote`}, {Table: `src`}},
+		},
+		{
+			name:  `select with joins and table functions`,
+			query: `SELECT a FROM db.t1 AS x JOIN t2 USING (id) LEFT JOIN numbers(10) n ON n.number = x.a`,
+			want:  []TableRef{{Database: `db`, Table: `t1`}, {Table: `t2`}, {Function: `numbers`}},
+		},
+		{
+			name:  `subquery`,
+			query: `SELECT a FROM (SELECT a FROM t1) JOIN (SELECT a FROM t1) USING (a)`,
+			want:  []TableRef{{Table: `t1`}},
+		},
+		{
+			name:  `create table as`,
+			query: `CREATE TABLE IF NOT EXISTS db.copy AS db.events`,
+			want:  []TableRef{{Database: `db`, Table: `copy`}, {Database: `db`, Table: `events`}},
+		},
+		{
+			name:  `create materialized view`,
+			query: `CREATE MATERIALIZED VIEW mv TO dst AS SELECT a FROM src`,
+			want:  []TableRef{{Table: `mv`}, {Table: `dst`}, {Table: `src`}},
+		},
+		{
+			name:  `drop`,
+			query: `DROP TABLE IF EXISTS db.t`,
+			want:  []TableRef{{Database: `db`, Table: `t`}},
+		},
+		{
+			name:  `alter move partition to disk`,
+			query: `ALTER TABLE t MOVE PARTITION tuple() TO DISK 'cold'`,
+			want:  []TableRef{{Table: `t`}},
+		},
+		{
+			name:  `rename`,
+			query: `RENAME TABLE a TO b, db.c TO db.d`,
+			want:  []TableRef{{Table: `a`}, {Table: `b`}, {Database: `db`, Table: `c`}, {Database: `db`, Table: `d`}},
+		},
+		{
+			name:  `exchange`,
+			query: `EXCHANGE TABLES a AND b`,
+			want:  []TableRef{{Table: `a`}, {Table: `b`}},
+		},
+		{
+			name:  `show create`,
+			query: `SHOW CREATE TABLE db.t`,
+			want:  []TableRef{{Database: `db`, Table: `t`}},
+		},
+		{
+			name:  `show tables`,
+			query: `SHOW TABLES`,
+			want:  []TableRef{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ReferencedTables(tt.query))
+		})
+	}
+}
+
+func TestTableRefString(t *testing.T) {
+	assert.Equal(t, `t`, TableRef{Table: `t`}.String())
+	assert.Equal(t, `db.t`, TableRef{Database: `db`, Table: `t`}.String())
+	assert.Equal(t, `s3()`, TableRef{Function: `s3`}.String())
+}
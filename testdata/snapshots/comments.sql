@@ -0,0 +1,3 @@
+-- generated
+INSERT INTO t /* batch /* 42 */ */ (a, -- first
+  b)
@@ -0,0 +1 @@
+INSERT INTO t (a, b, c, d) VALUES (1, 2.5e-3, 0x1F, $$raw$$)
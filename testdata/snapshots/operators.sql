@@ -0,0 +1 @@
+INSERT INTO t (a, b) SELECT x * 2, y || 'z' FROM s WHERE x >= 2 AND y != 'q'
@@ -0,0 +1 @@
+INSERT INTO `DATA (BASE`.`A (TABLE)` ( `column \`one`, columnTwo, 'col)umn\' (three ')
@@ -0,0 +1 @@
+INSERT INTO table (column1, column2)
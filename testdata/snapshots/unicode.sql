@@ -0,0 +1 @@
+INSERT INTO таблица (имя, 列名)
@@ -0,0 +1,227 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"clickhouse_go_insert_statement_parsing/grammar"
+)
+
+// TokenKind classifies the tokens produced by the tokenizer
+type TokenKind int
+
+const (
+	TokenIdentifier       TokenKind = iota // unquoted identifier
+	TokenQuotedIdentifier                  // backtick or double quoted identifier
+	TokenString                            // single quoted string, also accepted as a column name
+	TokenNumber                            // integer, decimal, scientific, hex or binary literal
+	TokenPunctuation                       // ( ) [ ] , . : ; and { } without query parameters
+	TokenComment                           // -- or /* */ comment, only kept with keepComments
+	TokenOperator                          // = == != <> < <= > >= + - * / % || -> ::
+	TokenPlaceholder                       // ? positional placeholder
+	TokenNamedPlaceholder                  // @name named argument
+	TokenParameter                         // {name:Type} server-side query parameter
+	TokenKeyword                           // unquoted keyword such as INSERT or VALUES, in any case
+)
+
+var tokenKindNames = [...]string{
+	TokenIdentifier:       "Identifier",
+	TokenQuotedIdentifier: "QuotedIdentifier",
+	TokenString:           "String",
+	TokenNumber:           "Number",
+	TokenPunctuation:      "Punctuation",
+	TokenComment:          "Comment",
+	TokenOperator:         "Operator",
+	TokenPlaceholder:      "Placeholder",
+	TokenNamedPlaceholder: "NamedPlaceholder",
+	TokenParameter:        "Parameter",
+	TokenKeyword:          "Keyword",
+}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenKindNames) {
+		return "Unknown"
+	}
+	return tokenKindNames[k]
+}
+
+// Token is a single lexical unit of a query. Value holds the source text,
+// including the quotes of quoted identifiers and strings
+type Token struct {
+	Kind  TokenKind
+	Value string
+	// Ordinal numbers the ? placeholders of a statement starting from 1, or
+	// holds N for the $N placeholders of the Postgres dialect
+	Ordinal int
+}
+
+func (t Token) String() string {
+	return t.Kind.String() + " " + t.Value
+}
+
+// isIdentifier reports whether the token is a bare or backtick quoted identifier
+func (t Token) isIdentifier() bool {
+	return t.Kind == TokenIdentifier || t.Kind == TokenQuotedIdentifier
+}
+
+// IsKeyword reports whether the token is the given keyword, ignoring case
+func (t Token) IsKeyword(keyword string) bool {
+	return t.Kind == TokenKeyword && strings.EqualFold(t.Value, keyword)
+}
+
+// keywords holds grammar.Keywords for lookups. Longer identifiers skip the
+// lookup
+var (
+	keywords         = make(map[string]struct{}, len(grammar.Keywords))
+	maxKeywordLength int
+)
+
+func init() {
+	for _, keyword := range grammar.Keywords {
+		keywords[keyword] = struct{}{}
+		maxKeywordLength = max(maxKeywordLength, len(keyword))
+	}
+}
+
+func isKeyword(word string) bool {
+	if len(word) > maxKeywordLength {
+		return false
+	}
+	_, ok := keywords[strings.ToUpper(word)]
+	return ok
+}
+
+// Parameter is a server-side query parameter written as {name:Type}
+type Parameter struct {
+	Name string
+	Type string
+}
+
+// Parameter returns the name and declared type of a Parameter token
+func (t Token) Parameter() (Parameter, bool) {
+	if t.Kind != TokenParameter {
+		return Parameter{}, false
+	}
+	return splitParameter(t.Value)
+}
+
+// splitParameter splits {name:Type} into its trimmed name, which has to be
+// an identifier, and type, which has to start with a type name
+func splitParameter(value string) (Parameter, bool) {
+	if len(value) < 2 || value[0] != '{' || value[len(value)-1] != '}' {
+		return Parameter{}, false
+	}
+	name, typ, found := strings.Cut(value[1:len(value)-1], ":")
+	parameter := Parameter{
+		Name: strings.TrimSpace(name),
+		Type: strings.TrimSpace(typ),
+	}
+	n := identifierPrefix(parameter.Name)
+	return parameter, found && n > 0 && n == len(parameter.Name) && identifierPrefix(parameter.Type) > 0
+}
+
+// DecodedValue returns the value of a single quoted string or a backtick or
+// double quoted identifier with the quotes removed and escapes such as \n,
+// \t, \\, \xHH and \uXXXX decoded, or the verbatim content of a $tag$
+// dollar-quoted string. Other tokens are returned unchanged. Dollar-quoted
+// strings missing their closing tag, as Scanner.Next returns for
+// unterminated input, are reported as errors
+func (t Token) DecodedValue() (string, error) {
+	if (t.Kind != TokenString && t.Kind != TokenQuotedIdentifier) || len(t.Value) < 2 {
+		return t.Value, nil
+	}
+	if t.Kind == TokenString && (t.Value[0] == 'E' || t.Value[0] == 'e') && len(t.Value) > 2 {
+		// E'...' string of the Postgres dialect
+		return unescape(t.Value[2:len(t.Value)-1], '\'')
+	}
+	if t.Value[0] == '$' {
+		end := strings.IndexByte(t.Value[1:], '$')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated dollar-quoted string: %s", t.Value)
+		}
+		delimiter := t.Value[:end+2]
+		if len(t.Value) < 2*len(delimiter) || !strings.HasSuffix(t.Value, delimiter) {
+			return "", fmt.Errorf("unterminated dollar-quoted string: %s", t.Value)
+		}
+		return t.Value[len(delimiter) : len(t.Value)-len(delimiter)], nil
+	}
+	return unescape(t.Value[1:len(t.Value)-1], t.Value[0])
+}
+
+// simpleEscapes maps the rune following a backslash to the rune it stands for
+var simpleEscapes = map[byte]byte{
+	'n': '\n',
+	't': '\t',
+	'r': '\r',
+	'b': '\b',
+	'f': '\f',
+	'a': '\a',
+	'v': '\v',
+	'0': 0,
+	'e': 0x1b,
+}
+
+// unescape decodes backslash escapes and doubled quote characters. As in
+// ClickHouse, a backslash before any other character stands for that
+// character, e.g. \' or \\
+func unescape(s string, quote byte) (string, error) {
+	if !strings.Contains(s, `\`) && !strings.Contains(s, string([]byte{quote, quote})) {
+		return s, nil
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == quote && i+1 < len(s) && s[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		if decoded, ok := simpleEscapes[s[i]]; ok {
+			b.WriteByte(decoded)
+			continue
+		}
+		switch s[i] {
+		case 'x':
+			value, err := parseHexEscape(s, i+1, 2)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(byte(value))
+			i += 2
+		case 'u':
+			value, err := parseHexEscape(s, i+1, 4)
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(rune(value))
+			i += 4
+		default:
+			r, width := utf8.DecodeRuneInString(s[i:])
+			b.WriteRune(r)
+			i += width - 1
+		}
+	}
+	return b.String(), nil
+}
+
+// parseHexEscape parses the digits hex digits of an escape starting at i
+func parseHexEscape(s string, i, digits int) (uint64, error) {
+	if i+digits > len(s) {
+		return 0, fmt.Errorf("truncated escape sequence: \\%s", s[i-1:])
+	}
+	value, err := strconv.ParseUint(s[i:i+digits], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid escape sequence: \\%s", s[i-1:i+digits])
+	}
+	return value, nil
+}
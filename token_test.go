@@ -0,0 +1,122 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"clickhouse_go_insert_statement_parsing/parsertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodedValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`'plain'`, `plain`},
+		{`'a\nb\tc'`, "a\nb\tc"},
+		{`'back\\slash'`, `back\slash`},
+		{`'it\'s'`, `it's`},
+		{`'\x41\x42'`, `AB`},
+		{`'caf\u00e9'`, `café`},
+		{`'été'`, `été`},
+		{`'\0'`, "\x00"},
+		{`'\q'`, `q`},
+		{`'\é'`, `é`},
+		{`''`, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			decoded, err := Token{Kind: TokenString, Value: tt.value}.DecodedValue()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, decoded)
+		})
+	}
+
+	t.Run(`dollar-quoted strings are verbatim`, func(t *testing.T) {
+		decoded, err := Token{Kind: TokenString, Value: `$tag$it's \n$tag$`}.DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, `it's \n`, decoded)
+		decoded, err = Token{Kind: TokenString, Value: `$$$$`}.DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, ``, decoded)
+
+		for _, value := range []string{`$a$x`, `$a$x$a`, `$$`, `$a`, `$tag$x$tab$`} {
+			_, err := Token{Kind: TokenString, Value: value}.DecodedValue()
+			assert.EqualError(t, err, `unterminated dollar-quoted string: `+value)
+		}
+	})
+
+	t.Run(`invalid escapes`, func(t *testing.T) {
+		_, err := Token{Kind: TokenString, Value: `'\xZZ'`}.DecodedValue()
+		assert.EqualError(t, err, `invalid escape sequence: \xZZ`)
+		_, err = Token{Kind: TokenString, Value: `'\u12'`}.DecodedValue()
+		assert.EqualError(t, err, `truncated escape sequence: \u12`)
+	})
+
+	t.Run(`quoted identifiers`, func(t *testing.T) {
+		decoded, err := Token{Kind: TokenQuotedIdentifier, Value: "`a\\nb`"}.DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, "a\nb", decoded)
+		decoded, err = Token{Kind: TokenQuotedIdentifier, Value: `"caf\u00e9 \xD0\xB8"`}.DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, `café и`, decoded)
+	})
+
+	t.Run(`other kinds are unchanged`, func(t *testing.T) {
+		decoded, err := Token{Kind: TokenIdentifier, Value: `a\nb`}.DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, `a\nb`, decoded)
+	})
+
+	t.Run(`from a parsed query`, func(t *testing.T) {
+		e := &columnExtractor{
+			query: `INSERT INTO t ('col\tumn')`,
+		}
+		assert.NoError(t, e.parse())
+		decoded, err := e.tokens[4].DecodedValue()
+		assert.NoError(t, err)
+		assert.Equal(t, "col\tumn", decoded)
+	})
+}
+
+func TestKeywords(t *testing.T) {
+	t.Run(`case insensitive`, func(t *testing.T) {
+		e := &columnExtractor{query: `insert Into t (a) vAlUeS`}
+		assert.NoError(t, e.parse())
+		parsertest.EqualTokens(t, []Token{
+			{Kind: TokenKeyword, Value: `insert`},
+			{Kind: TokenKeyword, Value: `Into`},
+			{Kind: TokenIdentifier, Value: `t`},
+			{Kind: TokenPunctuation, Value: `(`},
+			{Kind: TokenIdentifier, Value: `a`},
+			{Kind: TokenPunctuation, Value: `)`},
+			{Kind: TokenKeyword, Value: `vAlUeS`},
+		}, e.tokens)
+		assert.True(t, e.tokens[0].IsKeyword(`INSERT`))
+		assert.False(t, e.tokens[0].IsKeyword(`INTO`))
+	})
+
+	t.Run(`after a dot`, func(t *testing.T) {
+		e := &columnExtractor{query: `SELECT t.format FROM db.values`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, TokenIdentifier, e.tokens[3].Kind)
+		assert.Equal(t, TokenIdentifier, e.tokens[7].Kind)
+	})
+
+	t.Run(`quoted and string keywords`, func(t *testing.T) {
+		assert.False(t, Token{Kind: TokenQuotedIdentifier, Value: "`select`"}.IsKeyword(`select`))
+		assert.False(t, Token{Kind: TokenString, Value: `INSERT`}.IsKeyword(`INSERT`))
+	})
+
+	t.Run(`keywords as names`, func(t *testing.T) {
+		e := &columnExtractor{query: `INSERT INTO view (format, values)`}
+		assert.NoError(t, e.parse())
+		assert.Equal(t, []string{`format`, `values`}, e.columns())
+		assert.Equal(t, []TableRef{{Table: `view`}}, ReferencedTables(`INSERT INTO view (format, values)`))
+	})
+}
+
+func TestTokenString(t *testing.T) {
+	assert.Equal(t, `Keyword INSERT`, Token{Kind: TokenKeyword, Value: `INSERT`}.String())
+	assert.Equal(t, "QuotedIdentifier `a b`", Token{Kind: TokenQuotedIdentifier, Value: "`a b`"}.String())
+}
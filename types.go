@@ -0,0 +1,130 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"strings"
+)
+
+// Type is a parsed data type such as Array(Nullable(String)): its name and
+// the parameters in parentheses. Params is nil for a type written without
+// parentheses and empty for one written with empty ones, as in Tuple()
+type Type struct {
+	Name   string
+	Params []TypeParam
+}
+
+// TypeParam is a parameter of a Type: either a type, as String in
+// Array(String), or a literal kept as written, as 16 in FixedString(16) or
+// 'a' = 1 in Enum8('a' = 1). The elements of a named Tuple, as in
+// Tuple(a UInt8, b String), and those of Nested carry their name
+type TypeParam struct {
+	Name  string // unquoted element name, empty for unnamed parameters
+	Type  *Type  // nil for literals
+	Value string // source text of a literal
+}
+
+// ParseType parses a type expression such as LowCardinality(FixedString(16))
+// or Map(String, UInt64) into a tree of its types and their parameters.
+// Names of several words, e.g. DOUBLE PRECISION, are joined by a space
+func ParseType(s string) (Type, error) {
+	e := &columnExtractor{
+		query: s,
+	}
+	if err := e.parse(); err != nil {
+		return Type{}, err
+	}
+	p := &astParser{e: e, end: len(e.tokens)}
+	if p.end > 0 && e.tokens[p.end-1] == statementTerminator {
+		p.end--
+	}
+	typ, i, err := p.typ(e.skipComments(0), p.end)
+	if err != nil {
+		return Type{}, err
+	}
+	if i = e.skipComments(i); i < p.end {
+		return Type{}, p.errorAt(i, "unexpected %s", e.tokens[i].Value)
+	}
+	return *typ, nil
+}
+
+// String writes the type back in its canonical form, parameters separated
+// by a comma and a space
+func (t Type) String() string {
+	if t.Params == nil {
+		return t.Name
+	}
+	params := make([]string, 0, len(t.Params))
+	for _, param := range t.Params {
+		params = append(params, param.String())
+	}
+	return t.Name + "(" + strings.Join(params, ", ") + ")"
+}
+
+func (p TypeParam) String() string {
+	switch {
+	case p.Type == nil:
+		return p.Value
+	case p.Name != "":
+		return QuoteIdentifier(p.Name) + " " + p.Type.String()
+	default:
+		return p.Type.String()
+	}
+}
+
+// Element returns the type of the element called name of a named Tuple or
+// of Nested
+func (t Type) Element(name string) (*Type, bool) {
+	for _, param := range t.Params {
+		if param.Name == name && param.Type != nil {
+			return param.Type, true
+		}
+	}
+	return nil, false
+}
+
+// typ parses the type at index i, up to end, returning the index past it
+func (p *astParser) typ(i, end int) (*Type, int, error) {
+	e := p.e
+	if i >= end || !p.isName(i) {
+		return nil, i, p.errorAt(i, "missing type name")
+	}
+	words := []string{e.tokens[i].Value}
+	for i++; i < end && p.isName(i); i++ {
+		words = append(words, e.tokens[i].Value)
+	}
+	typ := &Type{Name: strings.Join(words, " ")}
+	if i == end || !p.isValue(i, "(") {
+		return typ, i, nil
+	}
+	close := e.matchingParenthesis(i)
+	if close < 0 || close >= end {
+		return nil, i, p.errorAt(i, "unclosed parenthesis")
+	}
+	typ.Params = make([]TypeParam, 0)
+	named := words[0] == "Tuple" || words[0] == "Nested"
+	for _, item := range e.splitList(i+1, close) {
+		typ.Params = append(typ.Params, p.typeParam(item, named))
+	}
+	return typ, close + 1, nil
+}
+
+// typeParam parses the tokens of a type parameter, taking them as a literal
+// unless they make up a type. With named set, as for the elements of Tuple
+// and Nested, a name followed by a type is an element name and its type
+func (p *astParser) typeParam(item []int, named bool) TypeParam {
+	first, last := item[0], item[len(item)-1]
+	if named && len(item) > 1 && p.isName(first) {
+		if typ, next, err := p.typ(first+1, last+1); err == nil && next == last+1 {
+			name := p.e.tokens[first].Value
+			if p.e.tokens[first].Kind == TokenQuotedIdentifier {
+				name = unquoteIdentifier(name)
+			}
+			return TypeParam{Name: name, Type: typ}
+		}
+	}
+	if p.isName(first) {
+		if typ, next, err := p.typ(first, last+1); err == nil && next == last+1 {
+			return TypeParam{Type: typ}
+		}
+	}
+	return TypeParam{Value: p.e.sourceText(first, last)}
+}
@@ -0,0 +1,79 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseType(t *testing.T) {
+	named := func(name string, params ...TypeParam) *Type {
+		return &Type{Name: name, Params: params}
+	}
+	tests := map[string]Type{
+		`UInt64`:                  {Name: `UInt64`},
+		`Array(Nullable(String))`: {Name: `Array`, Params: []TypeParam{{Type: named(`Nullable`, TypeParam{Type: &Type{Name: `String`}})}}},
+		`LowCardinality(FixedString(16))`: {Name: `LowCardinality`, Params: []TypeParam{
+			{Type: named(`FixedString`, TypeParam{Value: `16`})},
+		}},
+		`Map(String, UInt64)`:      {Name: `Map`, Params: []TypeParam{{Type: &Type{Name: `String`}}, {Type: &Type{Name: `UInt64`}}}},
+		`DateTime64(3, 'UTC')`:     {Name: `DateTime64`, Params: []TypeParam{{Value: `3`}, {Value: `'UTC'`}}},
+		`Enum8('a' = 1, 'b' = -2)`: {Name: `Enum8`, Params: []TypeParam{{Value: `'a' = 1`}, {Value: `'b' = -2`}}},
+		`Tuple()`:                  {Name: `Tuple`, Params: []TypeParam{}},
+		`DOUBLE  PRECISION`:        {Name: `DOUBLE PRECISION`},
+		`AggregateFunction(quantiles(0.5, 0.9), UInt64);`: {Name: `AggregateFunction`, Params: []TypeParam{
+			{Type: named(`quantiles`, TypeParam{Value: `0.5`}, TypeParam{Value: `0.9`})},
+			{Type: &Type{Name: `UInt64`}},
+		}},
+	}
+	for s, expected := range tests {
+		t.Run(s, func(t *testing.T) {
+			typ, err := ParseType(s)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, typ)
+		})
+	}
+
+	t.Run(`String`, func(t *testing.T) {
+		typ, err := ParseType(`Map( LowCardinality(String),Array(Decimal(18,2)) )`)
+		assert.NoError(t, err)
+		assert.Equal(t, `Map(LowCardinality(String), Array(Decimal(18, 2)))`, typ.String())
+	})
+
+	t.Run(`named elements`, func(t *testing.T) {
+		typ, err := ParseType("Tuple(a UInt8, `b c` Array(String), LowCardinality(String), Nested(x UInt32, y String))")
+		assert.NoError(t, err)
+		assert.Equal(t, Type{Name: `Tuple`, Params: []TypeParam{
+			{Name: `a`, Type: &Type{Name: `UInt8`}},
+			{Name: `b c`, Type: &Type{Name: `Array`, Params: []TypeParam{{Type: &Type{Name: `String`}}}}},
+			{Type: &Type{Name: `LowCardinality`, Params: []TypeParam{{Type: &Type{Name: `String`}}}}},
+			{Type: &Type{Name: `Nested`, Params: []TypeParam{
+				{Name: `x`, Type: &Type{Name: `UInt32`}},
+				{Name: `y`, Type: &Type{Name: `String`}},
+			}}},
+		}}, typ)
+		assert.Equal(t, "Tuple(a UInt8, `b c` Array(String), LowCardinality(String), Nested(x UInt32, y String))", typ.String())
+
+		element, ok := typ.Element(`b c`)
+		assert.True(t, ok)
+		assert.Equal(t, `Array(String)`, element.String())
+		_, ok = typ.Element(`x`)
+		assert.False(t, ok)
+
+		typ, err = ParseType(`Tuple(String, UInt8)`)
+		assert.NoError(t, err)
+		assert.Equal(t, []TypeParam{{Type: &Type{Name: `String`}}, {Type: &Type{Name: `UInt8`}}}, typ.Params)
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		for s, message := range map[string]string{
+			``:                 `1:1: missing type name`,
+			`Array(String`:     `1:6: unclosed parenthesis`,
+			`Array(String) x(`: `1:15: unexpected x`,
+			`16`:               `1:1: missing type name`,
+		} {
+			_, err := ParseType(s)
+			assert.EqualError(t, err, message, s)
+		}
+	})
+}
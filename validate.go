@@ -0,0 +1,129 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions caps the number of did-you-mean suggestions per unknown column
+const maxSuggestions = 3
+
+// UnknownColumnError reports an extracted column that the schema doesn't have,
+// along with the closest schema columns by edit distance
+type UnknownColumnError struct {
+	Column      string
+	Suggestions []string
+}
+
+func (err *UnknownColumnError) Error() string {
+	if len(err.Suggestions) == 0 {
+		return fmt.Sprintf("unknown column: %s", err.Column)
+	}
+	return fmt.Sprintf("unknown column: %s, did you mean %s?", err.Column, strings.Join(err.Suggestions, ", "))
+}
+
+// ValidateColumns checks the columns of an INSERT against the column names of
+// the target table, returning an UnknownColumnError for each column missing
+// from the schema
+func ValidateColumns(query string, schema []string) error {
+	e := &columnExtractor{
+		query: query,
+	}
+	if err := e.parse(); err != nil {
+		return err
+	}
+	return e.validateColumns(schema)
+}
+
+func (e *columnExtractor) validateColumns(schema []string) error {
+	errs := make([]error, 0)
+	for _, column := range e.columns() {
+		name := unquoteIdentifier(column)
+		if !slices.ContainsFunc(schema, func(known string) bool { return e.identifiersEqual(name, known) }) {
+			errs = append(errs, &UnknownColumnError{
+				Column:      name,
+				Suggestions: suggestColumns(name, schema),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DecodeIdentifier returns the name a column or table identifier stands for,
+// as listed in system.columns: backticks, double or single quotes are removed
+// and escapes such as \xHH and \uXXXX decoded. Unquoted identifiers are
+// returned unchanged
+func DecodeIdentifier(identifier string) (string, error) {
+	if len(identifier) < 2 {
+		return identifier, nil
+	}
+	quote := identifier[0]
+	if (quote != '`' && quote != '"' && quote != '\'') || identifier[len(identifier)-1] != quote {
+		return identifier, nil
+	}
+	return unescape(identifier[1:len(identifier)-1], quote)
+}
+
+// unquoteIdentifier is DecodeIdentifier for best effort callers, malformed
+// escapes are left as is
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) < 2 {
+		return identifier
+	}
+	unquoted, err := DecodeIdentifier(identifier)
+	if err != nil {
+		return identifier[1 : len(identifier)-1]
+	}
+	return unquoted
+}
+
+// suggestColumns returns up to maxSuggestions schema columns closest to name,
+// ignoring those too far off to plausibly be a typo
+func suggestColumns(name string, schema []string) []string {
+	type candidate struct {
+		column   string
+		distance int
+	}
+	limit := max(2, len([]rune(name))/3)
+	candidates := make([]candidate, 0, len(schema))
+	for _, column := range schema {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(column))
+		if distance <= limit {
+			candidates = append(candidates, candidate{column: column, distance: distance})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return a.distance - b.distance
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		suggestions = append(suggestions, c.column)
+	}
+	return suggestions
+}
+
+// levenshtein returns the number of single rune insertions, deletions and
+// substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
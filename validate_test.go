@@ -0,0 +1,85 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateColumns(t *testing.T) {
+	schema := []string{`user_id`, `user_name`, `created_at`, `updated_at`, `weight (kg)`}
+
+	t.Run(`known columns`, func(t *testing.T) {
+		err := ValidateColumns("INSERT INTO t (user_id, `weight (kg)`, 'created_at')", schema)
+		assert.NoError(t, err)
+	})
+
+	t.Run(`unknown column with suggestions`, func(t *testing.T) {
+		err := ValidateColumns(`INSERT INTO t (user_id, usr_name)`, schema)
+		assert.EqualError(t, err, `unknown column: usr_name, did you mean user_name?`)
+
+		var unknown *UnknownColumnError
+		assert.True(t, errors.As(err, &unknown))
+		assert.Equal(t, `usr_name`, unknown.Column)
+	})
+
+	t.Run(`at most three suggestions ordered by distance`, func(t *testing.T) {
+		err := ValidateColumns(`INSERT INTO t (xpdated_at)`, []string{`a_at`, `updated_at`, `created_at`, `updated_on`, `deleted_at`})
+		assert.EqualError(t, err, `unknown column: xpdated_at, did you mean updated_at, created_at, updated_on?`)
+	})
+
+	t.Run(`unknown column without suggestions`, func(t *testing.T) {
+		err := ValidateColumns(`INSERT INTO t (completely_different)`, schema)
+		assert.EqualError(t, err, `unknown column: completely_different`)
+	})
+
+	t.Run(`every unknown column is reported`, func(t *testing.T) {
+		err := ValidateColumns(`INSERT INTO t (a, user_id, b)`, schema)
+		assert.EqualError(t, err, "unknown column: a\nunknown column: b")
+	})
+
+	t.Run(`parse errors`, func(t *testing.T) {
+		err := ValidateColumns(`INSERT INTO t (a €)`, schema)
+		assert.EqualError(t, err, `1:18: unexpected rune: €`)
+	})
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein(`abc`, `abc`))
+	assert.Equal(t, 3, levenshtein(``, `abc`))
+	assert.Equal(t, 1, levenshtein(`abc`, `abd`))
+	assert.Equal(t, 3, levenshtein(`kitten`, `sitting`))
+	assert.Equal(t, 1, levenshtein(`café`, `cafe`))
+}
+
+func TestDecodeIdentifier(t *testing.T) {
+	tests := []struct {
+		raw     string
+		decoded string
+	}{
+		{`plain`, `plain`},
+		{"`a b`", `a b`},
+		{"`\\u0441\\u0443\\u043c\\u043c\\u0430`", `сумма`},
+		{`"\xE2\x82\xAC price"`, `€ price`},
+		{`'quoted'`, `quoted`},
+		{"`back\\`tick`", "back`tick"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			decoded, err := DecodeIdentifier(tt.raw)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.decoded, decoded)
+		})
+	}
+
+	t.Run(`invalid escape`, func(t *testing.T) {
+		_, err := DecodeIdentifier("`\\u12`")
+		assert.EqualError(t, err, `truncated escape sequence: \u12`)
+	})
+
+	t.Run(`matches schema columns`, func(t *testing.T) {
+		err := ValidateColumns("INSERT INTO t (`\\u0441\\u0443\\u043c\\u043c\\u0430`)", []string{`сумма`})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,368 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ValueKind classifies the values of rows following VALUES
+type ValueKind int
+
+const (
+	ValueLiteral    ValueKind = iota // string or possibly signed number
+	ValueExpression                  // function call, arithmetic or any other expression
+	ValueDefault                     // DEFAULT, the column is to take its default value
+	ValueArray                       // [1, 2] or array(1, 2)
+	ValueTuple                       // (1, 'a') or tuple(1, 'a')
+	ValueMap                         // map('k', 'v'), its elements alternating keys and values
+	ValueNull                        // NULL, in any case
+)
+
+var valueKindNames = [...]string{
+	ValueLiteral:    "Literal",
+	ValueExpression: "Expression",
+	ValueDefault:    "Default",
+	ValueArray:      "Array",
+	ValueTuple:      "Tuple",
+	ValueMap:        "Map",
+	ValueNull:       "Null",
+}
+
+func (k ValueKind) String() string {
+	if k < 0 || int(k) >= len(valueKindNames) {
+		return "Unknown"
+	}
+	return valueKindNames[k]
+}
+
+// Value is a value of a row following VALUES
+type Value struct {
+	Kind   ValueKind
+	Text   string // source text, e.g. 'a', 42 or now()
+	Tokens []Token
+	Pos    Position
+	// Elements holds the values of arrays, tuples and maps
+	Elements []Value
+}
+
+// Rows returns the values of each row following VALUES, respecting nested
+// parentheses, or nil without inline rows
+func (s *Insert) Rows() ([][]Value, error) {
+	if s.values < 0 {
+		return nil, nil
+	}
+	rows, err := s.e.rows(s.values)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]Value, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, s.e.rowValues(row))
+	}
+	return values, nil
+}
+
+// RowArityError reports a row following VALUES with more or fewer values
+// than the INSERT lists columns
+type RowArityError struct {
+	Row     int // starting from 1
+	Pos     Position
+	Values  int
+	Columns int
+}
+
+func (err *RowArityError) Error() string {
+	return fmt.Sprintf("%s: row %d has %d values, expected %d", err.Pos, err.Row, err.Values, err.Columns)
+}
+
+// ValidateRows checks that each row following the VALUES of an INSERT has as
+// many values as the INSERT lists columns, returning a RowArityError for each
+// row that doesn't. Rows of INSERTs without a column list aren't checked
+func ValidateRows(query string) error {
+	return Parser{}.ValidateRows(query)
+}
+
+// ValidateRows is ValidateRows with the options and dialect of p
+func (p Parser) ValidateRows(query string) error {
+	insert, err := p.ParseInsert(query)
+	if err != nil {
+		return err
+	}
+	return insert.ValidateRows()
+}
+
+// ValidateRows is ValidateRows for an INSERT parsed already
+func (s *Insert) ValidateRows() error {
+	if s.values < 0 || s.e.columnListStart() < 0 {
+		return nil
+	}
+	rows, err := s.e.rows(s.values)
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for n, row := range rows {
+		if len(row.values) != len(s.Columns) {
+			errs = append(errs, &RowArityError{
+				Row:     n + 1,
+				Pos:     s.e.tokenPosition(row.open),
+				Values:  len(row.values),
+				Columns: len(s.Columns),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SplitValues splits the rows following the VALUES of an INSERT into
+// statements of at most maxRowsPerStatement rows each, repeating the header
+// of the INSERT up to VALUES verbatim, e.g. to stay below max_query_size. A
+// clause of another dialect following the rows, e.g. MySQL's ON DUPLICATE
+// KEY UPDATE, ends every statement. An INSERT without rows after VALUES is
+// an error
+func SplitValues(query string, maxRowsPerStatement int) ([]string, error) {
+	return Parser{}.SplitValues(query, maxRowsPerStatement)
+}
+
+// SplitValues is SplitValues with the options and dialect of p
+func (p Parser) SplitValues(query string, maxRowsPerStatement int) ([]string, error) {
+	if maxRowsPerStatement < 1 {
+		return nil, fmt.Errorf("invalid number of rows per statement: %d", maxRowsPerStatement)
+	}
+	insert, err := p.ParseInsert(query)
+	if err != nil {
+		return nil, err
+	}
+	if insert.values < 0 {
+		return nil, errors.New("no VALUES clause")
+	}
+	e := insert.e
+	rows, err := e.rows(insert.values)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, e.errorAt(insert.values, "no rows after VALUES")
+	}
+	header := query[:e.tokenEnd(insert.values)]
+	trailer := e.rowsTrailer(e.matchingParenthesis(rows[len(rows)-1].open) + 1)
+	statements := make([]string, 0, (len(rows)+maxRowsPerStatement-1)/maxRowsPerStatement)
+	for len(rows) > 0 {
+		chunk := rows[:min(maxRowsPerStatement, len(rows))]
+		rows = rows[len(chunk):]
+		var s strings.Builder
+		s.WriteString(header)
+		for i, row := range chunk {
+			if i == 0 {
+				s.WriteString(" ")
+			} else {
+				s.WriteString(", ")
+			}
+			s.WriteString(e.sourceText(row.open, e.matchingParenthesis(row.open)))
+		}
+		if trailer != "" {
+			s.WriteString(" " + trailer)
+		}
+		statements = append(statements, s.String())
+	}
+	return statements, nil
+}
+
+// row holds the index of the parenthesis opening a row and the indexes of
+// the tokens of each of its values
+type row struct {
+	open   int
+	values [][]int
+}
+
+// rows splits the tokens following the VALUES keyword at index values into
+// rows. The commas separating rows are optional, as in ClickHouse
+func (e *columnExtractor) rows(values int) ([]row, error) {
+	var rows []row
+	i := e.skipComments(values + 1)
+	for i < len(e.tokens) && e.tokens[i] != statementTerminator && !e.isRowsTrailer(i) {
+		if e.tokens[i].Value != "(" {
+			return nil, e.errorAt(i, "row %d: expected ( but found %s", len(rows)+1, e.tokens[i].Value)
+		}
+		end := e.matchingParenthesis(i)
+		if end < 0 {
+			return nil, e.errorAt(i, "row %d: unclosed parenthesis", len(rows)+1)
+		}
+		if !e.bracketsBalanced(i, end) {
+			return nil, e.errorAt(i, "row %d: unbalanced brackets", len(rows)+1)
+		}
+		if comma := e.emptyItem(i, end); comma >= 0 {
+			return nil, e.errorAt(comma, "row %d: missing value", len(rows)+1)
+		}
+		rows = append(rows, row{open: i, values: e.splitList(i+1, end)})
+		i = e.skipComments(end + 1)
+		if i < len(e.tokens) && e.tokens[i].Value == "," {
+			i = e.skipComments(i + 1)
+		}
+	}
+	return rows, nil
+}
+
+// rowsTrailerClause holds the words of a clause other dialects accept after
+// the rows of an INSERT and the feature of the dialects accepting it
+type rowsTrailerClause struct {
+	feature Feature
+	words   []string
+}
+
+// rowsTrailers holds the clauses other dialects accept after the rows of an
+// INSERT, e.g. MySQL's ON DUPLICATE KEY UPDATE or Postgres' ON CONFLICT and
+// RETURNING
+var rowsTrailers = []rowsTrailerClause{
+	{OnDuplicateKeyUpdate, []string{"ON", "DUPLICATE", "KEY", "UPDATE"}},
+	{OnConflictReturning, []string{"ON", "CONFLICT"}},
+	{OnConflictReturning, []string{"RETURNING"}},
+}
+
+// isRowsTrailer reports whether a clause of rowsTrailers the dialect accepts
+// starts at index i
+func (e *columnExtractor) isRowsTrailer(i int) bool {
+	features := dialectFeatures(e.dialectOrDefault())
+	return slices.ContainsFunc(rowsTrailers, func(clause rowsTrailerClause) bool {
+		if features&clause.feature == 0 || i+len(clause.words) > len(e.tokens) {
+			return false
+		}
+		for n, word := range clause.words {
+			if !isWord(e.tokens[i+n], word) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// rowsTrailer returns the source text of the clause of rowsTrailers that
+// follows the rows, from index i on, or an empty string
+func (e *columnExtractor) rowsTrailer(i int) string {
+	i = e.skipComments(i)
+	if i == len(e.tokens) || !e.isRowsTrailer(i) {
+		return ""
+	}
+	last := len(e.tokens) - 1
+	for last > i && (e.tokens[last] == statementTerminator || e.tokens[last].Kind == TokenComment) {
+		last--
+	}
+	return e.sourceText(i, last)
+}
+
+// rowValues returns the values of a row
+func (e *columnExtractor) rowValues(row row) []Value {
+	values := make([]Value, 0, len(row.values))
+	for _, value := range row.values {
+		values = append(values, e.value(value))
+	}
+	return values
+}
+
+// value returns the value made of the tokens at the given indexes
+func (e *columnExtractor) value(indexes []int) Value {
+	tokens := make([]Token, 0, len(indexes))
+	for _, i := range indexes {
+		tokens = append(tokens, e.tokens[i])
+	}
+	value := Value{
+		Kind:   valueKind(tokens),
+		Text:   e.sourceText(indexes[0], indexes[len(indexes)-1]),
+		Tokens: tokens,
+		Pos:    e.tokenPosition(indexes[0]),
+	}
+	if kind, open, ok := e.composite(indexes); ok {
+		value.Kind = kind
+		value.Elements = make([]Value, 0)
+		for _, element := range e.splitList(open+1, indexes[len(indexes)-1]) {
+			value.Elements = append(value.Elements, e.value(element))
+		}
+	}
+	return value
+}
+
+// compositeFunctions maps the functions building composite values to the
+// kind of value they build
+var compositeFunctions = map[string]ValueKind{
+	"array": ValueArray,
+	"tuple": ValueTuple,
+	"map":   ValueMap,
+}
+
+// composite reports whether the tokens at the given indexes are an array,
+// tuple or map, returning the index of the bracket or parenthesis opening
+// its elements, which the last token closes
+func (e *columnExtractor) composite(indexes []int) (ValueKind, int, bool) {
+	first, last := indexes[0], indexes[len(indexes)-1]
+	switch {
+	case e.tokens[first].Value == "[" && e.matchingParenthesis(first) == last:
+		return ValueArray, first, true
+	case e.tokens[first].Value == "(" && e.matchingParenthesis(first) == last &&
+		len(e.splitList(first+1, last)) > 1:
+		return ValueTuple, first, true
+	case len(indexes) > 2 && e.tokens[first].Kind == TokenIdentifier && e.tokens[indexes[1]].Value == "(" &&
+		e.matchingParenthesis(indexes[1]) == last:
+		kind, ok := compositeFunctions[strings.ToLower(e.tokens[first].Value)]
+		return kind, indexes[1], ok
+	}
+	return 0, 0, false
+}
+
+// valueKind classifies a value by its tokens
+func valueKind(tokens []Token) ValueKind {
+	if len(tokens) == 2 && (tokens[0].Value == "-" || tokens[0].Value == "+") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 1 && (tokens[0].Kind == TokenString || tokens[0].Kind == TokenNumber) {
+		return ValueLiteral
+	}
+	if len(tokens) == 1 && tokens[0].IsKeyword("NULL") {
+		return ValueNull
+	}
+	// DEFAULT is commonly used as a name, so it isn't tokenized as a keyword
+	if len(tokens) == 1 && tokens[0].Kind == TokenIdentifier && strings.EqualFold(tokens[0].Value, "DEFAULT") {
+		return ValueDefault
+	}
+	return ValueExpression
+}
+
+// bracketsBalanced reports whether the square brackets between the tokens at
+// first and last pair up, parentheses having been matched already
+func (e *columnExtractor) bracketsBalanced(first, last int) bool {
+	depth := 0
+	for i := first; i <= last && depth >= 0; i++ {
+		switch e.tokens[i].Value {
+		case "[":
+			depth++
+		case "]":
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+// emptyItem returns the index of a comma between the tokens at first and
+// last that follows an opening parenthesis or bracket or another comma, so
+// that a value is missing before it, or -1. splitList would drop the empty
+// value, shifting the values that follow
+func (e *columnExtractor) emptyItem(first, last int) int {
+	previous := ""
+	for i := first; i <= last; i++ {
+		token := e.tokens[i]
+		if token.Kind == TokenComment {
+			continue
+		}
+		if token.Value == "," && (previous == "(" || previous == "[" || previous == ",") {
+			return i
+		}
+		previous = token.Value
+	}
+	return -1
+}
+
+// errorAt returns a SyntaxError positioned at the token at index i
+func (e *columnExtractor) errorAt(i int, format string, args ...any) error {
+	return &SyntaxError{Pos: e.tokenPosition(i), Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,278 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// valueTexts returns the source text of the values of each row
+func valueTexts(rows [][]Value) [][]string {
+	texts := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		text := make([]string, 0, len(row))
+		for _, value := range row {
+			text = append(text, value.Text)
+		}
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+func TestRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		rows  [][]string
+		err   string
+	}{
+		{
+			name:  `literals`,
+			query: `INSERT INTO t (a, b, c) VALUES (1, 'x', NULL), (-2.5, 'it''s, (quoted)', 0x1F)`,
+			rows:  [][]string{{`1`, `'x'`, `NULL`}, {`-2.5`, `'it''s, (quoted)'`, `0x1F`}},
+		},
+		{
+			name:  `functions`,
+			query: `INSERT INTO t VALUES (now(), toDate('2024-01-01'), concat('a', ','))`,
+			rows:  [][]string{{`now()`, `toDate('2024-01-01')`, `concat('a', ',')`}},
+		},
+		{
+			name:  `expressions`,
+			query: `INSERT INTO t VALUES ((1 + 2) * 3, arrayMap(x -> x + 1, [1, 2]), CAST(1 AS UInt8), 1 > 2 ? 'a' : 'b', x::Int8)`,
+			rows:  [][]string{{`(1 + 2) * 3`, `arrayMap(x -> x + 1, [1, 2])`, `CAST(1 AS UInt8)`, `1 > 2 ? 'a' : 'b'`, `x::Int8`}},
+		},
+		{
+			name:  `rows without commas and comments`,
+			query: "INSERT INTO t VALUES (1) /* x */ (2) -- y\n, (3);",
+			rows:  [][]string{{`1`}, {`2`}, {`3`}},
+		},
+		{
+			// invalid UTF-8, replaced in the tokens, is kept in the source text
+			name:  `invalid UTF-8`,
+			query: "INSERT INTO t (a, b) VALUES ('\xff', ['\xfe', 'x'])",
+			rows:  [][]string{{"'\xff'", "['\xfe', 'x']"}},
+		},
+		{
+			name:  `without rows`,
+			query: `INSERT INTO t (a) VALUES`,
+			rows:  [][]string{},
+		},
+		{
+			name:  `without VALUES`,
+			query: `INSERT INTO t SELECT 1`,
+		},
+		{
+			name:  `missing parenthesis`,
+			query: `INSERT INTO t VALUES (1), 2`,
+			err:   `1:27: row 2: expected ( but found 2`,
+		},
+		{
+			name:  `unclosed row`,
+			query: `INSERT INTO t VALUES (1, f(2)`,
+			err:   `1:22: row 1: unclosed parenthesis`,
+		},
+		{
+			name:  `unbalanced brackets`,
+			query: `INSERT INTO t VALUES (1), ([1, 2), (3)`,
+			err:   `1:27: row 2: unbalanced brackets`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insert, err := ParseInsert(tt.query)
+			assert.NoError(t, err)
+			rows, err := insert.Rows()
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.rows == nil {
+				assert.Nil(t, rows)
+				return
+			}
+			assert.Equal(t, tt.rows, valueTexts(rows))
+		})
+	}
+
+	t.Run(`tokens and positions`, func(t *testing.T) {
+		insert, err := ParseInsert("INSERT INTO t VALUES\n(1, f('a'))")
+		assert.NoError(t, err)
+		rows, err := insert.Rows()
+		assert.NoError(t, err)
+		assert.Equal(t, Position{Offset: 25, Line: 2, Column: 5}, rows[0][1].Pos)
+		assert.Equal(t, []Token{
+			{Kind: TokenIdentifier, Value: `f`},
+			{Kind: TokenPunctuation, Value: `(`},
+			{Kind: TokenString, Value: `'a'`},
+			{Kind: TokenPunctuation, Value: `)`},
+		}, rows[0][1].Tokens)
+	})
+}
+
+func TestValueKind(t *testing.T) {
+	insert, err := ParseInsert("INSERT INTO t VALUES (1, -2.5, 'x', now(), 1 + 2, a, DEFAULT, default, `DEFAULT`)")
+	assert.NoError(t, err)
+	rows, err := insert.Rows()
+	assert.NoError(t, err)
+	kinds := make([]ValueKind, 0)
+	for _, value := range rows[0] {
+		kinds = append(kinds, value.Kind)
+	}
+	assert.Equal(t, []ValueKind{
+		ValueLiteral, ValueLiteral, ValueLiteral, ValueExpression, ValueExpression, ValueExpression,
+		ValueDefault, ValueDefault, ValueExpression,
+	}, kinds)
+	assert.Equal(t, `Default`, ValueDefault.String())
+
+	t.Run(`NULL`, func(t *testing.T) {
+		insert, err := ParseInsert("INSERT INTO t VALUES (NULL, null, 'NULL', `NULL`, [NULL], isNull(NULL))")
+		assert.NoError(t, err)
+		rows, err := insert.Rows()
+		assert.NoError(t, err)
+		kinds := make([]ValueKind, 0)
+		for _, value := range rows[0] {
+			kinds = append(kinds, value.Kind)
+		}
+		assert.Equal(t, []ValueKind{ValueNull, ValueNull, ValueLiteral, ValueExpression, ValueArray, ValueExpression}, kinds)
+		assert.Equal(t, ValueNull, rows[0][4].Elements[0].Kind)
+	})
+}
+
+func TestCompositeValues(t *testing.T) {
+	insert, err := ParseInsert(`INSERT INTO t VALUES ([1, [2, 3], 'a,]'], (1, 'a'), map('k', [1], 'l', []), (1), tuple(), f([1]))`)
+	assert.NoError(t, err)
+	rows, err := insert.Rows()
+	assert.NoError(t, err)
+	values := rows[0]
+
+	array := values[0]
+	assert.Equal(t, ValueArray, array.Kind)
+	assert.Equal(t, [][]string{{`1`, `[2, 3]`, `'a,]'`}}, valueTexts([][]Value{array.Elements}))
+	assert.Equal(t, ValueArray, array.Elements[1].Kind)
+	assert.Equal(t, [][]string{{`2`, `3`}}, valueTexts([][]Value{array.Elements[1].Elements}))
+
+	assert.Equal(t, ValueTuple, values[1].Kind)
+	assert.Equal(t, [][]string{{`1`, `'a'`}}, valueTexts([][]Value{values[1].Elements}))
+
+	assert.Equal(t, ValueMap, values[2].Kind)
+	assert.Equal(t, [][]string{{`'k'`, `[1]`, `'l'`, `[]`}}, valueTexts([][]Value{values[2].Elements}))
+	assert.Equal(t, []Value{}, values[2].Elements[3].Elements)
+
+	assert.Equal(t, ValueExpression, values[3].Kind)
+	assert.Nil(t, values[3].Elements)
+	assert.Equal(t, ValueTuple, values[4].Kind)
+	assert.Equal(t, ValueExpression, values[5].Kind)
+}
+
+func TestValidateRows(t *testing.T) {
+	assert.NoError(t, ValidateRows(`INSERT INTO t (a, b) VALUES (1, f(2, 3)), ('x', [1, 2])`))
+	assert.NoError(t, ValidateRows(`INSERT INTO t VALUES (1), (1, 2)`))
+	assert.NoError(t, ValidateRows(`INSERT INTO t (a) SELECT 1, 2`))
+	assert.NoError(t, ValidateRows(`INSERT INTO t (a, b, c) VALUES (now(), toDate('2024-01-01'), 1+2)`))
+
+	err := ValidateRows("INSERT INTO t (a, b) VALUES (1, 2),\n(3), (4, 5, 6)")
+	assert.EqualError(t, err, "2:1: row 2 has 1 values, expected 2\n2:6: row 3 has 3 values, expected 2")
+	var arity *RowArityError
+	assert.ErrorAs(t, err, &arity)
+	assert.Equal(t, 2, arity.Row)
+	assert.Equal(t, 1, arity.Values)
+
+	assert.EqualError(t, ValidateRows(`INSERT INTO t (a) VALUES (1`), `1:26: row 1: unclosed parenthesis`)
+
+	for query, message := range map[string]string{
+		`INSERT INTO t (a, b, c) VALUES (1,,2)`:            `1:35: row 1: missing value`,
+		`INSERT INTO t (a, b) VALUES (1, 2), (, 3)`:        `1:38: row 2: missing value`,
+		`INSERT INTO t (a, b) VALUES (1, [2, /* x */, 3])`: `1:44: row 1: missing value`,
+	} {
+		assert.EqualError(t, ValidateRows(query), message, query)
+	}
+	assert.NoError(t, ValidateRows(`INSERT INTO t (a, b) VALUES (1, (2,)), (',', ',,')`))
+}
+
+func TestSplitValues(t *testing.T) {
+	statements, err := SplitValues("INSERT INTO db.t (a, b) VALUES (1, 'x, (y)'),\n(2, [3, 4]) (5, f(6)), (7, NULL);", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`INSERT INTO db.t (a, b) VALUES (1, 'x, (y)'), (2, [3, 4])`,
+		`INSERT INTO db.t (a, b) VALUES (5, f(6)), (7, NULL)`,
+	}, statements)
+
+	statements, err = SplitValues(`INSERT INTO t VALUES (1), (2), (3)`, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`INSERT INTO t VALUES (1), (2), (3)`}, statements)
+
+	statements, err = SplitValues("INSERT INTO `\xff` VALUES (1), (2)", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"INSERT INTO `\xff` VALUES (1)", "INSERT INTO `\xff` VALUES (2)"}, statements)
+
+	_, err = SplitValues(`INSERT INTO t (a) VALUES`, 1)
+	assert.EqualError(t, err, `1:19: no rows after VALUES`)
+	_, err = SplitValues(`INSERT INTO t (a) VALUES;`, 1)
+	assert.EqualError(t, err, `1:19: no rows after VALUES`)
+
+	_, err = SplitValues(`INSERT INTO t VALUES (1)`, 0)
+	assert.EqualError(t, err, `invalid number of rows per statement: 0`)
+	_, err = SplitValues(`INSERT INTO t SELECT 1`, 1)
+	assert.EqualError(t, err, `no VALUES clause`)
+	_, err = SplitValues(`INSERT INTO t VALUES (1), (2`, 1)
+	assert.EqualError(t, err, `1:27: row 2: unclosed parenthesis`)
+}
+
+func TestOnDuplicateKeyUpdate(t *testing.T) {
+	parser := Parser{Dialect: MySQL}
+	query := "INSERT INTO t (a, b) VALUES (1, 2), (3, 4) # upsert\nON DUPLICATE KEY UPDATE b = VALUES(b);"
+	insert, err := parser.ParseInsert(query)
+	assert.NoError(t, err)
+	rows, err := insert.Rows()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.NoError(t, parser.ValidateRows(query))
+
+	statements, err := parser.SplitValues(query, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`INSERT INTO t (a, b) VALUES (1, 2) ON DUPLICATE KEY UPDATE b = VALUES(b)`,
+		`INSERT INTO t (a, b) VALUES (3, 4) ON DUPLICATE KEY UPDATE b = VALUES(b)`,
+	}, statements)
+
+	header, columns, err := parser.NormalizeForBatch(query)
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO t (a, b) VALUES`, header)
+	assert.Equal(t, []string{`a`, `b`}, columns)
+
+	// ClickHouse has no such clause
+	query = "INSERT INTO t (a, b) VALUES (1, 2), (3, 4) ON DUPLICATE KEY UPDATE b = VALUES(b);"
+	_, err = SplitValues(query, 1)
+	assert.EqualError(t, err, `1:44: row 3: expected ( but found ON`)
+	assert.EqualError(t, ValidateRows(query), `1:44: row 3: expected ( but found ON`)
+}
+
+func TestReturning(t *testing.T) {
+	parser := Parser{Dialect: Postgres}
+	query := `INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4) ON CONFLICT (a) DO NOTHING RETURNING a;`
+	insert, err := parser.ParseInsert(query)
+	assert.NoError(t, err)
+	rows, err := insert.Rows()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.NoError(t, parser.ValidateRows(query))
+
+	statements, err := parser.SplitValues(`INSERT INTO "t" ("a") VALUES ($1), ($2) RETURNING "a"`, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`INSERT INTO "t" ("a") VALUES ($1) RETURNING "a"`,
+		`INSERT INTO "t" ("a") VALUES ($2) RETURNING "a"`,
+	}, statements)
+
+	stmt, err := parser.ParseInsertAST(`INSERT INTO t (a) VALUES ($1) RETURNING id`)
+	assert.NoError(t, err)
+	assert.Equal(t, &RawExpr{From: Position{Offset: 30, Line: 1, Column: 31}, Text: `RETURNING id`}, stmt.Trailer)
+
+	// ClickHouse has neither clause
+	_, err = SplitValues(`INSERT INTO t (a) VALUES (1), (2) RETURNING a`, 1)
+	assert.EqualError(t, err, `1:35: row 3: expected ( but found RETURNING`)
+	assert.EqualError(t, ValidateRows(`INSERT INTO t (a) VALUES (1) ON CONFLICT (a) DO NOTHING`), `1:30: row 2: expected ( but found ON`)
+	_, err = ParseInsert(`INSERT INTO t (a) VALUES ($1) RETURNING id`)
+	assert.EqualError(t, err, `1:27: unexpected rune: $`)
+}
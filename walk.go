@@ -0,0 +1,75 @@
+package clickhouse_go_insert_statement_parsing
+
+// Walk traverses the syntax tree rooted at node in depth-first order, as
+// ast.Inspect does: it calls fn for node and, if fn returns true, walks each
+// of its children in source order. Unlike ast.Inspect, fn is never called
+// with nil after the children
+func Walk(node Node, fn func(Node) bool) {
+	if isNilNode(node) || !fn(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *InsertStmt:
+		if n.Table != nil {
+			Walk(n.Table, fn)
+		}
+		if n.Function != nil {
+			Walk(n.Function, fn)
+		}
+		if n.Columns != nil {
+			Walk(n.Columns, fn)
+		}
+		walkNodes(n.Settings, fn)
+		if n.Source != nil {
+			Walk(n.Source, fn)
+		}
+		if n.Format != nil {
+			Walk(n.Format, fn)
+		}
+		if n.Trailer != nil {
+			Walk(n.Trailer, fn)
+		}
+	case *TableName:
+		if n.Database != nil {
+			Walk(n.Database, fn)
+		}
+		Walk(n.Table, fn)
+	case *ColumnList:
+		walkNodes(n.Columns, fn)
+	case *Setting:
+		Walk(n.Name, fn)
+		Walk(n.Value, fn)
+	case *ValuesSource:
+		walkNodes(n.Rows, fn)
+	case *Row:
+		walkNodes(n.Values, fn)
+	case *InfileSource:
+		Walk(n.File, fn)
+		if n.Compression != nil {
+			Walk(n.Compression, fn)
+		}
+	case *Call:
+		Walk(n.Name, fn)
+		walkNodes(n.Args, fn)
+	case *ArrayLit:
+		walkNodes(n.Elems, fn)
+	case *TupleLit:
+		walkNodes(n.Elems, fn)
+	case *ParenExpr:
+		Walk(n.X, fn)
+	case *UnaryExpr:
+		Walk(n.X, fn)
+	case *BinaryExpr:
+		Walk(n.X, fn)
+		Walk(n.Y, fn)
+	}
+	// *SelectSource, *DataSource, *Ident, *BasicLit, *Param and *RawExpr
+	// have no children
+}
+
+// walkNodes walks each of nodes in order
+func walkNodes[N Node](nodes []N, fn func(Node) bool) {
+	for _, node := range nodes {
+		Walk(node, fn)
+	}
+}
@@ -0,0 +1,58 @@
+package clickhouse_go_insert_statement_parsing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	stmt, err := ParseInsertAST(`INSERT INTO db.t (a, b) SETTINGS async_insert = 1 VALUES (1, 'x'), (?, concat('y', {p:String}))`)
+	assert.NoError(t, err)
+
+	t.Run(`order`, func(t *testing.T) {
+		kinds := make([]string, 0)
+		Walk(stmt, func(node Node) bool {
+			switch n := node.(type) {
+			case *Ident:
+				kinds = append(kinds, n.Name)
+			case *BasicLit:
+				kinds = append(kinds, n.Value)
+			case *Param:
+				kinds = append(kinds, n.Value)
+			}
+			return true
+		})
+		assert.Equal(t, []string{`db`, `t`, `a`, `b`, `async_insert`, `1`, `1`, `'x'`, `?`, `concat`, `'y'`, `{p:String}`}, kinds)
+	})
+
+	t.Run(`string literals`, func(t *testing.T) {
+		literals := make([]string, 0)
+		Walk(stmt, func(node Node) bool {
+			if lit, ok := node.(*BasicLit); ok && lit.Kind == TokenString {
+				literals = append(literals, lit.Value)
+			}
+			return true
+		})
+		assert.Equal(t, []string{`'x'`, `'y'`}, literals)
+	})
+
+	t.Run(`pruning`, func(t *testing.T) {
+		visited := 0
+		Walk(stmt, func(node Node) bool {
+			visited++
+			_, isSource := node.(*ValuesSource)
+			return !isSource
+		})
+		// InsertStmt, TableName and 2 Idents, ColumnList and 2 Idents,
+		// Setting with Ident and BasicLit, ValuesSource
+		assert.Equal(t, 11, visited)
+	})
+
+	t.Run(`nil`, func(t *testing.T) {
+		Walk((*InsertStmt)(nil), func(Node) bool {
+			t.Fail()
+			return true
+		})
+	})
+}